@@ -3,64 +3,82 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"cloudflare-domain-controller/core"
 	"github.com/spf13/cobra"
 )
 
-var addCmd = &cobra.Command{
-	Use:   "add [subdominio]",
-	Short: "Agrega un nuevo registro DNS",
-	Long: `Agrega un nuevo registro DNS para el subdominio especificado.
-Ejemplo: cloudflare-domain-controller add mipagina --type A --content 192.168.1.1`,
-	Args: cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		subdomain := args[0]
-		recordType, _ := cmd.Flags().GetString("type")
-		content, _ := cmd.Flags().GetString("content")
-		
-		// Crear cliente de Cloudflare
-		config := core.NewConfig()
-		// Validar configuración
-		if err := config.Validate(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error de configuración: %v\n", err)
-			os.Exit(1)
-		}
-		
-		client := core.NewCloudflareClient(config)
-		
-		// Construir el nombre completo del registro
-		fullName := subdomain
-		if config.DomainName != "" {
-			// Verificar si el subdominio ya incluye el dominio
-			if len(subdomain) <= len(config.DomainName) || (len(subdomain) > len(config.DomainName) && subdomain[len(subdomain)-len(config.DomainName)-1:] != "."+config.DomainName) {
-				fullName = subdomain + "." + config.DomainName
+// newAddCmd crea el comando add
+func newAddCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add [subdominio]",
+		Short: "Agrega un nuevo registro DNS",
+		Long: `Agrega un nuevo registro DNS para el subdominio especificado.
+Con --expires el registro es temporal: se marca con su fecha de caducidad y 'gc' lo elimina
+cuando caduca.
+Ejemplo: cloudflare-domain-controller add mipagina --type A --content 192.168.1.1
+Ejemplo: cloudflare-domain-controller add _verificacion --type TXT --content abc123 --expires 24h`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			subdomain := args[0]
+			recordType, _ := cmd.Flags().GetString("type")
+			content, _ := cmd.Flags().GetString("content")
+			replace, _ := cmd.Flags().GetBool("replace")
+			expires, _ := cmd.Flags().GetDuration("expires")
+
+			// Crear cliente de Cloudflare
+			config := core.NewConfig()
+			// Validar configuración
+			if err := config.Validate(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error de configuración: %v\n", err)
+				exit(1)
 			}
-		}
-		
-		// Crear el registro DNS
-		record := &core.DNSRecord{
-			Name:    fullName,
-			Type:    recordType,
-			Content: content,
-			TTL:     1, // Auto
-			Proxied: false,
-		}
-		
-		// Intentar crear el registro
-		if err := client.CreateDNSRecord(record); err != nil {
-			fmt.Fprintf(os.Stderr, "Error al agregar el registro DNS: %v\n", err)
-			os.Exit(1)
-		}
-		
-		fmt.Printf("Registro DNS para %s agregado exitosamente\n", subdomain)
-	},
-}
 
-func init() {
-	rootCmd.AddCommand(addCmd)
-	addCmd.Flags().StringP("type", "t", "A", "Tipo de registro DNS (A, CNAME, etc.)")
-	addCmd.Flags().StringP("content", "c", "", "Contenido del registro DNS (IP o CNAME)")
+			client := a.client(cmd, config)
+
+			// Construir el nombre completo del registro
+			fullName := resolveName(config, subdomain)
+
+			// Convertir los nombres IDN y dividir los TXT largos en cadenas de 255 caracteres
+			content = normalizeContent(recordType, content)
+
+			// Crear el registro DNS
+			record := &core.DNSRecord{
+				Name:    fullName,
+				Type:    recordType,
+				Content: content,
+				TTL:     1, // Auto
+				Proxied: false,
+			}
+			if expires > 0 {
+				core.SetExpiry(record, time.Now().Add(expires))
+			}
+
+			// Comprobar los conflictos con los registros existentes antes de crear el registro
+			if !resolveConflicts(client, config, record, nil, replace) {
+				if err := client.CreateDNSRecord(record); err != nil {
+					fmt.Fprintf(os.Stderr, "Error al agregar el registro DNS: %v\n", err)
+					exit(1)
+				}
+				recordJournal(config, core.AuditCreate, nil, record)
+			}
+
+			fmt.Printf("Registro DNS para %s agregado exitosamente\n", subdomain)
+			if expiry, ok := core.RecordExpiry(record); ok {
+				fmt.Printf("El registro caduca el %s\n", expiry.Local().Format("2006-01-02 15:04:05"))
+			}
+
+			// Esperar a que el registro se sirva en los servidores autoritativos
+			waitIfRequested(cmd, config, client, record)
+		},
+	}
+	cmd.Flags().StringP("type", "t", "A", "Tipo de registro DNS (A, CNAME, etc.)")
+	cmd.Flags().StringP("content", "c", "", "Contenido del registro DNS (IP o CNAME)")
+	addWaitFlags(cmd)
+	cmd.Flags().Bool("replace", false, "Eliminar los registros en conflicto en la misma operación")
+	cmd.Flags().Duration("expires", 0, "Crear un registro temporal que 'gc' eliminará pasado este tiempo (por ejemplo 24h)")
 	// Requerir el flag 'content'
-	addCmd.MarkFlagRequired("content")
-}
\ No newline at end of file
+	cmd.MarkFlagRequired("content")
+	return cmd
+}
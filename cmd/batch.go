@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"cloudflare-domain-controller/core"
+	"github.com/spf13/cobra"
+)
+
+// newBatchCmd crea el comando batch
+func newBatchCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "batch [archivo]",
+		Short: "Aplica una lista de operaciones en una sola solicitud",
+		Long: `Lee una lista de operaciones desde un archivo (o desde la entrada estándar si se omite
+o se indica '-') y las aplica mediante el endpoint batch de Cloudflare, de forma atómica.
+Si el endpoint no está disponible las operaciones se aplican una a una.
+
+Cada línea es un objeto JSON con los campos action (create, update o delete), name,
+type, content, ttl, proxied y match (contenido actual, para elegir entre varios registros):
+  {"action":"create","name":"mipagina","type":"A","content":"192.168.1.1"}
+  {"action":"update","name":"api","type":"A","content":"192.168.1.2"}
+  {"action":"delete","name":"viejo","type":"CNAME"}
+
+Ejemplo: cloudflare-domain-controller batch cambios.jsonl --dry-run`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			sequential, _ := cmd.Flags().GetBool("sequential")
+
+			config := core.NewConfig()
+			// Validar configuración
+			if err := config.Validate(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error de configuración: %v\n", err)
+				exit(1)
+			}
+			client := a.client(cmd, config)
+
+			// Leer las operaciones del archivo o de la entrada estándar
+			var input io.Reader = os.Stdin
+			if len(args) == 1 && args[0] != "-" {
+				file, err := os.Open(args[0])
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error al abrir el archivo de operaciones: %v\n", err)
+					exit(1)
+				}
+				defer file.Close()
+				input = file
+			}
+			ops, err := core.ReadBatchOperations(input)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al leer las operaciones: %v\n", err)
+				exit(1)
+			}
+
+			// Obtener la zona una sola vez para resolver todos los nombres
+			existing, err := client.ListDNSRecords()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al obtener los registros DNS: %v\n", err)
+				exit(1)
+			}
+			plan, err := config.PlanBatch(ops, existing)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error en las operaciones: %v\n", err)
+				exit(1)
+			}
+
+			printBatchPlan(plan)
+			if dryRun || plan.Len() == 0 {
+				return
+			}
+
+			var result *core.BatchResult
+			if !sequential {
+				result, err = client.BatchDNSRecords(plan)
+				if errors.Is(err, core.ErrBatchUnsupported) {
+					fmt.Fprintln(os.Stderr, "Advertencia: el endpoint batch no está disponible; las operaciones se aplican una a una y no son atómicas")
+					sequential = true
+				}
+			}
+			if sequential {
+				result, err = core.ApplyBatchSequentially(client, plan)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al aplicar las operaciones: %v\n", err)
+				if result != nil {
+					fmt.Fprintf(os.Stderr, "Operaciones aplicadas antes del error: %d eliminados, %d actualizados, %d creados\n",
+						len(result.Deletes), len(result.Patches)+len(result.Puts), len(result.Posts))
+				}
+				exit(1)
+			}
+
+			fmt.Printf("Operaciones aplicadas exitosamente: %d eliminados, %d actualizados, %d creados\n",
+				len(result.Deletes), len(result.Patches)+len(result.Puts), len(result.Posts))
+		},
+	}
+	cmd.Flags().Bool("dry-run", false, "Mostrar las operaciones sin aplicarlas")
+	cmd.Flags().Bool("sequential", false, "Aplicar las operaciones una a una en lugar de usar el endpoint batch")
+	return cmd
+}
+
+// printBatchPlan muestra las operaciones que se van a aplicar
+func printBatchPlan(plan *core.BatchOperations) {
+	for _, record := range plan.Deletes {
+		fmt.Printf("- %-30s %-6s %s\n", record.Name, record.Type, record.Content)
+	}
+	for _, record := range plan.Patches {
+		fmt.Printf("~ %-30s %-6s %s\n", record.Name, record.Type, record.Content)
+	}
+	for _, record := range plan.Puts {
+		fmt.Printf("~ %-30s %-6s %s\n", record.Name, record.Type, record.Content)
+	}
+	for _, record := range plan.Posts {
+		fmt.Printf("+ %-30s %-6s %s\n", record.Name, record.Type, record.Content)
+	}
+	fmt.Printf("%d operaciones\n", plan.Len())
+}
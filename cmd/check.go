@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"cloudflare-domain-controller/core"
+	"github.com/spf13/cobra"
+)
+
+// newCheckCmd crea el comando check
+func newCheckCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check [subdominio]",
+		Short: "Comprueba si un registro DNS ya se sirve en los servidores autoritativos",
+		Long: `Consulta el registro directamente en los servidores de nombres autoritativos de la zona
+y muestra el estado de cada uno. Si no se indica --content se usa el contenido actual del registro
+en Cloudflare. Con --wait repite las consultas hasta que todos los servidores lo devuelven.
+
+Los servidores se obtienen de Cloudflare, salvo que se indiquen con --nameserver o con la
+variable CLOUDFLARE_NAMESERVERS.
+Ejemplo: cloudflare-domain-controller check mipagina --type A --wait --timeout 2m`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			recordType, _ := cmd.Flags().GetString("type")
+			content, _ := cmd.Flags().GetString("content")
+			wait, _ := cmd.Flags().GetBool("wait")
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+			interval, _ := cmd.Flags().GetDuration("interval")
+			nameservers, _ := cmd.Flags().GetStringSlice("nameserver")
+
+			config := core.NewConfig()
+			if len(nameservers) > 0 {
+				config.Nameservers = nameservers
+			}
+			client := a.client(cmd, config)
+			fullName := resolveName(config, args[0])
+			recordType = strings.ToUpper(recordType)
+
+			record := &core.DNSRecord{Name: fullName, Type: recordType, Content: normalizeContent(recordType, content)}
+			if content == "" {
+				// Usar el registro actual de Cloudflare como respuesta esperada
+				records, err := client.FindDNSRecords(fullName, recordType)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error al obtener el registro DNS: %v\n", err)
+					exit(1)
+				}
+				if len(records) != 1 {
+					fmt.Fprintf(os.Stderr, "Se encontraron %d registros %s para %s; indica el contenido esperado con --content\n", len(records), recordType, fullName)
+					exit(1)
+				}
+				record = records[0]
+			}
+
+			if !wait {
+				timeout = 0
+			}
+			if !waitForRecord(cmd.Context(), config, client, record, timeout, interval) {
+				exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringP("type", "t", "A", "Tipo de registro DNS (A, CNAME, etc.)")
+	cmd.Flags().StringP("content", "c", "", "Contenido esperado (por defecto, el registro actual en Cloudflare)")
+	cmd.Flags().Bool("wait", false, "Repetir las consultas hasta que todos los servidores devuelvan el registro")
+	cmd.Flags().Duration("timeout", core.DefaultWaitTimeout, "Tiempo máximo de espera con --wait")
+	cmd.Flags().Duration("interval", core.DefaultWaitInterval, "Intervalo entre consultas con --wait")
+	cmd.Flags().StringSlice("nameserver", nil, "Servidor DNS a consultar (host o host:puerto); se puede repetir")
+	return cmd
+}
+
+// waitForRecord consulta el registro en los servidores de nombres y muestra el estado de cada uno.
+// Con timeout mayor que cero repite las consultas hasta que el registro se propaga, vence el plazo
+// o se cancela ctx. Devuelve true si todos los servidores sirven el registro.
+func waitForRecord(ctx context.Context, config *core.Config, client core.DNSProvider, record *core.DNSRecord, timeout, interval time.Duration) bool {
+	servers, err := core.Nameservers(config, client)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error al obtener los servidores de nombres: %v\n", err)
+		return false
+	}
+
+	var statuses []core.PropagationStatus
+	if timeout > 0 {
+		fmt.Printf("Esperando a que %s %s se propague a %d servidores (máximo %s)...\n", record.Name, record.Type, len(servers), timeout)
+		waitCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		statuses, err = core.WaitForPropagation(waitCtx, servers, record, interval)
+	} else {
+		statuses = core.CheckPropagation(ctx, servers, record)
+	}
+
+	for _, status := range statuses {
+		switch {
+		case status.Err != nil:
+			fmt.Printf("✗ %-30s error: %v\n", status.Server, status.Err)
+		case status.Matched:
+			fmt.Printf("✓ %-30s %s\n", status.Server, strings.Join(status.Answers, ", "))
+		case len(status.Answers) == 0:
+			fmt.Printf("✗ %-30s sin respuesta\n", status.Server)
+		default:
+			fmt.Printf("✗ %-30s %s\n", status.Server, strings.Join(status.Answers, ", "))
+		}
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return false
+	}
+	if !core.Propagated(statuses) {
+		fmt.Fprintln(os.Stderr, "El registro todavía no se sirve en todos los servidores")
+		return false
+	}
+	fmt.Println("El registro se sirve en todos los servidores")
+	return true
+}
+
+// addWaitFlags agrega las opciones para esperar la propagación tras aplicar un cambio
+func addWaitFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("wait", false, "Esperar a que el registro se sirva en los servidores autoritativos")
+	cmd.Flags().Duration("wait-timeout", core.DefaultWaitTimeout, "Tiempo máximo de espera con --wait")
+}
+
+// waitIfRequested espera la propagación del registro si se indicó --wait y termina con un error si no se propaga
+func waitIfRequested(cmd *cobra.Command, config *core.Config, client core.DNSProvider, record *core.DNSRecord) {
+	wait, _ := cmd.Flags().GetBool("wait")
+	if !wait {
+		return
+	}
+	timeout, _ := cmd.Flags().GetDuration("wait-timeout")
+	if !waitForRecord(cmd.Context(), config, client, record, timeout, core.DefaultWaitInterval) {
+		exit(1)
+	}
+}
@@ -0,0 +1,432 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"cloudflare-domain-controller/core"
+	"cloudflare-domain-controller/core/coretest"
+)
+
+// exitCode es el valor con el que se interrumpe un comando que llama a exit durante las pruebas
+type exitCode int
+
+// result es el resultado de ejecutar la CLI
+type result struct {
+	stdout string
+	stderr string
+	code   int
+}
+
+// testZone es una zona de prueba de coretest: el backend en memoria, que los comandos usan
+// directamente como core.DNSProvider, o el servidor HTTP, que usan con el cliente real
+type testZone interface {
+	Provider(config *core.Config) core.DNSProvider
+	Add(record core.DNSRecord) string
+	Records() []*core.DNSRecord
+	Record(id string) *core.DNSRecord
+	FailRequests(n, status int)
+}
+
+// newTestServer crea la zona falsa publicada por HTTP y configura el entorno de la CLI para usarla
+func newTestServer(t *testing.T) *coretest.Server {
+	t.Helper()
+	server := coretest.NewServer(t, "example.com")
+	server.SetEnv(t)
+	return server
+}
+
+// forEachZone ejecuta la prueba contra el backend en memoria y contra el servidor HTTP, de
+// modo que los comandos se comportan igual con cualquier core.DNSProvider
+func forEachZone(t *testing.T, test func(t *testing.T, zone testZone)) {
+	t.Run("backend", func(t *testing.T) {
+		backend := coretest.NewBackend("example.com")
+		backend.SetEnv(t)
+		test(t, backend)
+	})
+	t.Run("server", func(t *testing.T) {
+		test(t, newTestServer(t))
+	})
+}
+
+// run ejecuta la CLI con los argumentos indicados contra la zona de prueba. Cada ejecución
+// crea un árbol de comandos nuevo, así que los flags no se arrastran entre llamadas.
+func run(t *testing.T, zone testZone, args ...string) result {
+	t.Helper()
+
+	restoreStdout := capture(t, &os.Stdout)
+	restoreStderr := capture(t, &os.Stderr)
+	code := func() (code int) {
+		previous := exit
+		exit = func(code int) { panic(exitCode(code)) }
+		defer func() {
+			exit = previous
+			if r := recover(); r != nil {
+				value, ok := r.(exitCode)
+				if !ok {
+					panic(r)
+				}
+				code = int(value)
+			}
+		}()
+
+		root := NewRootCommand(zone.Provider)
+		root.SetArgs(args)
+		if err := root.ExecuteContext(context.Background()); err != nil {
+			return 1
+		}
+		return 0
+	}()
+	return result{stdout: restoreStdout(), stderr: restoreStderr(), code: code}
+}
+
+// capture redirige una salida estándar a una tubería y devuelve una función que la restaura
+// y devuelve lo escrito
+func capture(t *testing.T, file **os.File) func() string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := *file
+	*file = w
+
+	var buf bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		io.Copy(&buf, r)
+		close(done)
+	}()
+	return func() string {
+		*file = original
+		w.Close()
+		<-done
+		r.Close()
+		return buf.String()
+	}
+}
+
+func TestAddUpdateDelete(t *testing.T) {
+	forEachZone(t, func(t *testing.T, zone testZone) {
+		res := run(t, zone, "add", "web", "--type", "A", "--content", "192.0.2.1")
+		if res.code != 0 || !strings.Contains(res.stdout, "Registro DNS para web agregado exitosamente") {
+			t.Fatalf("add: %+v", res)
+		}
+		records := zone.Records()
+		if len(records) != 1 || records[0].Name != "web.example.com" || records[0].Content != "192.0.2.1" {
+			t.Fatalf("Registros tras add: %v", records)
+		}
+
+		res = run(t, zone, "update", "web", "--type", "A", "--content", "192.0.2.2")
+		if res.code != 0 {
+			t.Fatalf("update: %+v", res)
+		}
+		if got := zone.Record(records[0].ID); got.Content != "192.0.2.2" {
+			t.Errorf("Contenido tras update: %s", got.Content)
+		}
+
+		res = run(t, zone, "list")
+		if res.code != 0 || !strings.Contains(res.stdout, "Registros DNS encontrados (1)") || !strings.Contains(res.stdout, "192.0.2.2") {
+			t.Errorf("list: %+v", res)
+		}
+
+		res = run(t, zone, "delete", "web")
+		if res.code != 0 || len(zone.Records()) != 0 {
+			t.Fatalf("delete: %+v, quedan %d registros", res, len(zone.Records()))
+		}
+
+		// Las tres operaciones quedan en el diario y undo restaura el registro eliminado
+		res = run(t, zone, "undo")
+		if res.code != 0 || !strings.Contains(res.stdout, "deshecha exitosamente") {
+			t.Fatalf("undo: %+v", res)
+		}
+		if records := zone.Records(); len(records) != 1 || records[0].Content != "192.0.2.2" {
+			t.Errorf("Registros tras undo: %v", records)
+		}
+	})
+}
+
+func TestJournalStoresAPIRecords(t *testing.T) {
+	forEachZone(t, func(t *testing.T, zone testZone) {
+		id := zone.Add(core.DNSRecord{Name: "web.example.com", Type: "A", Content: "192.0.2.1", TTL: 300})
+
+		// El tipo se envía en minúsculas y la API lo guarda normalizado
+		if res := run(t, zone, "update", "web", "--type", "a", "--content", "192.0.2.2"); res.code != 0 {
+			t.Fatalf("update: %+v", res)
+		}
+		if res := run(t, zone, "add", "api", "--type", "a", "--content", "192.0.2.3"); res.code != 0 {
+			t.Fatalf("add: %+v", res)
+		}
+		if res := run(t, zone, "proxy", "on", "api"); res.code != 0 {
+			t.Fatalf("proxy: %+v", res)
+		}
+		journal, err := core.OpenJournal(core.NewConfig())
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries, err := journal.Entries()
+		if err != nil || len(entries) != 3 {
+			t.Fatalf("Entradas del diario: %v, %v", entries, err)
+		}
+		if after := entries[0].After; after == nil || after.ID != id || after.Type != "A" || after.Content != "192.0.2.2" || after.TTL != 300 {
+			t.Errorf("El diario debe guardar el registro actualizado devuelto por la API: %+v", after)
+		}
+		if after := entries[1].After; after == nil || after.ID == "" || after.Type != "A" {
+			t.Errorf("El diario debe guardar el registro creado devuelto por la API: %+v", after)
+		}
+		if after := entries[2].After; after == nil || !after.Proxied || after.Type != "A" {
+			t.Errorf("El diario debe guardar el registro devuelto por el batch: %+v", after)
+		}
+
+		// Los registros del diario coinciden con los de la zona, así que se pueden deshacer
+		for i := 0; i < 3; i++ {
+			if res := run(t, zone, "undo"); res.code != 0 {
+				t.Fatalf("undo %d: %+v", i, res)
+			}
+		}
+		if records := zone.Records(); len(records) != 1 || records[0].Content != "192.0.2.1" {
+			t.Errorf("Registros tras deshacer: %+v", records)
+		}
+	})
+}
+
+func TestGCJournal(t *testing.T) {
+	forEachZone(t, func(t *testing.T, zone testZone) {
+		expired := core.DNSRecord{Name: "temp.example.com", Type: "TXT", Content: "abc", TTL: 1}
+		core.SetExpiry(&expired, time.Now().Add(-time.Hour))
+		zone.Add(expired)
+
+		// Una ejecución puntual guarda las eliminaciones en el diario
+		if res := run(t, zone, "gc"); res.code != 0 || len(zone.Records()) != 0 {
+			t.Fatalf("gc: %+v", res)
+		}
+		config := core.NewConfig()
+		journal, err := core.OpenJournal(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entries, _ := journal.Entries(); len(entries) != 1 {
+			t.Fatalf("Entradas del diario tras gc: %+v", entries)
+		}
+
+		// Las pasadas del modo servicio no
+		zone.Add(expired)
+		restore := capture(t, &os.Stdout)
+		ok := collectExpired(context.Background(), config, zone.Provider(config), 1, false, false)
+		restore()
+		if !ok || len(zone.Records()) != 0 {
+			t.Fatalf("El recolector no eliminó el registro: %v, %+v", ok, zone.Records())
+		}
+		if entries, _ := journal.Entries(); len(entries) != 1 {
+			t.Errorf("El modo servicio no debe guardar sus eliminaciones en el diario: %+v", entries)
+		}
+	})
+}
+
+func TestAddErrors(t *testing.T) {
+	forEachZone(t, func(t *testing.T, zone testZone) {
+		zone.Add(core.DNSRecord{Name: "www.example.com", Type: "CNAME", Content: "web.example.com", TTL: 1})
+
+		res := run(t, zone, "add", "www", "--type", "A", "--content", "192.0.2.1")
+		if res.code != 1 || res.stderr == "" {
+			t.Errorf("Un registro en conflicto debe fallar: %+v", res)
+		}
+
+		res = run(t, zone, "add", "web", "--type", "A", "--content", "no-es-una-ip")
+		if res.code != 1 || !strings.Contains(res.stderr, "Error al agregar el registro DNS") {
+			t.Errorf("Un registro inválido debe fallar: %+v", res)
+		}
+
+		zone.FailRequests(1, 500)
+		res = run(t, zone, "list")
+		if res.code != 1 || !strings.Contains(res.stderr, "500") {
+			t.Errorf("Un error de la API debe fallar: %+v", res)
+		}
+		if len(zone.Records()) != 1 {
+			t.Errorf("Los errores no deben modificar la zona: %v", zone.Records())
+		}
+
+		res = run(t, zone, "add", "web")
+		if res.code != 1 || !strings.Contains(res.stderr, "content") {
+			t.Errorf("Falta un flag obligatorio: %+v", res)
+		}
+	})
+}
+
+func TestUpsertExitCodes(t *testing.T) {
+	forEachZone(t, func(t *testing.T, zone testZone) {
+		tests := []struct {
+			content string
+			code    int
+			output  string
+		}{
+			{"192.0.2.1", upsertExitCreated, "created web.example.com A 192.0.2.1"},
+			{"192.0.2.1", upsertExitUnchanged, "unchanged web.example.com A 192.0.2.1"},
+			{"192.0.2.2", upsertExitUpdated, "updated web.example.com A 192.0.2.1 -> 192.0.2.2"},
+		}
+		for _, test := range tests {
+			res := run(t, zone, "upsert", "web", "--content", test.content)
+			if res.code != test.code || strings.TrimSpace(res.stdout) != test.output {
+				t.Errorf("upsert %s: código %d, salida %q", test.content, res.code, res.stdout)
+			}
+		}
+		if len(zone.Records()) != 1 {
+			t.Errorf("upsert no debe duplicar registros: %v", zone.Records())
+		}
+	})
+}
+
+func TestRRSetReplace(t *testing.T) {
+	server := newTestServer(t)
+	server.Add(core.DNSRecord{Name: "web.example.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+	server.Add(core.DNSRecord{Name: "web.example.com", Type: "A", Content: "192.0.2.2", TTL: 1})
+
+	res := run(t, server, "rrset", "replace", "web", "192.0.2.2", "192.0.2.3", "--dry-run")
+	if res.code != 0 || len(server.Records()) != 2 {
+		t.Fatalf("--dry-run no debe aplicar cambios: %+v", res)
+	}
+	for _, req := range server.Requests() {
+		if req.Method != "GET" {
+			t.Errorf("--dry-run envió %s %s", req.Method, req.Path)
+		}
+	}
+
+	res = run(t, server, "rrset", "replace", "web", "192.0.2.2", "192.0.2.3", "--yes")
+	if res.code != 0 {
+		t.Fatalf("rrset replace: %+v", res)
+	}
+	var contents []string
+	for _, record := range server.Records() {
+		contents = append(contents, record.Content)
+	}
+	if strings.Join(contents, ",") != "192.0.2.2,192.0.2.3" {
+		t.Errorf("Conjunto tras replace: %v", contents)
+	}
+
+	// Los cambios se aplican en una única solicitud batch
+	batches := 0
+	for _, req := range server.Requests() {
+		if strings.HasSuffix(req.Path, "/dns_records/batch") {
+			batches++
+		}
+	}
+	if batches != 1 {
+		t.Errorf("Se esperaba una solicitud batch y hubo %d", batches)
+	}
+}
+
+func TestBatchUnsupported(t *testing.T) {
+	server := newTestServer(t)
+	server.DisableBatch()
+	server.Add(core.DNSRecord{Name: "web.example.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+
+	// rrset no aplica ningún cambio si no puede hacerlo de forma atómica
+	res := run(t, server, "rrset", "replace", "web", "192.0.2.2", "--yes")
+	if res.code != 1 || !strings.Contains(res.stderr, "no se aplicó ningún cambio") {
+		t.Fatalf("rrset replace sin batch: %+v", res)
+	}
+	if records := server.Records(); len(records) != 1 || records[0].Content != "192.0.2.1" {
+		t.Errorf("El conjunto no debía cambiar: %v", records)
+	}
+
+	// batch avisa de que las operaciones se aplican una a una
+	file := filepath.Join(t.TempDir(), "ops.jsonl")
+	if err := os.WriteFile(file, []byte(`{"action":"create","name":"nuevo","type":"A","content":"192.0.2.4"}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res = run(t, server, "batch", file)
+	if res.code != 0 || !strings.Contains(res.stderr, "no son atómicas") || len(server.Records()) != 2 {
+		t.Errorf("batch sin endpoint batch: %+v", res)
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	forEachZone(t, func(t *testing.T, zone testZone) {
+		zone.Add(core.DNSRecord{Name: "web.example.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+
+		if res := run(t, zone, "snapshot", "save", "base"); res.code != 0 {
+			t.Fatalf("snapshot save: %+v", res)
+		}
+		zone.Add(core.DNSRecord{Name: "temporal.example.com", Type: "TXT", Content: "\"x\"", TTL: 1})
+
+		// El registro en vivo tiene un comentario y etiquetas que la instantánea no tiene
+		web := zone.Records()[0]
+		web.Comment = "temporal"
+		web.Tags = []string{"demo"}
+		if err := zone.Provider(core.NewConfig()).UpdateDNSRecord(web.ID, web); err != nil {
+			t.Fatal(err)
+		}
+
+		res := run(t, zone, "snapshot", "diff", "base")
+		if res.code != 0 || !strings.Contains(res.stdout, "- temporal.example.com") ||
+			!strings.Contains(res.stdout, `192.0.2.1: comentario "temporal" -> "", etiquetas [demo] -> []`) {
+			t.Errorf("snapshot diff: %+v", res)
+		}
+		if res := run(t, zone, "snapshot", "restore", "base"); res.code != 0 {
+			t.Fatalf("snapshot restore: %+v", res)
+		}
+		records := zone.Records()
+		if len(records) != 1 || records[0].Name != "web.example.com" || records[0].Comment != "" || len(records[0].Tags) != 0 {
+			t.Errorf("Registros tras restore: %+v", records)
+		}
+		if res := run(t, zone, "snapshot", "diff", "base"); res.code != 0 || !strings.Contains(res.stdout, "Sin cambios.") {
+			t.Errorf("La zona debía coincidir con la instantánea tras restore: %+v", res)
+		}
+	})
+}
+
+func TestEmptySelector(t *testing.T) {
+	server := newTestServer(t)
+	server.Add(core.DNSRecord{Name: "web.example.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+	server.Add(core.DNSRecord{Name: "api.example.com", Type: "A", Content: "192.0.2.2", TTL: 1})
+
+	for _, args := range [][]string{
+		{"delete", "--regex", "", "--yes"},
+		{"delete", "--match", "", "--yes"},
+		{"delete", "--where-content", "", "--type", "A", "--yes"},
+		{"update", "--regex", "", "--content", "192.0.2.9", "--yes"},
+	} {
+		res := run(t, server, args...)
+		if res.code != 1 || !strings.Contains(res.stderr, "Error en la selección") {
+			t.Errorf("%v debe fallar: %+v", args, res)
+		}
+	}
+	for _, req := range server.Requests() {
+		if req.Method != "GET" {
+			t.Errorf("Un selector vacío no debe modificar la zona: %s %s", req.Method, req.Path)
+		}
+	}
+	if len(server.Records()) != 2 {
+		t.Errorf("Registros tras los intentos: %v", server.Records())
+	}
+
+	res := run(t, server, "delete", "--regex", "^web$", "--yes")
+	if res.code != 0 || len(server.Records()) != 1 {
+		t.Errorf("delete --regex: %+v, registros %v", res, server.Records())
+	}
+}
+
+func TestDaemonFlagValidation(t *testing.T) {
+	server := newTestServer(t)
+	for _, args := range [][]string{
+		{"scheduler", "--interval", "0s"},
+		{"gc", "--daemon", "--interval", "-1m"},
+		{"failover", "web", "--origin", "192.0.2.1", "--interval", "0s"},
+		{"failover", "web", "--origin", "192.0.2.1", "--rise", "0"},
+		{"failover", "web", "--origin", "192.0.2.1", "--fall", "0"},
+	} {
+		res := run(t, server, args...)
+		if res.code != 1 || !strings.Contains(res.stderr, "Error") {
+			t.Errorf("%v debe fallar: %+v", args, res)
+		}
+	}
+	if requests := server.Requests(); len(requests) != 0 {
+		t.Errorf("La validación debe ocurrir antes de llamar a la API: %v", requests)
+	}
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"cloudflare-domain-controller/core"
+	"github.com/spf13/cobra"
+)
+
+// ClientFactory crea el proveedor DNS que usan los comandos a partir de la configuración
+type ClientFactory func(config *core.Config) core.DNSProvider
+
+// newCloudflareClient es el ClientFactory por defecto: el cliente de la API de Cloudflare
+func newCloudflareClient(config *core.Config) core.DNSProvider {
+	return core.NewCloudflareClient(config)
+}
+
+// app agrupa las dependencias compartidas por los comandos
+type app struct {
+	newClient ClientFactory
+	// auditors son los registros de auditoría abiertos por client, que se cierran al terminar el comando
+	auditors []*core.Auditor
+}
+
+// client crea el proveedor DNS con el registro de auditoría configurado, si el proveedor lo
+// admite. Las solicitudes usan el contexto del comando, que incluye la traza del llamante.
+func (a *app) client(cmd *cobra.Command, config *core.Config) core.DNSProvider {
+	client := core.ProviderWithContext(a.newClient(config), cmd.Context())
+
+	audited, ok := client.(interface{ SetAuditor(*core.Auditor) })
+	if !ok {
+		return client
+	}
+	auditor, err := core.OpenAuditor(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Advertencia: no se pudo abrir el registro de auditoría: %v\n", err)
+	} else if auditor != nil {
+		audited.SetAuditor(auditor)
+		a.auditors = append(a.auditors, auditor)
+	}
+	return client
+}
+
+// closeAuditors cierra los registros de auditoría abiertos durante el comando
+func (a *app) closeAuditors() {
+	for _, auditor := range a.auditors {
+		if err := auditor.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Advertencia: no se pudo cerrar el registro de auditoría: %v\n", err)
+		}
+	}
+	a.auditors = nil
+}
+
+// recordJournal guarda la operación en el diario local para poder deshacerla con 'undo'
+func recordJournal(config *core.Config, action string, before, after *core.DNSRecord) {
+	journal, err := core.OpenJournal(config)
+	if err == nil {
+		err = journal.Append(action, before, after)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Advertencia: no se pudo guardar la operación en el diario: %v\n", err)
+	}
+}
+
+// resolveConflicts comprueba si el registro entra en conflicto con otros de la zona. Sin --replace
+// muestra los conflictos y termina; con --replace elimina los registros en conflicto y aplica el
+// cambio en una sola operación. Devuelve true si el cambio ya se aplicó.
+func resolveConflicts(client core.DNSProvider, config *core.Config, record, before *core.DNSRecord, replace bool) bool {
+	conflicts, err := core.CheckConflicts(client, record, config.DomainName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error al comprobar los conflictos: %v\n", err)
+		exit(1)
+	}
+	if len(conflicts) == 0 {
+		return false
+	}
+
+	if !replace {
+		fmt.Fprintf(os.Stderr, "El registro %s %s entra en conflicto con registros existentes:\n", record.Name, record.Type)
+		for _, conflict := range conflicts {
+			fmt.Fprintf(os.Stderr, "  %s %s %s: %s\n", conflict.Record.Name, conflict.Record.Type, conflict.Record.Content, conflict.Description())
+		}
+		fmt.Fprintln(os.Stderr, "Usa --replace para eliminarlos en la misma operación")
+		exit(1)
+	}
+
+	if _, err := core.ReplaceConflicts(client, record, conflicts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error al reemplazar los registros en conflicto: %v\n", err)
+		exit(1)
+	}
+	for _, conflict := range conflicts {
+		recordJournal(config, core.AuditDelete, conflict.Record, nil)
+		fmt.Printf("Eliminado %s %s %s\n", conflict.Record.Name, conflict.Record.Type, conflict.Record.Content)
+	}
+	if before == nil {
+		recordJournal(config, core.AuditCreate, nil, record)
+	} else {
+		recordJournal(config, core.AuditUpdate, before, record)
+	}
+	return true
+}
+
+// resolveName convierte el nombre indicado en el nombre completo del registro o termina con un error
+func resolveName(config *core.Config, name string) string {
+	fullName, err := config.ResolveName(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error en el nombre del registro: %v\n", err)
+		exit(1)
+	}
+	return fullName
+}
+
+// normalizeContent convierte a punycode los nombres de host internacionalizados y divide los
+// TXT largos en cadenas de 255 caracteres; termina con un error si el contenido es inválido
+func normalizeContent(recordType, content string) string {
+	if recordType == "TXT" {
+		return core.ChunkTXT(content)
+	}
+	normalized, err := core.NormalizeContent(recordType, content)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error en el contenido del registro: %v\n", err)
+		exit(1)
+	}
+	return normalized
+}
@@ -8,45 +8,62 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var deleteCmd = &cobra.Command{
-	Use:   "delete [subdominio]",
-	Short: "Elimina un registro DNS",
-	Long: `Elimina el registro DNS asociado al subdominio especificado.
-Ejemplo: cloudflare-domain-controller delete mipagina`,
-	Args: cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		subdomain := args[0]
-		
-		// Crear cliente de Cloudflare
-		config := core.NewConfig()
-		client := core.NewCloudflareClient(config)
-		
-		// Construir el nombre completo del registro
-		fullName := subdomain
-		if config.DomainName != "" {
-			// Verificar si el subdominio ya incluye el dominio
-			if len(subdomain) <= len(config.DomainName) || subdomain[len(subdomain)-len(config.DomainName):] != config.DomainName {
-				fullName = subdomain + "." + config.DomainName
+// newDeleteCmd crea el comando delete
+func newDeleteCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete [subdominio]",
+		Short: "Elimina un registro DNS",
+		Long: `Elimina el registro DNS asociado al subdominio especificado.
+Con --match, --regex o --where-content se eliminan todos los registros seleccionados en una
+única operación, tras mostrar la selección y pedir confirmación.
+Ejemplo: cloudflare-domain-controller delete mipagina
+Ejemplo: cloudflare-domain-controller delete --match 'preview-*' --type A`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			// Crear cliente de Cloudflare
+			config := core.NewConfig()
+			client := a.client(cmd, config)
+
+			// Eliminar varios registros seleccionados por patrón, tipo o contenido
+			if bulkSelection(cmd) {
+				if len(args) > 0 {
+					fmt.Fprintln(os.Stderr, "No se puede indicar un subdominio junto con --match, --regex o --where-content")
+					exit(1)
+				}
+				var changes []core.RecordChange
+				for _, record := range selectRecords(cmd, config, client) {
+					changes = append(changes, core.RecordChange{Action: core.AuditDelete, Current: record})
+				}
+				applyBulk(cmd, config, client, changes)
+				return
 			}
-		}
-		
-		// Obtener el registro existente
-		record, err := client.GetDNSRecordByName(fullName)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error al obtener el registro DNS: %v\n", err)
-			os.Exit(1)
-		}
-		
-		// Eliminar el registro
-		if err := client.DeleteDNSRecord(record.ID); err != nil {
-			fmt.Fprintf(os.Stderr, "Error al eliminar el registro DNS: %v\n", err)
-			os.Exit(1)
-		}
-		
-		fmt.Printf("Registro DNS para %s eliminado exitosamente\n", subdomain)
-	},
-}
+			if len(args) != 1 {
+				fmt.Fprintln(os.Stderr, "Indica el subdominio o un criterio de selección (--match, --regex o --where-content)")
+				exit(1)
+			}
+			subdomain := args[0]
 
-func init() {
-	rootCmd.AddCommand(deleteCmd)
-}
\ No newline at end of file
+			// Construir el nombre completo del registro
+			fullName := resolveName(config, subdomain)
+
+			// Obtener el registro existente
+			record, err := client.GetDNSRecordByName(fullName)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al obtener el registro DNS: %v\n", err)
+				exit(1)
+			}
+
+			// Eliminar el registro
+			if err := client.DeleteDNSRecord(record.ID); err != nil {
+				fmt.Fprintf(os.Stderr, "Error al eliminar el registro DNS: %v\n", err)
+				exit(1)
+			}
+			recordJournal(config, core.AuditDelete, record, nil)
+
+			fmt.Printf("Registro DNS para %s eliminado exitosamente\n", subdomain)
+		},
+	}
+	cmd.Flags().StringP("type", "t", "", "Eliminar solo los registros de este tipo (con --match, --regex o --where-content)")
+	addSelectorFlags(cmd)
+	return cmd
+}
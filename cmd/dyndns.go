@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"cloudflare-domain-controller/core"
+	"github.com/spf13/cobra"
+)
+
+// newDyndnsCmd crea el comando dyndns
+func newDyndnsCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dyndns",
+		Short: "Inicia un servidor compatible con el protocolo dyndns2",
+		Long: `Inicia un servidor HTTP que implementa el endpoint /nic/update del protocolo dyndns2,
+para que routers como FritzBox, UniFi o pfSense puedan actualizar sus registros DNS.
+
+El archivo de usuarios contiene una línea por usuario con el formato:
+  usuario:contraseña:host1.ejemplo.com,host2.ejemplo.com
+
+Con --metrics-listen se publican las métricas para Prometheus en /metrics, en una dirección
+distinta de la del servidor dyndns2.
+Ejemplo: cloudflare-domain-controller dyndns --listen :8245 --users /etc/cfdc/dyndns-users`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			listen, _ := cmd.Flags().GetString("listen")
+			usersFile, _ := cmd.Flags().GetString("users")
+
+			config := core.NewConfig()
+			// Validar configuración
+			if err := config.Validate(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error de configuración: %v\n", err)
+				exit(1)
+			}
+
+			// Cargar los usuarios autorizados
+			file, err := os.Open(usersFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al abrir el archivo de usuarios: %v\n", err)
+				exit(1)
+			}
+			users, err := core.LoadDynDNSUsers(file)
+			file.Close()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al leer el archivo de usuarios: %v\n", err)
+				exit(1)
+			}
+
+			client := a.client(cmd, config)
+			serveMetrics(cmd)
+
+			mux := http.NewServeMux()
+			mux.Handle("/nic/update", core.NewDynDNSHandler(client, users))
+
+			fmt.Printf("Servidor dyndns2 escuchando en %s (%d usuarios)\n", listen, len(users))
+			if err := http.ListenAndServe(listen, mux); err != nil {
+				fmt.Fprintf(os.Stderr, "Error en el servidor dyndns2: %v\n", err)
+				exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringP("listen", "l", ":8245", "Dirección en la que escucha el servidor")
+	cmd.Flags().StringP("users", "u", "", "Archivo con los usuarios y los hostnames permitidos")
+	addMetricsFlag(cmd)
+	// Requerir el flag 'users'
+	cmd.MarkFlagRequired("users")
+	return cmd
+}
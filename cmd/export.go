@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"cloudflare-domain-controller/core"
+	"github.com/spf13/cobra"
+)
+
+// newExportCmd crea el comando export
+func newExportCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export [archivo]",
+		Short: "Exporta los registros DNS a un archivo",
+		Long: `Exporta todos los registros DNS de la zona en formato CSV, con las columnas
+name,type,content,ttl,proxied,priority,comment,tags. Si no se indica un archivo
+se escribe en la salida estándar. El resultado se puede volver a importar con 'import'.
+Ejemplo: cloudflare-domain-controller export inventario.csv --format csv`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			format, _ := cmd.Flags().GetString("format")
+			if format != "csv" {
+				fmt.Fprintf(os.Stderr, "Formato no soportado: %s\n", format)
+				exit(1)
+			}
+
+			config := core.NewConfig()
+			client := a.client(cmd, config)
+
+			records, err := client.ListDNSRecords()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al obtener los registros DNS: %v\n", err)
+				exit(1)
+			}
+
+			var output io.Writer = os.Stdout
+			if len(args) == 1 && args[0] != "-" {
+				file, err := os.Create(args[0])
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error al crear el archivo: %v\n", err)
+					exit(1)
+				}
+				defer file.Close()
+				output = file
+			}
+
+			if err := core.WriteRecordsCSV(output, records); err != nil {
+				fmt.Fprintf(os.Stderr, "Error al exportar los registros DNS: %v\n", err)
+				exit(1)
+			}
+
+			if output != os.Stdout {
+				fmt.Printf("%d registros exportados a %s\n", len(records), args[0])
+			}
+		},
+	}
+	cmd.Flags().StringP("format", "f", "csv", "Formato del archivo (csv)")
+	return cmd
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"cloudflare-domain-controller/core"
+	"github.com/spf13/cobra"
+)
+
+// newFailoverCmd crea el comando failover
+func newFailoverCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "failover [subdominio]",
+		Short: "Mantiene un registro apuntando al origen sano de mayor prioridad",
+		Long: `Inicia un servicio que comprueba periódicamente la salud de varios orígenes y actualiza el
+registro para que apunte al origen sano de mayor prioridad (el orden de --origin).
+
+Las comprobaciones pueden ser HTTP/HTTPS (respuesta menor que 400) o TCP (conexión al puerto).
+Un origen se considera caído tras --fall fallos seguidos y vuelve a estar sano tras --rise
+comprobaciones correctas seguidas, para evitar cambios continuos. Si ningún origen está sano
+el registro no se modifica.
+
+Cada origen se indica como "contenido" o "contenido@dirección" para comprobarlo en otra
+dirección (por ejemplo por la red interna). Con --listen se publica el estado en JSON en /status
+y con --metrics-listen las métricas para Prometheus en /metrics.
+Ejemplo: cloudflare-domain-controller failover web --origin 203.0.113.4 --origin 198.51.100.7 --check https --path /health --host web.ejemplo.com`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			recordType, _ := cmd.Flags().GetString("type")
+			originValues, _ := cmd.Flags().GetStringArray("origin")
+			interval, _ := cmd.Flags().GetDuration("interval")
+			rise, _ := cmd.Flags().GetInt("rise")
+			fall, _ := cmd.Flags().GetInt("fall")
+			listen, _ := cmd.Flags().GetString("listen")
+			if interval <= 0 {
+				fmt.Fprintf(os.Stderr, "Error en --interval: debe ser mayor que cero, obtenido %s\n", interval)
+				exit(1)
+			}
+			// Con 0 no habría histéresis: una sola comprobación cambiaría el estado del origen
+			if rise < 1 || fall < 1 {
+				fmt.Fprintln(os.Stderr, "Error: --rise y --fall deben ser al menos 1")
+				exit(1)
+			}
+
+			check := core.HealthCheck{}
+			check.Kind, _ = cmd.Flags().GetString("check")
+			check.Port, _ = cmd.Flags().GetInt("port")
+			check.Path, _ = cmd.Flags().GetString("path")
+			check.Host, _ = cmd.Flags().GetString("host")
+			check.Timeout, _ = cmd.Flags().GetDuration("timeout")
+
+			config := core.NewConfig()
+			// Validar configuración
+			if err := config.Validate(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error de configuración: %v\n", err)
+				exit(1)
+			}
+			client := a.client(cmd, config)
+			fullName := resolveName(config, args[0])
+
+			var origins []core.FailoverOrigin
+			for _, value := range originValues {
+				origin, err := core.ParseFailoverOrigin(value)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error en --origin %q: %v\n", value, err)
+					exit(1)
+				}
+				origin.Content = normalizeContent(recordType, origin.Content)
+				origins = append(origins, origin)
+			}
+
+			failover, err := core.NewFailover(client, fullName, recordType, origins, check)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error en la configuración de la conmutación: %v\n", err)
+				exit(1)
+			}
+			failover.Rise = rise
+			failover.Fall = fall
+			defer failover.Close()
+
+			if listen != "" {
+				mux := http.NewServeMux()
+				mux.Handle("/status", failover)
+				go func() {
+					if err := http.ListenAndServe(listen, mux); err != nil {
+						fmt.Fprintf(os.Stderr, "Error en el servidor de estado: %v\n", err)
+						exit(1)
+					}
+				}()
+				fmt.Printf("Estado disponible en http://%s/status\n", listen)
+			}
+			serveMetrics(cmd)
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			fmt.Printf("Conmutación de %s %s entre %d orígenes (cada %s)\n", fullName, recordType, len(origins), interval)
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			lastError := ""
+			for {
+				change, err := failover.Step(ctx)
+				timestamp := time.Now().Format("2006-01-02 15:04:05")
+				switch {
+				case err != nil && ctx.Err() == nil:
+					// Mostrar cada error una sola vez mientras se repita
+					if err.Error() != lastError {
+						fmt.Fprintf(os.Stderr, "[%s] %v\n", timestamp, err)
+					}
+					lastError = err.Error()
+				case change != nil:
+					recordJournal(config, core.AuditUpdate, change.Current, change.Desired)
+					fmt.Printf("[%s] %s %s: %s -> %s\n", timestamp, fullName, recordType, change.Current.Content, change.Desired.Content)
+					lastError = ""
+				default:
+					lastError = ""
+				}
+
+				select {
+				case <-ctx.Done():
+					fmt.Println("Conmutación detenida")
+					return
+				case <-ticker.C:
+				}
+			}
+		},
+	}
+	cmd.Flags().StringP("type", "t", "A", "Tipo de registro DNS (A, AAAA o CNAME)")
+	cmd.Flags().StringArray("origin", nil, "Origen candidato, por orden de prioridad (contenido[@dirección]); se puede repetir")
+	cmd.Flags().String("check", core.CheckHTTP, "Tipo de comprobación: http, https o tcp")
+	cmd.Flags().Int("port", 0, "Puerto de la comprobación (por defecto 80 para http y 443 para https)")
+	cmd.Flags().String("path", "/", "Ruta de las comprobaciones HTTP")
+	cmd.Flags().String("host", "", "Cabecera Host y nombre TLS de las comprobaciones HTTP")
+	cmd.Flags().Duration("timeout", core.DefaultCheckTimeout, "Tiempo máximo de cada comprobación")
+	cmd.Flags().Duration("interval", core.DefaultFailoverInterval, "Intervalo entre comprobaciones")
+	cmd.Flags().Int("rise", core.DefaultFailoverRise, "Comprobaciones correctas seguidas para considerar sano un origen")
+	cmd.Flags().Int("fall", core.DefaultFailoverFall, "Fallos seguidos para considerar caído un origen")
+	cmd.Flags().String("listen", "", "Dirección del endpoint /status (por ejemplo :8246)")
+	addMetricsFlag(cmd)
+	cmd.MarkFlagRequired("origin")
+	return cmd
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"cloudflare-domain-controller/core"
+	"github.com/spf13/cobra"
+)
+
+// newGCCmd crea el comando gc
+func newGCCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Elimina los registros temporales caducados",
+		Long: `Revisa todos los registros de la zona y elimina los que se crearon con 'add --expires'
+y ya han caducado. Al terminar muestra un informe con los registros eliminados.
+
+Con --daemon se queda en ejecución y repite la revisión cada --interval.
+Ejemplo: cloudflare-domain-controller gc --dry-run
+Ejemplo: cloudflare-domain-controller gc --daemon --interval 10m`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			daemon, _ := cmd.Flags().GetBool("daemon")
+			interval, _ := cmd.Flags().GetDuration("interval")
+			concurrency, _ := cmd.Flags().GetInt("concurrency")
+			if daemon && interval <= 0 {
+				fmt.Fprintf(os.Stderr, "Error en --interval: debe ser mayor que cero, obtenido %s\n", interval)
+				exit(1)
+			}
+
+			config := core.NewConfig()
+			// Validar configuración
+			if err := config.Validate(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error de configuración: %v\n", err)
+				exit(1)
+			}
+			client := a.client(cmd, config)
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			if !daemon {
+				if !collectExpired(ctx, config, client, concurrency, dryRun, true) {
+					exit(1)
+				}
+				return
+			}
+
+			serveMetrics(cmd)
+			fmt.Printf("Recolector de registros caducados en marcha (cada %s)\n", interval)
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				// En modo servicio los errores no detienen el recolector: se reintenta en la siguiente pasada.
+				// Las eliminaciones solo se guardan en la auditoría: en el diario quedarían por encima de
+				// los cambios del usuario y 'undo' volvería a crear registros caducados.
+				collectExpired(ctx, config, client, concurrency, dryRun, false)
+				select {
+				case <-ctx.Done():
+					fmt.Println("Recolector detenido")
+					return
+				case <-ticker.C:
+				}
+			}
+		},
+	}
+	cmd.Flags().Bool("dry-run", false, "Mostrar los registros caducados sin eliminarlos")
+	cmd.Flags().Bool("daemon", false, "Quedarse en ejecución y revisar la zona periódicamente")
+	cmd.Flags().Duration("interval", core.DefaultGCInterval, "Intervalo entre revisiones en modo servicio")
+	cmd.Flags().Int("concurrency", core.DefaultConcurrency, "Número máximo de registros que se eliminan a la vez")
+	addMetricsFlag(cmd)
+	return cmd
+}
+
+// collectExpired realiza una pasada del recolector y muestra el informe. Con journal las
+// eliminaciones se guardan también en el diario para poder deshacerlas con 'undo'.
+// Devuelve false si no se pudo revisar la zona o algún registro no se pudo eliminar.
+func collectExpired(ctx context.Context, config *core.Config, client core.DNSProvider, concurrency int, dryRun, journal bool) bool {
+	report, err := core.CollectExpired(ctx, client, time.Now(), concurrency, dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error al revisar los registros caducados: %v\n", err)
+		return false
+	}
+
+	verb := "Eliminado"
+	if dryRun {
+		verb = "Caducado"
+	}
+	for _, record := range report.Collected {
+		expiry, _ := core.RecordExpiry(record)
+		if !dryRun && journal {
+			recordJournal(config, core.AuditDelete, record, nil)
+		}
+		fmt.Printf("%s %s %s %s (caducó el %s)\n", verb, config.RelativeName(record.Name), record.Type, record.Content, expiry.Local().Format("2006-01-02 15:04:05"))
+	}
+	for _, result := range report.Failed {
+		record := result.Operation.Change.Current
+		fmt.Fprintf(os.Stderr, "Error al eliminar %s %s: %v\n", config.RelativeName(record.Name), record.Type, result.Err)
+	}
+
+	if dryRun {
+		fmt.Printf("[%s] %d registros revisados, %d caducados\n", report.Time.Format("2006-01-02 15:04:05"), report.Checked, len(report.Collected))
+	} else {
+		fmt.Printf("[%s] %d registros revisados, %d eliminados, %d con errores\n", report.Time.Format("2006-01-02 15:04:05"), report.Checked, len(report.Collected), len(report.Failed))
+	}
+	return len(report.Failed) == 0
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"cloudflare-domain-controller/core"
+	"github.com/spf13/cobra"
+)
+
+// newHistoryCmd crea el comando history
+func newHistoryCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history [subdominio]",
+		Short: "Muestra el historial de cambios registrado en la auditoría",
+		Long: `Muestra los cambios realizados con la herramienta, leídos del registro de auditoría.
+Se puede filtrar por nombre y por rango de tiempo; --since y --until aceptan una fecha
+(2006-01-02), una fecha y hora RFC 3339 o una duración relativa al momento actual (24h).
+Ejemplo: cloudflare-domain-controller history mipagina --since 168h`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			since, _ := cmd.Flags().GetString("since")
+			until, _ := cmd.Flags().GetString("until")
+			limit, _ := cmd.Flags().GetInt("limit")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			config := core.NewConfig()
+
+			var filter core.AuditFilter
+			var err error
+			if len(args) == 1 {
+				// Construir el nombre completo del registro
+				filter.Name = resolveName(config, args[0])
+			}
+			if filter.Since, err = parseTimeFlag(since); err != nil {
+				fmt.Fprintf(os.Stderr, "Valor inválido para --since: %v\n", err)
+				exit(1)
+			}
+			if filter.Until, err = parseTimeFlag(until); err != nil {
+				fmt.Fprintf(os.Stderr, "Valor inválido para --until: %v\n", err)
+				exit(1)
+			}
+
+			path, err := config.AuditLogPath()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al consultar la auditoría: %v\n", err)
+				exit(1)
+			}
+			file, err := os.Open(path)
+			if os.IsNotExist(err) {
+				fmt.Println("No hay cambios registrados.")
+				return
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al abrir el registro de auditoría: %v\n", err)
+				exit(1)
+			}
+			defer file.Close()
+
+			entries, err := core.ReadAuditLog(file, filter)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al leer el registro de auditoría: %v\n", err)
+				exit(1)
+			}
+			// Mostrar solo las entradas más recientes
+			if limit > 0 && len(entries) > limit {
+				entries = entries[len(entries)-limit:]
+			}
+
+			if asJSON {
+				encoder := json.NewEncoder(os.Stdout)
+				for _, entry := range entries {
+					encoder.Encode(entry)
+				}
+				return
+			}
+
+			if len(entries) == 0 {
+				fmt.Println("No hay cambios registrados.")
+				return
+			}
+			for _, entry := range entries {
+				fmt.Printf("%s %-6s %-30s %s -> %s (%s@%s)\n",
+					entry.Timestamp.Local().Format("2006-01-02 15:04:05"),
+					entry.Action,
+					entry.Name,
+					describeRecord(entry.Before),
+					describeRecord(entry.After),
+					entry.User,
+					entry.Hostname,
+				)
+			}
+		},
+	}
+	cmd.Flags().String("since", "", "Mostrar cambios desde esta fecha o duración (por ejemplo 24h)")
+	cmd.Flags().String("until", "", "Mostrar cambios hasta esta fecha o duración")
+	cmd.Flags().IntP("limit", "n", 0, "Número máximo de cambios a mostrar (0 = todos)")
+	cmd.Flags().Bool("json", false, "Mostrar las entradas en formato JSON")
+	return cmd
+}
+
+// describeRecord devuelve una descripción corta del tipo y contenido de un registro
+func describeRecord(record *core.DNSRecord) string {
+	if record == nil {
+		return "-"
+	}
+	return record.Type + " " + record.Content
+}
+
+// parseTimeFlag interpreta una fecha, una fecha RFC 3339 o una duración hacia atrás desde ahora
+func parseTimeFlag(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%q no es una fecha ni una duración", value)
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"cloudflare-domain-controller/core"
+	"github.com/spf13/cobra"
+)
+
+// newImportCmd crea el comando import
+func newImportCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import [archivo]",
+		Short: "Importa registros DNS desde un archivo",
+		Long: `Importa registros DNS desde un archivo CSV (o desde la entrada estándar si se omite
+o se indica '-'). La primera fila es la cabecera; las columnas admitidas son:
+  name,type,content,ttl,proxied,priority,comment,tags
+Solo name, type y content son obligatorias. El ttl acepta un número o 'auto', proxied
+acepta true/false y las etiquetas se separan con ';'.
+
+Los registros que ya existen con el mismo nombre y tipo se omiten, salvo que se use --upsert,
+en cuyo caso se actualizan. Si alguna fila es inválida no se aplica ningún cambio.
+Ejemplo: cloudflare-domain-controller import inventario.csv --format csv --upsert`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			format, _ := cmd.Flags().GetString("format")
+			upsert, _ := cmd.Flags().GetBool("upsert")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			if format != "csv" {
+				fmt.Fprintf(os.Stderr, "Formato no soportado: %s\n", format)
+				exit(1)
+			}
+
+			config := core.NewConfig()
+			// Validar configuración
+			if err := config.Validate(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error de configuración: %v\n", err)
+				exit(1)
+			}
+			client := a.client(cmd, config)
+
+			var input io.Reader = os.Stdin
+			if len(args) == 1 && args[0] != "-" {
+				file, err := os.Open(args[0])
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error al abrir el archivo: %v\n", err)
+					exit(1)
+				}
+				defer file.Close()
+				input = file
+			}
+
+			// Validar todas las filas antes de aplicar cambios
+			rows, errs := config.ReadRecordsCSV(input)
+			if len(errs) > 0 {
+				for _, err := range errs {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				}
+				fmt.Fprintf(os.Stderr, "%d filas inválidas; no se aplicó ningún cambio\n", len(errs))
+				exit(1)
+			}
+
+			existing, err := client.ListDNSRecords()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al obtener los registros DNS: %v\n", err)
+				exit(1)
+			}
+
+			summary := core.ImportRecords(client, rows, existing, upsert, dryRun)
+			for _, result := range summary.Results {
+				switch result.Action {
+				case core.ImportFailed:
+					fmt.Fprintf(os.Stderr, "línea %d: error en %s %s: %v\n", result.Line, result.Record.Name, result.Record.Type, result.Err)
+				case core.ImportSkipped:
+					fmt.Printf("línea %d: omitido %s %s (%s)\n", result.Line, result.Record.Name, result.Record.Type, result.Reason)
+				case core.ImportCreated:
+					fmt.Printf("línea %d: creado %s %s %s\n", result.Line, result.Record.Name, result.Record.Type, result.Record.Content)
+				case core.ImportUpdated:
+					fmt.Printf("línea %d: actualizado %s %s %s\n", result.Line, result.Record.Name, result.Record.Type, result.Record.Content)
+				}
+			}
+
+			fmt.Printf("Resumen: %d creados, %d actualizados, %d omitidos, %d con errores\n",
+				summary.Created, summary.Updated, summary.Skipped, summary.Failed)
+			if dryRun {
+				fmt.Println("Modo dry-run: no se aplicó ningún cambio")
+			}
+			if summary.Failed > 0 {
+				exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringP("format", "f", "csv", "Formato del archivo (csv)")
+	cmd.Flags().Bool("upsert", false, "Actualizar los registros existentes con el mismo nombre y tipo")
+	cmd.Flags().Bool("dry-run", false, "Mostrar lo que se haría sin aplicar cambios")
+	return cmd
+}
@@ -8,44 +8,53 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var listCmd = &cobra.Command{
-	Use:   "list",
-	Short: "Lista todos los registros DNS",
-	Long:  `Lista todos los registros DNS configurados en la zona de Cloudflare.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		// Crear cliente de Cloudflare
-		config := core.NewConfig()
-		client := core.NewCloudflareClient(config)
-		
-		// Obtener todos los registros
-		records, err := client.ListDNSRecords()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error al obtener los registros DNS: %v\n", err)
-			os.Exit(1)
-		}
-		
-		// Mostrar los registros
-		if len(records) == 0 {
-			fmt.Println("No se encontraron registros DNS.")
-			return
-		}
-		
-		fmt.Printf("Registros DNS encontrados (%d):\n", len(records))
-		fmt.Println("----------------------------------------")
-		for _, record := range records {
-			// Mostrar solo el subdominio si pertenece al dominio principal
-			displayName := record.Name
-			if config.DomainName != "" && len(record.Name) > len(config.DomainName) {
-				if record.Name[len(record.Name)-len(config.DomainName):] == config.DomainName {
-					displayName = record.Name[:len(record.Name)-len(config.DomainName)-1]
+// newListCmd crea el comando list
+func newListCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "Lista todos los registros DNS",
+		Long:  `Lista todos los registros DNS configurados en la zona de Cloudflare.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			ascii, _ := cmd.Flags().GetBool("ascii")
+
+			// Crear cliente de Cloudflare
+			config := core.NewConfig()
+			client := a.client(cmd, config)
+
+			// Obtener todos los registros
+			records, err := client.ListDNSRecords()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al obtener los registros DNS: %v\n", err)
+				exit(1)
+			}
+
+			// Mostrar los registros
+			if len(records) == 0 {
+				fmt.Println("No se encontraron registros DNS.")
+				return
+			}
+
+			fmt.Printf("Registros DNS encontrados (%d):\n", len(records))
+			fmt.Printf("%-20s %-6s %-30s %-6s %s\n", "NOMBRE", "TIPO", "CONTENIDO", "TTL", "PROXY")
+			fmt.Println("----------------------------------------------------------------------")
+			for _, record := range records {
+				// Mostrar solo el subdominio si pertenece al dominio principal
+				displayName := config.RelativeName(record.Name)
+				content := record.Content
+
+				// Mostrar los nombres internacionalizados en Unicode salvo que se pida ASCII
+				if !ascii {
+					displayName = core.ToUnicode(displayName)
+					if core.HasHostnameContent(record.Type) {
+						content = core.ToUnicode(content)
+					}
 				}
+
+				// La columna de proxy muestra "-" si el tipo de registro no admite proxy
+				fmt.Printf("%-20s %-6s %-30s %-6s %s\n", displayName, record.Type, content, ttlStatus(record.TTL), proxyStatus(record))
 			}
-			
-			fmt.Printf("%-20s %-6s %-15s\n", displayName, record.Type, record.Content)
-		}
-	},
+		},
+	}
+	cmd.Flags().Bool("ascii", false, "Mostrar los nombres internacionalizados en punycode")
+	return cmd
 }
-
-func init() {
-	rootCmd.AddCommand(listCmd)
-}
\ No newline at end of file
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"cloudflare-domain-controller/core"
+	"github.com/spf13/cobra"
+)
+
+// addMetricsFlag agrega la opción para publicar las métricas de un servicio
+func addMetricsFlag(cmd *cobra.Command) {
+	cmd.Flags().String("metrics-listen", "", "Dirección en la que se publican las métricas /metrics para Prometheus (por ejemplo :9180)")
+}
+
+// serveMetrics publica las métricas en la dirección del flag --metrics-listen, si se indicó
+func serveMetrics(cmd *cobra.Command) {
+	listen, _ := cmd.Flags().GetString("metrics-listen")
+	if listen == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", core.MetricsHandler())
+	go func() {
+		if err := http.ListenAndServe(listen, mux); err != nil {
+			fmt.Fprintf(os.Stderr, "Error en el servidor de métricas: %v\n", err)
+			exit(1)
+		}
+	}()
+	fmt.Printf("Métricas disponibles en http://%s/metrics\n", listen)
+}
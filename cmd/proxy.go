@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"cloudflare-domain-controller/core"
+	"github.com/spf13/cobra"
+)
+
+// newProxyCmd crea el comando proxy
+func newProxyCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "proxy on|off [patrón...]",
+		Short: "Activa o desactiva el proxy de Cloudflare en uno o varios registros",
+		Long: `Activa o desactiva el proxy de Cloudflare en los registros cuyo nombre coincide con alguno
+de los patrones. Los patrones admiten comodines glob: "@" es el ápice, "www" es www.<dominio> y
+"*.dev" son todos los nombres bajo dev.<dominio>. Solo los registros A, AAAA y CNAME admiten proxy.
+Los cambios se aplican en una única operación batch.
+Ejemplo: cloudflare-domain-controller proxy on www "*.app" --type A`,
+		Args:      cobra.MinimumNArgs(2),
+		ValidArgs: []string{"on", "off"},
+		Run: func(cmd *cobra.Command, args []string) {
+			recordType, _ := cmd.Flags().GetString("type")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			var proxied bool
+			switch args[0] {
+			case "on":
+				proxied = true
+			case "off":
+				proxied = false
+			default:
+				fmt.Fprintf(os.Stderr, "Se esperaba 'on' u 'off', obtenido %q\n", args[0])
+				exit(1)
+			}
+
+			config := core.NewConfig()
+			client := a.client(cmd, config)
+
+			records, err := client.ListDNSRecords()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al obtener los registros DNS: %v\n", err)
+				exit(1)
+			}
+			selector, err := config.NewRecordSelector(args[1:], "", recordType, "")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				exit(1)
+			}
+			matches := selector.Filter(records)
+			if len(matches) == 0 {
+				fmt.Fprintln(os.Stderr, "Ningún registro coincide con los patrones indicados")
+				exit(1)
+			}
+
+			changes, err := core.PlanProxyChanges(matches, proxied)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				exit(1)
+			}
+			if len(changes) == 0 {
+				fmt.Println("Sin cambios.")
+				return
+			}
+
+			for _, change := range changes {
+				fmt.Printf("~ %s %s %s proxy %s -> %s\n", change.Desired.Name, change.Desired.Type, change.Desired.Content, proxyStatus(change.Current), proxyStatus(change.Desired))
+			}
+			if dryRun {
+				fmt.Printf("%d cambios (simulación, no se aplicó ninguno)\n", len(changes))
+				return
+			}
+
+			if err := core.ApplyBulkChanges(client, changes); err != nil {
+				fmt.Fprintf(os.Stderr, "Error al cambiar el proxy: %v\n", err)
+				exit(1)
+			}
+			for _, change := range changes {
+				recordJournal(config, core.AuditUpdate, change.Current, change.Desired)
+			}
+			fmt.Printf("%d registros actualizados\n", len(changes))
+		},
+	}
+	cmd.Flags().StringP("type", "t", "", "Cambiar solo los registros de este tipo")
+	cmd.Flags().Bool("dry-run", false, "Mostrar los cambios sin aplicarlos")
+	return cmd
+}
+
+// proxyStatus describe el estado del proxy de un registro para mostrarlo
+func proxyStatus(record *core.DNSRecord) string {
+	switch {
+	case !core.IsProxiable(record.Type):
+		return "-"
+	case record.Proxied:
+		return "on"
+	}
+	return "off"
+}
+
+// ttlStatus describe el TTL de un registro para mostrarlo; 1 significa automático
+func ttlStatus(ttl int) string {
+	if ttl == 1 {
+		return "auto"
+	}
+	return fmt.Sprintf("%d", ttl)
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+
+	"cloudflare-domain-controller/core"
+	"github.com/spf13/cobra"
+)
+
+// newReplaceContentCmd crea el comando replace-content
+func newReplaceContentCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replace-content",
+		Short: "Reemplaza una IP o un nombre de host en todos los registros de la zona",
+		Long: `Busca los registros que apuntan al valor anterior (direcciones A y AAAA, destinos de CNAME,
+MX y SRV y, con --include-spf, los mecanismos de los registros SPF) y los actualiza para que
+apunten al nuevo valor. Muestra los cambios y pide confirmación antes de aplicarlos.
+
+Los registros se actualizan de forma independiente y en paralelo (--concurrency): si alguno
+falla se continúa con el resto y al final se muestra un resumen de los fallos. Con Ctrl+C se
+cancelan las actualizaciones pendientes.
+Ejemplo: cloudflare-domain-controller replace-content --from 203.0.113.4 --to 198.51.100.7`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			from, _ := cmd.Flags().GetString("from")
+			to, _ := cmd.Flags().GetString("to")
+			includeSPF, _ := cmd.Flags().GetBool("include-spf")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			yes, _ := cmd.Flags().GetBool("yes")
+			concurrency, _ := cmd.Flags().GetInt("concurrency")
+
+			config := core.NewConfig()
+			client := a.client(cmd, config)
+
+			records, err := client.ListDNSRecords()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al obtener los registros DNS: %v\n", err)
+				exit(1)
+			}
+
+			changes, err := core.PlanContentReplacement(records, from, to, includeSPF)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al preparar el reemplazo: %v\n", err)
+				exit(1)
+			}
+			printChanges(changes)
+			if len(changes) == 0 || dryRun {
+				return
+			}
+			if !yes && !confirm(fmt.Sprintf("¿Aplicar %d cambios?", len(changes))) {
+				fmt.Println("Operación cancelada.")
+				return
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+
+			completed := 0
+			summary := core.ApplyContentReplacement(ctx, client, changes, concurrency, func(i int, change core.RecordChange, err error) {
+				completed++
+				record := change.Desired
+				if err != nil {
+					fmt.Printf("[%d/%d] ✗ %s %s: %v\n", completed, len(changes), record.Name, record.Type, err)
+					return
+				}
+				recordJournal(config, core.AuditUpdate, change.Current, change.Desired)
+				fmt.Printf("[%d/%d] ✓ %s %s\n", completed, len(changes), record.Name, record.Type)
+			})
+
+			fmt.Printf("%d registros actualizados, %d con errores\n", len(summary.Applied), len(summary.Failed))
+			if len(summary.Failed) > 0 {
+				fmt.Fprintln(os.Stderr, "Registros sin actualizar:")
+				for _, failure := range summary.Failed {
+					record := failure.Change.Current
+					fmt.Fprintf(os.Stderr, "  %s %s %s: %v\n", record.Name, record.Type, record.Content, failure.Err)
+				}
+				exit(1)
+			}
+		},
+	}
+	cmd.Flags().String("from", "", "Valor anterior (IP o nombre de host)")
+	cmd.Flags().String("to", "", "Valor nuevo")
+	cmd.Flags().Bool("include-spf", false, "Reemplazar también el valor en los registros SPF")
+	cmd.Flags().Bool("dry-run", false, "Mostrar los cambios sin aplicarlos")
+	cmd.Flags().BoolP("yes", "y", false, "No pedir confirmación antes de aplicar los cambios")
+	cmd.Flags().Int("concurrency", core.DefaultConcurrency, "Número máximo de registros que se actualizan a la vez")
+	cmd.MarkFlagRequired("from")
+	cmd.MarkFlagRequired("to")
+	return cmd
+}
@@ -1,26 +1,144 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
+	"time"
 
+	"cloudflare-domain-controller/core"
 	"github.com/spf13/cobra"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
-var rootCmd = &cobra.Command{
-	Use:   "cloudflare-domain-controller",
-	Short: "Una herramienta CLI para gestionar registros DNS en Cloudflare",
-	Long: `Una herramienta CLI que permite agregar, modificar y eliminar registros DNS
+// tracerProvider es el proveedor de trazas configurado con --otlp-endpoint o las variables OTEL_*
+var tracerProvider *sdktrace.TracerProvider
+
+// exit termina el proceso con el código indicado, tras enviar las trazas pendientes. Las
+// pruebas lo sustituyen para comprobar los errores de los comandos sin terminar el proceso.
+var exit = func(code int) {
+	shutdownTracing()
+	os.Exit(code)
+}
+
+// tracingShutdownTimeout limita la espera por las trazas pendientes al terminar
+const tracingShutdownTimeout = 5 * time.Second
+
+// NewRootCommand crea el comando raíz con todos los subcomandos. Los comandos obtienen el
+// proveedor DNS de newClient, lo que permite ejecutarlos contra otro backend; con nil se
+// usa el cliente de la API de Cloudflare.
+func NewRootCommand(newClient ClientFactory) *cobra.Command {
+	if newClient == nil {
+		newClient = newCloudflareClient
+	}
+	a := &app{newClient: newClient}
+
+	cmd := &cobra.Command{
+		Use:   "cloudflare-domain-controller",
+		Short: "Una herramienta CLI para gestionar registros DNS en Cloudflare",
+		Long: `Una herramienta CLI que permite agregar, modificar y eliminar registros DNS
 en Cloudflare mediante comandos simples.`,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			setupLogging(cmd)
+			setupTracing(cmd)
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			a.closeAuditors()
+		},
+	}
+	cmd.PersistentFlags().BoolP("verbose", "v", false, "Mostrar información de lo que se está haciendo")
+	cmd.PersistentFlags().Bool("debug", false, "Mostrar información de depuración, incluidas las solicitudes a la API")
+	cmd.PersistentFlags().Bool("trace", false, "Mostrar las solicitudes y respuestas HTTP completas (con los datos sensibles ocultos)")
+	cmd.PersistentFlags().String("log-format", "text", "Formato del registro: text o json")
+	cmd.PersistentFlags().String("otlp-endpoint", "", "URL del recolector OTLP/HTTP al que enviar las trazas (por ejemplo http://localhost:4318)")
+
+	cmd.AddCommand(
+		newAddCmd(a),
+		newUpdateCmd(a),
+		newUpsertCmd(a),
+		newDeleteCmd(a),
+		newListCmd(a),
+		newExportCmd(a),
+		newImportCmd(a),
+		newBatchCmd(a),
+		newProxyCmd(a),
+		newReplaceContentCmd(a),
+		newRRSetCmd(a),
+		newCheckCmd(a),
+		newVerifyCmd(a),
+		newSnapshotCmd(a),
+		newHistoryCmd(a),
+		newUndoCmd(a),
+		newScheduleCmd(a),
+		newSchedulerCmd(a),
+		newGCCmd(a),
+		newFailoverCmd(a),
+		newDyndnsCmd(a),
+	)
+	return cmd
 }
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	// Las trazas continúan la del proceso que ejecuta el comando (TRACEPARENT)
+	err := NewRootCommand(nil).ExecuteContext(core.ContextFromEnvironment(context.Background()))
+	shutdownTracing()
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		exit(1)
+	}
+}
+
+// setupLogging configura el registro estructurado según los flags globales. Por defecto solo
+// se muestran advertencias y errores; --verbose, --debug y --trace muestran cada vez más detalle.
+func setupLogging(cmd *cobra.Command) {
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	debug, _ := cmd.Flags().GetBool("debug")
+	trace, _ := cmd.Flags().GetBool("trace")
+	format, _ := cmd.Flags().GetString("log-format")
+
+	level := slog.LevelWarn
+	switch {
+	case trace:
+		level = core.LevelTrace
+	case debug:
+		level = slog.LevelDebug
+	case verbose:
+		level = slog.LevelInfo
+	}
+
+	switch format {
+	case "text", "json":
+	default:
+		fmt.Fprintf(os.Stderr, "Formato de registro desconocido: %s (usa text o json)\n", format)
+		exit(1)
+	}
+	core.SetLogger(core.NewLogger(os.Stderr, level, format == "json"))
+}
+
+// setupTracing activa la exportación de trazas por OTLP si se indica un endpoint con
+// --otlp-endpoint o con las variables estándar de OpenTelemetry. Sin ellas no se exporta nada.
+func setupTracing(cmd *cobra.Command) {
+	endpoint, _ := cmd.Flags().GetString("otlp-endpoint")
+	if !core.TracingEnabled(endpoint) {
+		return
+	}
+	provider, err := core.NewTracerProvider(cmd.Context(), endpoint)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error al configurar las trazas: %v\n", err)
+		exit(1)
 	}
+	tracerProvider = provider
+	core.SetTracerProvider(provider)
 }
 
-func init() {
-	// Aquí se pueden agregar flags globales si son necesarios
-}
\ No newline at end of file
+// shutdownTracing envía las trazas pendientes y detiene el exportador
+func shutdownTracing() {
+	if tracerProvider == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+	defer cancel()
+	tracerProvider.Shutdown(ctx)
+	tracerProvider = nil
+}
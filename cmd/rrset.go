@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"cloudflare-domain-controller/core"
+	"github.com/spf13/cobra"
+)
+
+// newRRSetCmd crea el comando rrset
+func newRRSetCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rrset",
+		Short: "Gestiona como un conjunto todos los registros de un nombre y tipo",
+		Long: `Trata todos los registros de un mismo nombre y tipo (por ejemplo varios registros A para
+repartir la carga por round-robin) como un único conjunto. Los cambios se muestran antes de
+aplicarlos y se aplican en una única operación batch.`,
+	}
+	cmd.PersistentFlags().StringP("type", "t", "A", "Tipo de registro DNS (A, AAAA, TXT, etc.)")
+	cmd.AddCommand(newRRSetShowCmd(a), newRRSetReplaceCmd(a), newRRSetAddCmd(a), newRRSetRemoveCmd(a))
+	return cmd
+}
+
+// newRRSetShowCmd crea el comando rrset show
+func newRRSetShowCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show [subdominio]",
+		Short: "Muestra los registros del conjunto",
+		Long: `Muestra todos los registros del nombre y tipo indicados.
+Ejemplo: cloudflare-domain-controller rrset show web --type A`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			config, client, fullName, recordType := rrsetContext(a, cmd, args[0])
+			records := findRRSet(client, fullName, recordType)
+
+			if len(records) == 0 {
+				fmt.Printf("No hay registros %s %s\n", config.RelativeName(fullName), recordType)
+				return
+			}
+			fmt.Printf("%-30s %-6s %-6s %s\n", "CONTENIDO", "TTL", "PROXY", "ID")
+			for _, record := range records {
+				fmt.Printf("%-30s %-6s %-6s %s\n", record.Content, ttlStatus(record.TTL), proxyStatus(record), record.ID)
+			}
+			fmt.Printf("%d registros %s %s\n", len(records), config.RelativeName(fullName), recordType)
+		},
+	}
+	return cmd
+}
+
+// newRRSetReplaceCmd crea el comando rrset replace
+func newRRSetReplaceCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replace [subdominio] [contenido...]",
+		Short: "Reemplaza el conjunto completo de forma atómica",
+		Long: `Deja el conjunto con exactamente los contenidos indicados: crea los que faltan, elimina los que
+sobran y conserva los demás. Sin contenidos elimina todo el conjunto.
+Ejemplo: cloudflare-domain-controller rrset replace web --type A 192.0.2.1 192.0.2.2 192.0.2.3`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			config, client, fullName, recordType := rrsetContext(a, cmd, args[0])
+			current := findRRSet(client, fullName, recordType)
+			applyRRSet(cmd, config, client, fullName, recordType, current, args[1:])
+		},
+	}
+	addRRSetChangeFlags(cmd)
+	return cmd
+}
+
+// newRRSetAddCmd crea el comando rrset add
+func newRRSetAddCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add [subdominio] [contenido...]",
+		Short: "Agrega miembros al conjunto",
+		Long: `Agrega uno o varios contenidos al conjunto. Los que ya existen se ignoran.
+Ejemplo: cloudflare-domain-controller rrset add web --type A 192.0.2.4`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			config, client, fullName, recordType := rrsetContext(a, cmd, args[0])
+			current := findRRSet(client, fullName, recordType)
+			contents := append(core.RRSetContents(current), args[1:]...)
+			applyRRSet(cmd, config, client, fullName, recordType, current, contents)
+		},
+	}
+	addRRSetChangeFlags(cmd)
+	return cmd
+}
+
+// newRRSetRemoveCmd crea el comando rrset remove
+func newRRSetRemoveCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remove [subdominio] [contenido...]",
+		Short: "Quita miembros del conjunto",
+		Long: `Quita uno o varios contenidos del conjunto.
+Ejemplo: cloudflare-domain-controller rrset remove web --type A 192.0.2.4`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			config, client, fullName, recordType := rrsetContext(a, cmd, args[0])
+			current := findRRSet(client, fullName, recordType)
+			contents, err := core.RemoveRRSetContents(current, args[1:])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al quitar miembros: %v\n", err)
+				exit(1)
+			}
+			applyRRSet(cmd, config, client, fullName, recordType, current, contents)
+		},
+	}
+	addRRSetChangeFlags(cmd)
+	return cmd
+}
+
+// rrsetContext crea la configuración y el cliente y resuelve el nombre y el tipo del conjunto
+func rrsetContext(a *app, cmd *cobra.Command, name string) (*core.Config, core.DNSProvider, string, string) {
+	recordType, _ := cmd.Flags().GetString("type")
+
+	config := core.NewConfig()
+	// Validar configuración
+	if err := config.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error de configuración: %v\n", err)
+		exit(1)
+	}
+	return config, a.client(cmd, config), resolveName(config, name), recordType
+}
+
+// findRRSet obtiene los registros del conjunto o termina con un error
+func findRRSet(client core.DNSProvider, name, recordType string) []*core.DNSRecord {
+	records, err := client.FindDNSRecords(name, recordType)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error al obtener los registros DNS: %v\n", err)
+		exit(1)
+	}
+	return records
+}
+
+// applyRRSet calcula los cambios para que el conjunto tenga los contenidos indicados y los aplica.
+// El TTL y el proxy se conservan del conjunto actual salvo que se indiquen con --ttl o --proxied.
+func applyRRSet(cmd *cobra.Command, config *core.Config, client core.DNSProvider, name, recordType string, current []*core.DNSRecord, contents []string) {
+	ttl, proxied := 1, false
+	if len(current) > 0 {
+		ttl, proxied = current[0].TTL, current[0].Proxied
+	}
+	if cmd.Flags().Changed("ttl") {
+		ttl, _ = cmd.Flags().GetInt("ttl")
+	}
+	if cmd.Flags().Changed("proxied") {
+		proxied, _ = cmd.Flags().GetBool("proxied")
+	}
+
+	desired, err := core.NewRRSet(name, recordType, contents, ttl, proxied)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error en el conjunto de registros: %v\n", err)
+		exit(1)
+	}
+	applyBulk(cmd, config, client, core.PlanRRSet(current, desired))
+}
+
+// addRRSetChangeFlags añade los flags de los subcomandos que modifican el conjunto
+func addRRSetChangeFlags(cmd *cobra.Command) {
+	cmd.Flags().Int("ttl", 1, "TTL en segundos de todo el conjunto (1 = automático)")
+	cmd.Flags().Bool("proxied", false, "Activar el proxy de Cloudflare en todo el conjunto")
+	cmd.Flags().BoolP("yes", "y", false, "No pedir confirmación antes de aplicar los cambios")
+	cmd.Flags().Bool("dry-run", false, "Mostrar los cambios sin aplicarlos")
+}
@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"cloudflare-domain-controller/core"
+	"github.com/spf13/cobra"
+)
+
+// newScheduleCmd crea el comando schedule
+func newScheduleCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Programa cambios de registros DNS para una hora concreta",
+		Long: `Guarda cambios de registros DNS para que el servicio 'scheduler' los aplique a la hora indicada,
+por ejemplo para una migración planificada de madrugada. Los cambios se guardan en el directorio
+de estado local (CLOUDFLARE_STATE_DIR).
+
+La hora (--at) admite una fecha ("2026-10-19 02:00"), una hora ("02:00", la próxima vez que
+llegue) o una duración desde ahora ("+90m").`,
+	}
+	cmd.AddCommand(newScheduleAddCmd(a), newScheduleUpdateCmd(a), newScheduleDeleteCmd(a), newScheduleListCmd(a), newScheduleCancelCmd(a))
+	return cmd
+}
+
+// newScheduleAddCmd crea el comando schedule add
+func newScheduleAddCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add [subdominio]",
+		Short: "Programa la creación de un registro DNS",
+		Long: `Programa la creación de un registro DNS.
+Ejemplo: cloudflare-domain-controller schedule add nuevo --type A --content 198.51.100.7 --at 02:00`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			recordType, _ := cmd.Flags().GetString("type")
+			content, _ := cmd.Flags().GetString("content")
+			ttl, _ := cmd.Flags().GetInt("ttl")
+			proxied, _ := cmd.Flags().GetBool("proxied")
+
+			config := core.NewConfig()
+			at := scheduleTime(cmd)
+			record := &core.DNSRecord{
+				Name:    resolveName(config, args[0]),
+				Type:    recordType,
+				Content: normalizeContent(recordType, content),
+				TTL:     ttl,
+				Proxied: proxied,
+			}
+			addScheduledChange(config, core.ScheduledChange{At: at, Action: core.AuditCreate, After: record})
+		},
+	}
+	addScheduleFlags(cmd)
+	addScheduleRecordFlags(cmd)
+	cmd.MarkFlagRequired("content")
+	return cmd
+}
+
+// newScheduleUpdateCmd crea el comando schedule update
+func newScheduleUpdateCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "update [subdominio]",
+		Short: "Programa la actualización de un registro DNS",
+		Long: `Programa la actualización de un registro DNS existente. Se guarda el estado actual del registro:
+si cambia antes de la hora programada, el cambio no se aplica.
+Ejemplo: cloudflare-domain-controller schedule update web --type A --content 198.51.100.7 --at "2026-10-19 02:00"`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			content, _ := cmd.Flags().GetString("content")
+			ttl, _ := cmd.Flags().GetInt("ttl")
+			proxied, _ := cmd.Flags().GetBool("proxied")
+
+			config := core.NewConfig()
+			at := scheduleTime(cmd)
+			current := findScheduledRecord(a, cmd, config, args[0])
+
+			desired := *current
+			if content != "" {
+				desired.Content = normalizeContent(current.Type, content)
+			}
+			if cmd.Flags().Changed("ttl") {
+				desired.TTL = ttl
+			}
+			if cmd.Flags().Changed("proxied") {
+				desired.Proxied = proxied
+			}
+			addScheduledChange(config, core.ScheduledChange{At: at, Action: core.AuditUpdate, Before: current, After: &desired})
+		},
+	}
+	addScheduleFlags(cmd)
+	addScheduleRecordFlags(cmd)
+	return cmd
+}
+
+// newScheduleDeleteCmd crea el comando schedule delete
+func newScheduleDeleteCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete [subdominio]",
+		Short: "Programa la eliminación de un registro DNS",
+		Long: `Programa la eliminación de un registro DNS existente. Si el registro cambia antes de la hora
+programada, no se elimina.
+Ejemplo: cloudflare-domain-controller schedule delete antiguo --type A --at 02:00`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			config := core.NewConfig()
+			at := scheduleTime(cmd)
+			current := findScheduledRecord(a, cmd, config, args[0])
+			addScheduledChange(config, core.ScheduledChange{At: at, Action: core.AuditDelete, Before: current})
+		},
+	}
+	addScheduleFlags(cmd)
+	return cmd
+}
+
+// newScheduleListCmd crea el comando schedule list
+func newScheduleListCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "Lista los cambios programados",
+		Long: `Muestra los cambios pendientes. Con --all muestra también los ya ejecutados, fallidos o cancelados.
+Ejemplo: cloudflare-domain-controller schedule list --all`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			all, _ := cmd.Flags().GetBool("all")
+
+			config := core.NewConfig()
+			entries, err := openSchedule(config).Entries()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al leer los cambios programados: %v\n", err)
+				exit(1)
+			}
+
+			fmt.Printf("%-4s %-17s %-10s %-7s %-20s %-6s %s\n", "ID", "HORA", "ESTADO", "ACCIÓN", "NOMBRE", "TIPO", "CONTENIDO")
+			for _, entry := range entries {
+				if entry.Status != core.SchedulePending && !all {
+					continue
+				}
+				record := entry.Record()
+				content := record.Content
+				if entry.Action == core.AuditUpdate && entry.Before.Content != entry.After.Content {
+					content = entry.Before.Content + " -> " + entry.After.Content
+				}
+				fmt.Printf("%-4s %-17s %-10s %-7s %-20s %-6s %s\n", entry.ID, entry.At.Local().Format("2006-01-02 15:04"), entry.Status, entry.Action, config.RelativeName(record.Name), record.Type, content)
+				if entry.Error != "" {
+					fmt.Printf("     %s\n", entry.Error)
+				}
+			}
+		},
+	}
+	cmd.Flags().Bool("all", false, "Mostrar también los cambios ya ejecutados o cancelados")
+	return cmd
+}
+
+// newScheduleCancelCmd crea el comando schedule cancel
+func newScheduleCancelCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cancel [id]",
+		Short: "Cancela un cambio programado",
+		Long: `Cancela un cambio pendiente para que el servicio 'scheduler' no lo aplique.
+Ejemplo: cloudflare-domain-controller schedule cancel 3`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			config := core.NewConfig()
+			if err := openSchedule(config).Cancel(args[0]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error al cancelar el cambio: %v\n", err)
+				exit(1)
+			}
+			fmt.Printf("Cambio %s cancelado\n", args[0])
+		},
+	}
+	return cmd
+}
+
+// addScheduleFlags añade los flags comunes de los subcomandos que programan un cambio
+func addScheduleFlags(cmd *cobra.Command) {
+	cmd.Flags().String("at", "", "Hora a la que se aplica el cambio")
+	cmd.Flags().StringP("type", "t", "A", "Tipo de registro DNS (A, CNAME, etc.)")
+	cmd.MarkFlagRequired("at")
+}
+
+// addScheduleRecordFlags añade los flags con los valores del registro programado
+func addScheduleRecordFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("content", "c", "", "Contenido del registro DNS (IP o CNAME)")
+	cmd.Flags().Int("ttl", 1, "TTL en segundos (1 = automático)")
+	cmd.Flags().Bool("proxied", false, "Activar el proxy de Cloudflare")
+}
+
+// openSchedule abre el archivo de cambios programados o termina con un error
+func openSchedule(config *core.Config) *core.Schedule {
+	schedule, err := core.OpenSchedule(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error al abrir los cambios programados: %v\n", err)
+		exit(1)
+	}
+	return schedule
+}
+
+// scheduleTime interpreta el flag --at o termina con un error
+func scheduleTime(cmd *cobra.Command) time.Time {
+	value, _ := cmd.Flags().GetString("at")
+	now := time.Now()
+	at, err := core.ParseScheduleTime(value, now)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error en --at: %v\n", err)
+		exit(1)
+	}
+	if !at.After(now) {
+		fmt.Fprintf(os.Stderr, "Error en --at: %s ya ha pasado\n", at.Local().Format("2006-01-02 15:04:05"))
+		exit(1)
+	}
+	return at
+}
+
+// findScheduledRecord busca el registro existente por nombre y tipo o termina con un error
+func findScheduledRecord(a *app, cmd *cobra.Command, config *core.Config, name string) *core.DNSRecord {
+	recordType, _ := cmd.Flags().GetString("type")
+	if err := config.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error de configuración: %v\n", err)
+		exit(1)
+	}
+	fullName := resolveName(config, name)
+
+	records, err := a.client(cmd, config).FindDNSRecords(fullName, recordType)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error al buscar el registro DNS: %v\n", err)
+		exit(1)
+	}
+	switch len(records) {
+	case 0:
+		fmt.Fprintf(os.Stderr, "No existe ningún registro %s %s\n", fullName, recordType)
+		exit(1)
+	case 1:
+	default:
+		fmt.Fprintf(os.Stderr, "Hay %d registros %s %s; el cambio programado debe afectar a uno solo\n", len(records), fullName, recordType)
+		exit(1)
+	}
+	return records[0]
+}
+
+// addScheduledChange guarda el cambio en la programación y muestra su identificador
+func addScheduledChange(config *core.Config, change core.ScheduledChange) {
+	change, err := openSchedule(config).Add(change)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error al programar el cambio: %v\n", err)
+		exit(1)
+	}
+	record := change.Record()
+	fmt.Printf("Cambio %s programado: %s %s %s a las %s\n", change.ID, change.Action, record.Name, record.Type, change.At.Local().Format("2006-01-02 15:04:05"))
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"cloudflare-domain-controller/core"
+	"github.com/spf13/cobra"
+)
+
+// newSchedulerCmd crea el comando scheduler
+func newSchedulerCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "scheduler",
+		Short: "Aplica los cambios programados cuando llega su hora",
+		Long: `Inicia un servicio que revisa periódicamente los cambios programados con 'schedule' y los
+aplica cuando llega su hora. Antes de aplicar un cambio comprueba que el registro no ha cambiado
+desde que se programó; si cambió, el cambio se descarta. Los cambios que no se pudieron aplicar
+a tiempo (más de --max-delay de retraso) también se descartan.
+
+El resultado de cada cambio se guarda en la programación ('schedule list --all'), en la
+auditoría y en el diario de operaciones.
+Ejemplo: cloudflare-domain-controller scheduler --interval 15s`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			interval, _ := cmd.Flags().GetDuration("interval")
+			maxDelay, _ := cmd.Flags().GetDuration("max-delay")
+			once, _ := cmd.Flags().GetBool("once")
+			if !once && interval <= 0 {
+				fmt.Fprintf(os.Stderr, "Error en --interval: debe ser mayor que cero, obtenido %s\n", interval)
+				exit(1)
+			}
+
+			config := core.NewConfig()
+			// Validar configuración
+			if err := config.Validate(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error de configuración: %v\n", err)
+				exit(1)
+			}
+			client := a.client(cmd, config)
+			schedule := openSchedule(config)
+
+			if once {
+				if !runScheduled(config, client, schedule, maxDelay) {
+					exit(1)
+				}
+				return
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			serveMetrics(cmd)
+			fmt.Printf("Programador en marcha (cada %s)\n", interval)
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				runScheduled(config, client, schedule, maxDelay)
+				select {
+				case <-ctx.Done():
+					fmt.Println("Programador detenido")
+					return
+				case <-ticker.C:
+				}
+			}
+		},
+	}
+	cmd.Flags().Duration("interval", core.DefaultSchedulerInterval, "Intervalo entre revisiones de los cambios programados")
+	cmd.Flags().Duration("max-delay", core.DefaultScheduleMaxDelay, "Retraso máximo con el que se aplica un cambio (0 = sin límite)")
+	cmd.Flags().Bool("once", false, "Aplicar los cambios pendientes y terminar (para cron o systemd timers)")
+	addMetricsFlag(cmd)
+	return cmd
+}
+
+// runScheduled aplica los cambios pendientes y muestra su resultado.
+// Devuelve false si no se pudo leer la programación o algún cambio no se aplicó.
+func runScheduled(config *core.Config, client core.DNSProvider, schedule *core.Schedule, maxDelay time.Duration) bool {
+	processed, err := core.RunScheduled(client, schedule, config.DomainName, time.Now(), maxDelay)
+	ok := err == nil
+	for _, change := range processed {
+		record := change.Record()
+		timestamp := time.Now().Format("2006-01-02 15:04:05")
+		if change.Status != core.ScheduleDone {
+			ok = false
+			fmt.Fprintf(os.Stderr, "[%s] %s cambio %s (%s %s %s): %s\n", timestamp, change.Status, change.ID, change.Action, record.Name, record.Type, change.Error)
+			continue
+		}
+		recordJournal(config, change.Action, change.Before, change.Result)
+		fmt.Printf("[%s] done cambio %s (%s %s %s)\n", timestamp, change.ID, change.Action, record.Name, record.Type)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error al procesar los cambios programados: %v\n", err)
+	}
+	return ok
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"cloudflare-domain-controller/core"
+	"github.com/spf13/cobra"
+)
+
+// addSelectorFlags agrega las opciones para seleccionar varios registros a la vez
+func addSelectorFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("match", nil, "Seleccionar los registros cuyo nombre coincide con el patrón glob (se puede repetir)")
+	cmd.Flags().String("regex", "", "Seleccionar los registros cuyo nombre relativo coincide con la expresión regular")
+	cmd.Flags().String("where-content", "", "Seleccionar solo los registros con este contenido")
+	cmd.Flags().BoolP("yes", "y", false, "No pedir confirmación antes de aplicar los cambios")
+	cmd.Flags().Bool("dry-run", false, "Mostrar los registros seleccionados sin aplicar los cambios")
+}
+
+// bulkSelection indica si se indicó algún criterio de selección de varios registros
+func bulkSelection(cmd *cobra.Command) bool {
+	return cmd.Flags().Changed("match") || cmd.Flags().Changed("regex") || cmd.Flags().Changed("where-content")
+}
+
+// selectRecords obtiene los registros de la zona que cumplen los criterios de selección.
+// El tipo solo filtra si se indicó explícitamente con --type.
+func selectRecords(cmd *cobra.Command, config *core.Config, client core.DNSProvider) []*core.DNSRecord {
+	globs, _ := cmd.Flags().GetStringSlice("match")
+	regex, _ := cmd.Flags().GetString("regex")
+	content, _ := cmd.Flags().GetString("where-content")
+	recordType := ""
+	if cmd.Flags().Changed("type") {
+		recordType, _ = cmd.Flags().GetString("type")
+	}
+
+	// Un criterio vacío no filtra nada: '--regex ""' seleccionaría toda la zona
+	emptyGlob := cmd.Flags().Changed("match") && len(globs) == 0
+	for _, glob := range globs {
+		emptyGlob = emptyGlob || strings.TrimSpace(glob) == ""
+	}
+	if emptyGlob || (cmd.Flags().Changed("regex") && regex == "") || (cmd.Flags().Changed("where-content") && content == "") {
+		fmt.Fprintln(os.Stderr, "Error en la selección: --match, --regex y --where-content no admiten valores vacíos")
+		exit(1)
+	}
+
+	selector, err := config.NewRecordSelector(globs, regex, recordType, content)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error en la selección: %v\n", err)
+		exit(1)
+	}
+	if selector.Empty() {
+		fmt.Fprintln(os.Stderr, "Error en la selección: no se indicó ningún criterio; usa --regex '.*' para seleccionar toda la zona")
+		exit(1)
+	}
+
+	records, err := client.ListDNSRecords()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error al obtener los registros DNS: %v\n", err)
+		exit(1)
+	}
+	return selector.Filter(records)
+}
+
+// applyBulk muestra los cambios, pide confirmación y los aplica en una única operación batch
+func applyBulk(cmd *cobra.Command, config *core.Config, client core.DNSProvider, changes []core.RecordChange) {
+	yes, _ := cmd.Flags().GetBool("yes")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	if len(changes) == 0 {
+		fmt.Println("Sin cambios.")
+		return
+	}
+	printChanges(changes)
+	if dryRun {
+		return
+	}
+	if !yes && !confirm(fmt.Sprintf("¿Aplicar %d cambios?", len(changes))) {
+		fmt.Println("Operación cancelada.")
+		return
+	}
+
+	if err := core.ApplyBulkChanges(client, changes); err != nil {
+		fmt.Fprintf(os.Stderr, "Error al aplicar los cambios: %v\n", err)
+		exit(1)
+	}
+	for _, change := range changes {
+		recordJournal(config, change.Action, change.Current, change.Desired)
+	}
+	fmt.Printf("%d registros modificados\n", len(changes))
+}
+
+// confirm pregunta al usuario y devuelve true solo si responde afirmativamente
+func confirm(question string) bool {
+	fmt.Printf("%s [s/N]: ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "s", "si", "sí", "y", "yes":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"cloudflare-domain-controller/core"
+	"github.com/spf13/cobra"
+)
+
+// newSnapshotCmd crea el comando snapshot
+func newSnapshotCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Gestiona instantáneas de los registros DNS de la zona",
+		Long: `Guarda, compara y restaura instantáneas completas de los registros DNS de la zona.
+Las instantáneas se guardan en el directorio de estado local (CLOUDFLARE_STATE_DIR) o en el indicado con --dir.`,
+	}
+	cmd.PersistentFlags().String("dir", "", "Directorio de instantáneas (por defecto en el directorio de estado)")
+	cmd.AddCommand(newSnapshotSaveCmd(a), newSnapshotListCmd(a), newSnapshotDiffCmd(a), newSnapshotRestoreCmd(a))
+	return cmd
+}
+
+// newSnapshotSaveCmd crea el comando snapshot save
+func newSnapshotSaveCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "save [nombre]",
+		Short: "Guarda una instantánea de la zona",
+		Long: `Guarda todos los registros DNS actuales de la zona en una instantánea.
+Si no se indica un nombre se usa la fecha y hora actual.
+Ejemplo: cloudflare-domain-controller snapshot save antes-de-migrar`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			name := ""
+			if len(args) == 1 {
+				name = args[0]
+			}
+
+			config := core.NewConfig()
+			client := a.client(cmd, config)
+			dir := snapshotDir(cmd, config)
+
+			records, err := client.ListDNSRecords()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al obtener los registros DNS: %v\n", err)
+				exit(1)
+			}
+
+			snapshot := core.NewSnapshot(name, config, records)
+			path, err := core.SaveSnapshot(dir, snapshot)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al guardar la instantánea: %v\n", err)
+				exit(1)
+			}
+
+			fmt.Printf("Instantánea %s guardada con %d registros en %s\n", snapshot.Name, len(records), path)
+		},
+	}
+	return cmd
+}
+
+// newSnapshotListCmd crea el comando snapshot list
+func newSnapshotListCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "Lista las instantáneas guardadas",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			config := core.NewConfig()
+			dir := snapshotDir(cmd, config)
+
+			snapshots, err := core.ListSnapshots(dir)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al listar las instantáneas: %v\n", err)
+				exit(1)
+			}
+
+			if len(snapshots) == 0 {
+				fmt.Println("No se encontraron instantáneas.")
+				return
+			}
+
+			for _, snapshot := range snapshots {
+				fmt.Printf("%-25s %s %4d registros  %s\n",
+					snapshot.Name,
+					snapshot.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+					len(snapshot.Records),
+					snapshot.DomainName,
+				)
+			}
+		},
+	}
+	return cmd
+}
+
+// newSnapshotDiffCmd crea el comando snapshot diff
+func newSnapshotDiffCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff <instantánea> [instantánea]",
+		Short: "Compara dos instantáneas o una instantánea con la zona actual",
+		Long: `Muestra los cambios necesarios para pasar de la primera instantánea a la segunda.
+Si solo se indica una instantánea, se compara la zona actual con ella, es decir,
+se muestran los cambios que aplicaría 'snapshot restore'.
+Ejemplo: cloudflare-domain-controller snapshot diff antes-de-migrar`,
+		Args: cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			config := core.NewConfig()
+			dir := snapshotDir(cmd, config)
+
+			from, err := core.LoadSnapshot(dir, args[0])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al cargar la instantánea: %v\n", err)
+				exit(1)
+			}
+
+			var changes []core.RecordChange
+			if len(args) == 2 {
+				to, err := core.LoadSnapshot(dir, args[1])
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error al cargar la instantánea: %v\n", err)
+					exit(1)
+				}
+				changes = core.DiffRecords(from.Records, to.Records)
+			} else {
+				client := a.client(cmd, config)
+				live, err := client.ListDNSRecords()
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error al obtener los registros DNS: %v\n", err)
+					exit(1)
+				}
+				changes = core.DiffRecords(live, from.Records)
+			}
+
+			printChanges(changes)
+		},
+	}
+	return cmd
+}
+
+// newSnapshotRestoreCmd crea el comando snapshot restore
+func newSnapshotRestoreCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore <instantánea>",
+		Short: "Restaura la zona al estado de una instantánea",
+		Long: `Calcula y aplica el conjunto mínimo de creaciones, actualizaciones y eliminaciones
+necesario para que la zona vuelva al estado guardado en la instantánea.
+Ejemplo: cloudflare-domain-controller snapshot restore antes-de-migrar --dry-run`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			config := core.NewConfig()
+			// Validar configuración
+			if err := config.Validate(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error de configuración: %v\n", err)
+				exit(1)
+			}
+			client := a.client(cmd, config)
+			dir := snapshotDir(cmd, config)
+
+			snapshot, err := core.LoadSnapshot(dir, args[0])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al cargar la instantánea: %v\n", err)
+				exit(1)
+			}
+			if snapshot.ZoneID != "" && snapshot.ZoneID != config.ZoneID {
+				fmt.Fprintf(os.Stderr, "La instantánea pertenece a otra zona (%s)\n", snapshot.ZoneID)
+				exit(1)
+			}
+
+			live, err := client.ListDNSRecords()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al obtener los registros DNS: %v\n", err)
+				exit(1)
+			}
+
+			changes := core.DiffRecords(live, snapshot.Records)
+			printChanges(changes)
+			if dryRun || len(changes) == 0 {
+				return
+			}
+
+			if err := core.ApplyRecordChanges(client, changes); err != nil {
+				fmt.Fprintf(os.Stderr, "Error al restaurar la instantánea: %v\n", err)
+				exit(1)
+			}
+
+			fmt.Printf("Instantánea %s restaurada exitosamente\n", snapshot.Name)
+		},
+	}
+	cmd.Flags().Bool("dry-run", false, "Mostrar los cambios sin aplicarlos")
+	return cmd
+}
+
+// snapshotDir devuelve el directorio de instantáneas indicado con --dir o el configurado
+func snapshotDir(cmd *cobra.Command, config *core.Config) string {
+	if dir, _ := cmd.Flags().GetString("dir"); dir != "" {
+		return dir
+	}
+	dir, err := config.SnapshotDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error al acceder al directorio de instantáneas: %v\n", err)
+		exit(1)
+	}
+	return dir
+}
+
+// printChanges muestra un conjunto de cambios, uno por línea
+func printChanges(changes []core.RecordChange) {
+	if len(changes) == 0 {
+		fmt.Println("Sin cambios.")
+		return
+	}
+
+	for _, change := range changes {
+		switch change.Action {
+		case core.AuditCreate:
+			fmt.Printf("+ %-30s %-6s %s\n", change.Desired.Name, change.Desired.Type, change.Desired.Content)
+		case core.AuditDelete:
+			fmt.Printf("- %-30s %-6s %s\n", change.Current.Name, change.Current.Type, change.Current.Content)
+		case core.AuditUpdate:
+			fmt.Printf("~ %-30s %-6s %s\n", change.Desired.Name, change.Desired.Type, describeUpdate(change.Current, change.Desired))
+		}
+	}
+	fmt.Printf("%d cambios\n", len(changes))
+}
+
+// describeUpdate describe los campos que cambian en una actualización. Si el contenido no
+// cambia se muestra delante de los demás campos.
+func describeUpdate(current, desired *core.DNSRecord) string {
+	var fields []string
+	if current.TTL != desired.TTL {
+		fields = append(fields, fmt.Sprintf("ttl %s -> %s", ttlStatus(current.TTL), ttlStatus(desired.TTL)))
+	}
+	if current.Proxied != desired.Proxied {
+		fields = append(fields, fmt.Sprintf("proxy %s -> %s", proxyStatus(current), proxyStatus(desired)))
+	}
+	if priority, want := priorityLabel(current.Priority), priorityLabel(desired.Priority); priority != want {
+		fields = append(fields, fmt.Sprintf("prioridad %s -> %s", priority, want))
+	}
+	if current.Comment != desired.Comment {
+		fields = append(fields, fmt.Sprintf("comentario %q -> %q", current.Comment, desired.Comment))
+	}
+	if tags, want := sortedTags(current.Tags), sortedTags(desired.Tags); !slices.Equal(tags, want) {
+		fields = append(fields, fmt.Sprintf("etiquetas [%s] -> [%s]", strings.Join(tags, " "), strings.Join(want, " ")))
+	}
+
+	if current.Content != desired.Content {
+		return strings.Join(append([]string{current.Content + " -> " + desired.Content}, fields...), ", ")
+	}
+	if len(fields) == 0 {
+		return desired.Content
+	}
+	return desired.Content + ": " + strings.Join(fields, ", ")
+}
+
+// priorityLabel muestra una prioridad opcional
+func priorityLabel(priority *int) string {
+	if priority == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d", *priority)
+}
+
+// sortedTags devuelve una copia ordenada de las etiquetas
+func sortedTags(tags []string) []string {
+	sorted := slices.Clone(tags)
+	slices.Sort(sorted)
+	return sorted
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"cloudflare-domain-controller/core"
+	"github.com/spf13/cobra"
+)
+
+// newUndoCmd crea el comando undo
+func newUndoCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "undo",
+		Short: "Deshace las últimas operaciones realizadas",
+		Long: `Deshace las últimas operaciones de add, update y delete guardadas en el diario local:
+elimina un registro recién agregado, restaura el contenido, tipo y TTL anteriores de uno
+actualizado o vuelve a crear uno eliminado. La operación se rechaza si el registro cambió
+desde entonces. El tamaño del diario se configura con CLOUDFLARE_JOURNAL_SIZE.
+Ejemplo: cloudflare-domain-controller undo --steps 2`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			steps, _ := cmd.Flags().GetInt("steps")
+			list, _ := cmd.Flags().GetBool("list")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			config := core.NewConfig()
+			journal, err := core.OpenJournal(config)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al abrir el diario de operaciones: %v\n", err)
+				exit(1)
+			}
+			entries, err := journal.Entries()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al leer el diario de operaciones: %v\n", err)
+				exit(1)
+			}
+
+			if len(entries) == 0 {
+				fmt.Println("No hay operaciones para deshacer.")
+				return
+			}
+
+			if list {
+				// Mostrar de la más reciente a la más antigua
+				for i := len(entries) - 1; i >= 0; i-- {
+					printJournalEntry(len(entries)-i, entries[i])
+				}
+				return
+			}
+
+			if steps > len(entries) {
+				steps = len(entries)
+			}
+
+			client := a.client(cmd, config)
+			for i := 0; i < steps; i++ {
+				entry := entries[len(entries)-1-i]
+				printJournalEntry(i+1, entry)
+				if dryRun {
+					continue
+				}
+
+				if err := core.Undo(client, entry); err != nil {
+					fmt.Fprintf(os.Stderr, "No se pudo deshacer la operación: %v\n", err)
+					exit(1)
+				}
+				if err := journal.RemoveLast(); err != nil {
+					fmt.Fprintf(os.Stderr, "Error al actualizar el diario de operaciones: %v\n", err)
+					exit(1)
+				}
+				fmt.Printf("Operación %s de %s deshecha exitosamente\n", entry.Action, entry.Name())
+			}
+		},
+	}
+	cmd.Flags().IntP("steps", "n", 1, "Número de operaciones a deshacer")
+	cmd.Flags().BoolP("list", "l", false, "Mostrar las operaciones guardadas sin deshacer nada")
+	cmd.Flags().Bool("dry-run", false, "Mostrar las operaciones que se desharían sin aplicarlas")
+	return cmd
+}
+
+// printJournalEntry muestra una operación del diario en una línea
+func printJournalEntry(position int, entry core.JournalEntry) {
+	fmt.Printf("%2d. %s %-6s %-30s %s -> %s\n",
+		position,
+		entry.Timestamp.Local().Format("2006-01-02 15:04:05"),
+		entry.Action,
+		entry.Name(),
+		describeRecord(entry.Before),
+		describeRecord(entry.After),
+	)
+}
@@ -8,55 +8,88 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var updateCmd = &cobra.Command{
-	Use:   "update [subdominio]",
-	Short: "Actualiza un registro DNS existente",
-	Long: `Actualiza un registro DNS existente para el subdominio especificado.
-Ejemplo: cloudflare-domain-controller update mipagina --type A --content 192.168.1.2`,
-	Args: cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		subdomain := args[0]
-		recordType, _ := cmd.Flags().GetString("type")
-		content, _ := cmd.Flags().GetString("content")
-		
-		// Crear cliente de Cloudflare
-		config := core.NewConfig()
-		client := core.NewCloudflareClient(config)
-		
-		// Construir el nombre completo del registro
-		fullName := subdomain
-		if config.DomainName != "" {
-			// Verificar si el subdominio ya incluye el dominio
-			if len(subdomain) <= len(config.DomainName) || subdomain[len(subdomain)-len(config.DomainName):] != config.DomainName {
-				fullName = subdomain + "." + config.DomainName
+// newUpdateCmd crea el comando update
+func newUpdateCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "update [subdominio]",
+		Short: "Actualiza un registro DNS existente",
+		Long: `Actualiza un registro DNS existente para el subdominio especificado.
+Con --match, --regex o --where-content se actualiza el contenido de todos los registros
+seleccionados en una única operación, tras mostrar la selección y pedir confirmación.
+Ejemplo: cloudflare-domain-controller update mipagina --type A --content 192.168.1.2
+Ejemplo: cloudflare-domain-controller update --where-content 10.0.0.5 --content 10.0.0.6`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			recordType, _ := cmd.Flags().GetString("type")
+			content, _ := cmd.Flags().GetString("content")
+			replace, _ := cmd.Flags().GetBool("replace")
+
+			// Crear cliente de Cloudflare
+			config := core.NewConfig()
+			client := a.client(cmd, config)
+
+			// Actualizar varios registros seleccionados por patrón, tipo o contenido
+			if bulkSelection(cmd) {
+				if len(args) > 0 {
+					fmt.Fprintln(os.Stderr, "No se puede indicar un subdominio junto con --match, --regex o --where-content")
+					exit(1)
+				}
+				changes, err := core.PlanBulkUpdate(selectRecords(cmd, config, client), content)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error al preparar la actualización: %v\n", err)
+					exit(1)
+				}
+				applyBulk(cmd, config, client, changes)
+				return
+			}
+			if len(args) != 1 {
+				fmt.Fprintln(os.Stderr, "Indica el subdominio o un criterio de selección (--match, --regex o --where-content)")
+				exit(1)
+			}
+			subdomain := args[0]
+
+			// Construir el nombre completo del registro
+			fullName := resolveName(config, subdomain)
+
+			// Obtener el registro existente
+			record, err := client.GetDNSRecordByName(fullName)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al obtener el registro DNS: %v\n", err)
+				exit(1)
 			}
-		}
-		
-		// Obtener el registro existente
-		record, err := client.GetDNSRecordByName(fullName)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error al obtener el registro DNS: %v\n", err)
-			os.Exit(1)
-		}
-		
-		// Actualizar los campos
-		record.Type = recordType
-		record.Content = content
-		
-		// Actualizar el registro
-		if err := client.UpdateDNSRecord(record.ID, record); err != nil {
-			fmt.Fprintf(os.Stderr, "Error al actualizar el registro DNS: %v\n", err)
-			os.Exit(1)
-		}
-		
-		fmt.Printf("Registro DNS para %s actualizado exitosamente\n", subdomain)
-	},
-}
 
-func init() {
-	rootCmd.AddCommand(updateCmd)
-	updateCmd.Flags().StringP("type", "t", "A", "Tipo de registro DNS (A, CNAME, etc.)")
-	updateCmd.Flags().StringP("content", "c", "", "Nuevo contenido del registro DNS (IP o CNAME)")
+			// Guardar el estado anterior para poder deshacer el cambio
+			before := *record
+
+			// Convertir los nombres IDN y dividir los TXT largos en cadenas de 255 caracteres
+			content = normalizeContent(recordType, content)
+
+			// Actualizar los campos
+			record.Type = recordType
+			record.Content = content
+
+			// Comprobar los conflictos con otros registros antes de actualizar
+			if !resolveConflicts(client, config, record, &before, replace) {
+				if err := client.UpdateDNSRecord(record.ID, record); err != nil {
+					fmt.Fprintf(os.Stderr, "Error al actualizar el registro DNS: %v\n", err)
+					exit(1)
+				}
+				// record contiene ahora el registro devuelto por la API
+				recordJournal(config, core.AuditUpdate, &before, record)
+			}
+
+			fmt.Printf("Registro DNS para %s actualizado exitosamente\n", subdomain)
+
+			// Esperar a que el registro se sirva en los servidores autoritativos
+			waitIfRequested(cmd, config, client, record)
+		},
+	}
+	cmd.Flags().StringP("type", "t", "A", "Tipo de registro DNS (A, CNAME, etc.)")
+	cmd.Flags().StringP("content", "c", "", "Nuevo contenido del registro DNS (IP o CNAME)")
+	addWaitFlags(cmd)
+	addSelectorFlags(cmd)
+	cmd.Flags().Bool("replace", false, "Eliminar los registros en conflicto en la misma operación")
 	// Requerir el flag 'content'
-	updateCmd.MarkFlagRequired("content")
-}
\ No newline at end of file
+	cmd.MarkFlagRequired("content")
+	return cmd
+}
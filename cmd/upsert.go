@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"cloudflare-domain-controller/core"
+	"github.com/spf13/cobra"
+)
+
+// Códigos de salida de upsert para distinguir el resultado en los scripts
+const (
+	upsertExitUnchanged = 0
+	upsertExitCreated   = 2
+	upsertExitUpdated   = 3
+)
+
+// newUpsertCmd crea el comando upsert
+func newUpsertCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "upsert [subdominio]",
+		Aliases: []string{"set"},
+		Short:   "Crea o actualiza un registro DNS de forma idempotente",
+		Long: `Busca el registro por nombre y tipo: lo crea si no existe, lo actualiza si es distinto
+y no hace nada si ya tiene los valores indicados.
+
+Códigos de salida: 0 sin cambios, 2 creado, 3 actualizado, 1 error.
+Ejemplo: cloudflare-domain-controller upsert mipagina --type A --content 192.168.1.1`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			subdomain := args[0]
+			recordType, _ := cmd.Flags().GetString("type")
+			content, _ := cmd.Flags().GetString("content")
+			ttl, _ := cmd.Flags().GetInt("ttl")
+			proxied, _ := cmd.Flags().GetBool("proxied")
+
+			config := core.NewConfig()
+			if err := config.Validate(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error de configuración: %v\n", err)
+				exit(1)
+			}
+			client := a.client(cmd, config)
+
+			// Construir el nombre completo del registro
+			fullName := resolveName(config, subdomain)
+
+			content = normalizeContent(recordType, content)
+
+			record := &core.DNSRecord{
+				Name:    fullName,
+				Type:    recordType,
+				Content: content,
+				TTL:     ttl,
+				Proxied: proxied,
+			}
+
+			result, err := core.UpsertDNSRecord(client, record)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al aplicar el registro DNS: %v\n", err)
+				exit(1)
+			}
+
+			switch result.Status {
+			case core.UpsertCreated:
+				recordJournal(config, core.AuditCreate, nil, result.Record)
+				fmt.Printf("created %s %s %s\n", fullName, recordType, result.Record.Content)
+				exit(upsertExitCreated)
+			case core.UpsertUpdated:
+				recordJournal(config, core.AuditUpdate, result.Before, result.Record)
+				fmt.Printf("updated %s %s %s -> %s\n", fullName, recordType, result.Before.Content, result.Record.Content)
+				exit(upsertExitUpdated)
+			default:
+				fmt.Printf("unchanged %s %s %s\n", fullName, recordType, result.Record.Content)
+				exit(upsertExitUnchanged)
+			}
+		},
+	}
+	cmd.Flags().StringP("type", "t", "A", "Tipo de registro DNS (A, CNAME, etc.)")
+	cmd.Flags().StringP("content", "c", "", "Contenido del registro DNS (IP o CNAME)")
+	cmd.Flags().Int("ttl", 1, "TTL en segundos (1 = automático)")
+	cmd.Flags().Bool("proxied", false, "Pasar el tráfico por el proxy de Cloudflare")
+	cmd.MarkFlagRequired("content")
+	return cmd
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"cloudflare-domain-controller/core"
+	"github.com/spf13/cobra"
+)
+
+// verifyReport es el informe que muestra 'verify --json'
+type verifyReport struct {
+	Zone      string              `json:"zone"`
+	Resolvers []string            `json:"resolvers"`
+	CheckedAt time.Time           `json:"checked_at"`
+	Checked   int                 `json:"checked"`
+	Failed    int                 `json:"failed"`
+	Results   []core.VerifyResult `json:"results"`
+}
+
+// newVerifyCmd crea el comando verify
+func newVerifyCmd(a *app) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Compara los registros de Cloudflare con las respuestas DNS reales",
+		Long: `Resuelve todos los registros de la zona en los servidores indicados y compara las respuestas
+con el contenido de la API. Los registros con proxy deben resolverse a direcciones de Cloudflare.
+Por defecto se consultan los servidores autoritativos de la zona; con --resolver se pueden
+indicar otros (por ejemplo resolvedores públicos) para detectar delegaciones obsoletas.
+
+Termina con código 1 si encuentra alguna diferencia.
+Ejemplo: cloudflare-domain-controller verify --resolver 1.1.1.1 --resolver 8.8.8.8 --json`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			resolvers, _ := cmd.Flags().GetStringSlice("resolver")
+			asJSON, _ := cmd.Flags().GetBool("json")
+			showAll, _ := cmd.Flags().GetBool("all")
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+
+			config := core.NewConfig()
+			client := a.client(cmd, config)
+
+			records, err := client.ListDNSRecords()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error al obtener los registros DNS: %v\n", err)
+				exit(1)
+			}
+
+			if len(resolvers) == 0 {
+				if resolvers, err = core.Nameservers(config, client); err != nil {
+					fmt.Fprintf(os.Stderr, "Error al obtener los servidores de nombres: %v\n", err)
+					exit(1)
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+			defer cancel()
+			results := core.VerifyRecords(ctx, records, resolvers)
+
+			report := verifyReport{
+				Zone:      config.DomainName,
+				Resolvers: resolvers,
+				CheckedAt: time.Now().UTC(),
+				Checked:   len(results),
+				Results:   results,
+			}
+			for _, result := range results {
+				if result.Status != core.VerifyOK && result.Status != core.VerifySkipped {
+					report.Failed++
+				}
+			}
+
+			if asJSON {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				encoder.Encode(report)
+			} else {
+				for _, result := range results {
+					if result.Status == core.VerifyOK && !showAll {
+						continue
+					}
+					printVerifyResult(config, result)
+				}
+				fmt.Printf("%d comprobaciones en %d servidores, %d con diferencias\n", report.Checked, len(resolvers), report.Failed)
+			}
+
+			if core.VerifyFailed(results) {
+				exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringSlice("resolver", nil, "Servidor DNS a consultar (host o host:puerto); se puede repetir")
+	cmd.Flags().Bool("json", false, "Mostrar el informe en formato JSON")
+	cmd.Flags().Bool("all", false, "Mostrar también los registros sin diferencias")
+	cmd.Flags().Duration("timeout", 2*time.Minute, "Tiempo máximo para completar todas las consultas")
+	return cmd
+}
+
+// printVerifyResult muestra una línea con el resultado de la verificación de un registro
+func printVerifyResult(config *core.Config, result core.VerifyResult) {
+	name := config.RelativeName(result.Name)
+	line := fmt.Sprintf("%-8s %-20s %-6s %-20s", result.Status, name, result.Type, result.Resolver)
+	switch {
+	case result.Message != "":
+		line += " " + result.Message
+	case len(result.Missing) > 0 || len(result.Unexpected) > 0:
+		if len(result.Missing) > 0 {
+			line += " faltan: " + strings.Join(result.Missing, ", ")
+		}
+		if len(result.Unexpected) > 0 {
+			line += " sobran: " + strings.Join(result.Unexpected, ", ")
+		}
+	default:
+		line += " " + strings.Join(result.Answers, ", ")
+	}
+	fmt.Println(line)
+}
@@ -0,0 +1,222 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Acciones registradas en la auditoría
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+// Valores especiales de CLOUDFLARE_AUDIT_LOG
+const (
+	auditLogSyslog   = "syslog"
+	auditLogDisabled = "off"
+	auditLogFileName = "audit.log"
+)
+
+// AuditEntry representa un cambio registrado en la auditoría
+type AuditEntry struct {
+	Timestamp  time.Time  `json:"timestamp"`
+	Action     string     `json:"action"`
+	User       string     `json:"user"`
+	Hostname   string     `json:"hostname"`
+	Profile    string     `json:"profile,omitempty"`
+	Zone       string     `json:"zone"`
+	Name       string     `json:"name"`
+	Before     *DNSRecord `json:"before,omitempty"`
+	After      *DNSRecord `json:"after,omitempty"`
+	ResponseID string     `json:"response_id,omitempty"`
+}
+
+// Auditor escribe una línea JSON por cada cambio realizado sobre la zona
+type Auditor struct {
+	mu       sync.Mutex
+	w        io.WriteCloser
+	user     string
+	hostname string
+	profile  string
+	zone     string
+	now      func() time.Time
+}
+
+// NewAuditor crea un auditor que escribe en w usando los datos de la configuración
+func NewAuditor(w io.WriteCloser, config *Config) *Auditor {
+	hostname, _ := os.Hostname()
+	return &Auditor{
+		w:        w,
+		user:     currentUsername(),
+		hostname: hostname,
+		profile:  config.Profile,
+		zone:     config.ZoneID,
+		now:      time.Now,
+	}
+}
+
+// OpenAuditor abre el destino de auditoría indicado en CLOUDFLARE_AUDIT_LOG.
+// Devuelve nil si la auditoría está desactivada.
+func OpenAuditor(config *Config) (*Auditor, error) {
+	switch config.AuditLog {
+	case auditLogDisabled:
+		return nil, nil
+	case auditLogSyslog:
+		w, err := openSyslog()
+		if err != nil {
+			return nil, err
+		}
+		return NewAuditor(w, config), nil
+	}
+
+	path, err := config.AuditLogPath()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return NewAuditor(file, config), nil
+}
+
+// AuditLogPath devuelve la ruta del archivo de auditoría configurado
+func (c *Config) AuditLogPath() (string, error) {
+	switch c.AuditLog {
+	case auditLogDisabled:
+		return "", fmt.Errorf("la auditoría está desactivada")
+	case auditLogSyslog:
+		return "", fmt.Errorf("la auditoría se envía a syslog y no se puede consultar desde un archivo")
+	case "":
+		return c.StatePath(auditLogFileName)
+	}
+	return c.AuditLog, nil
+}
+
+// Record escribe una entrada en el registro de auditoría
+func (a *Auditor) Record(action string, before, after *DNSRecord, responseID string) error {
+	entry := AuditEntry{
+		Timestamp:  a.now().UTC(),
+		Action:     action,
+		User:       a.user,
+		Hostname:   a.hostname,
+		Profile:    a.profile,
+		Zone:       a.zone,
+		Before:     before,
+		After:      after,
+		ResponseID: responseID,
+	}
+	if after != nil {
+		entry.Name = after.Name
+	} else if before != nil {
+		entry.Name = before.Name
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, err = a.w.Write(append(line, '\n'))
+	return err
+}
+
+// Close vuelca a disco las entradas escritas, si el destino es un archivo, y lo cierra
+func (a *Auditor) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if file, ok := a.w.(interface{ Sync() error }); ok {
+		if err := file.Sync(); err != nil {
+			a.w.Close()
+			return err
+		}
+	}
+	return a.w.Close()
+}
+
+// audit registra un cambio si el cliente tiene un auditor configurado. El cambio ya se aplicó,
+// así que un fallo al escribir la auditoría se registra como error pero no se devuelve.
+func (c *CloudflareClient) audit(action string, before, after *DNSRecord, responseID string) {
+	if c.auditor == nil {
+		return
+	}
+	if err := c.auditor.Record(action, before, after, responseID); err != nil {
+		name := ""
+		if after != nil {
+			name = after.Name
+		} else if before != nil {
+			name = before.Name
+		}
+		logger.Error("el cambio se aplicó pero no se pudo registrar en la auditoría", "action", action, "name", name, "ray_id", responseID, "error", err)
+	}
+}
+
+// AuditFilter define los criterios para consultar el registro de auditoría
+type AuditFilter struct {
+	Name  string
+	Since time.Time
+	Until time.Time
+}
+
+// Matches indica si la entrada cumple los criterios del filtro
+func (f AuditFilter) Matches(entry AuditEntry) bool {
+	if f.Name != "" && !strings.EqualFold(entry.Name, f.Name) {
+		return false
+	}
+	if !f.Since.IsZero() && entry.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && entry.Timestamp.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// ReadAuditLog lee las entradas de auditoría que cumplen el filtro, en orden cronológico
+func ReadAuditLog(r io.Reader, filter AuditFilter) ([]AuditEntry, error) {
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var entry AuditEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return nil, fmt.Errorf("línea %d del registro de auditoría inválida: %v", lineNumber, err)
+		}
+		if filter.Matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// currentUsername devuelve el usuario del sistema operativo que ejecuta la herramienta
+func currentUsername() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
@@ -0,0 +1,13 @@
+//go:build !windows && !plan9
+
+package core
+
+import (
+	"io"
+	"log/syslog"
+)
+
+// openSyslog abre una conexión con el syslog local para la auditoría
+func openSyslog() (io.WriteCloser, error) {
+	return syslog.New(syslog.LOG_NOTICE|syslog.LOG_USER, "cloudflare-domain-controller")
+}
@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package core
+
+import (
+	"fmt"
+	"io"
+)
+
+// openSyslog no está disponible en esta plataforma
+func openSyslog() (io.WriteCloser, error) {
+	return nil, fmt.Errorf("syslog no está disponible en esta plataforma")
+}
@@ -0,0 +1,140 @@
+package core_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"cloudflare-domain-controller/core"
+	"cloudflare-domain-controller/core/coretest"
+)
+
+// nopWriteCloser adapta un bytes.Buffer para usarlo como destino de auditoría
+type nopWriteCloser struct {
+	*bytes.Buffer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestAuditorRecordsMutations(t *testing.T) {
+	server := coretest.NewServer(t, "test-domain.com")
+	config := server.Config()
+	config.Profile = "produccion"
+	client := server.NewClient(config)
+
+	var buf bytes.Buffer
+	auditor := core.NewAuditor(nopWriteCloser{&buf}, config)
+	client.SetAuditor(auditor)
+
+	record := &core.DNSRecord{Name: "audit.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1}
+	if err := client.CreateDNSRecord(record); err != nil {
+		t.Fatalf("Error al crear el registro DNS: %v", err)
+	}
+	record.Content = "192.0.2.2"
+	if err := client.UpdateDNSRecord(record.ID, record); err != nil {
+		t.Fatalf("Error al actualizar el registro DNS: %v", err)
+	}
+	if err := client.DeleteDNSRecord(record.ID); err != nil {
+		t.Fatalf("Error al eliminar el registro DNS: %v", err)
+	}
+	if len(server.Records()) != 0 {
+		t.Fatalf("El registro no se eliminó")
+	}
+
+	entries, err := core.ReadAuditLog(strings.NewReader(buf.String()), core.AuditFilter{})
+	if err != nil {
+		t.Fatalf("Error al leer la auditoría: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Número de entradas incorrecto: esperado 3, obtenido %d", len(entries))
+	}
+
+	create, update, remove := entries[0], entries[1], entries[2]
+	if create.Action != core.AuditCreate || create.Before != nil || create.After == nil || create.After.ID != record.ID {
+		t.Errorf("Entrada de creación incorrecta: %+v", create)
+	}
+	if update.Action != core.AuditUpdate || update.Before.Content != "192.0.2.1" || update.After.Content != "192.0.2.2" {
+		t.Errorf("Entrada de actualización incorrecta: %+v", update)
+	}
+	if remove.Action != core.AuditDelete || remove.Before == nil || remove.After != nil {
+		t.Errorf("Entrada de eliminación incorrecta: %+v", remove)
+	}
+	for _, entry := range entries {
+		if entry.Name != "audit.test-domain.com" || entry.Zone != coretest.ZoneID || entry.Profile != "produccion" {
+			t.Errorf("Metadatos incorrectos en la entrada: %+v", entry)
+		}
+		if entry.ResponseID != "coretest" {
+			t.Errorf("ID de respuesta incorrecto: esperado 'coretest', obtenido '%s'", entry.ResponseID)
+		}
+	}
+}
+
+// failingWriteCloser es un destino de auditoría en el que no se puede escribir
+type failingWriteCloser struct{}
+
+func (failingWriteCloser) Write([]byte) (int, error) { return 0, errors.New("disco lleno") }
+func (failingWriteCloser) Close() error              { return nil }
+
+func TestAuditFailureDoesNotFailMutation(t *testing.T) {
+	server := coretest.NewServer(t, "test-domain.com")
+	config := server.Config()
+	client := server.NewClient(config)
+	client.SetAuditor(core.NewAuditor(failingWriteCloser{}, config))
+
+	// El cambio ya se aplicó en Cloudflare: el fallo de la auditoría no se devuelve como error
+	record := &core.DNSRecord{Name: "audit.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1}
+	if err := client.CreateDNSRecord(record); err != nil {
+		t.Fatalf("Error al crear el registro DNS: %v", err)
+	}
+	record.Content = "192.0.2.2"
+	if err := client.UpdateDNSRecord(record.ID, record); err != nil {
+		t.Fatalf("Error al actualizar el registro DNS: %v", err)
+	}
+	if _, err := client.BatchDNSRecords(&core.BatchOperations{Posts: []*core.DNSRecord{{Name: "otro.test-domain.com", Type: "A", Content: "192.0.2.3", TTL: 1}}}); err != nil {
+		t.Fatalf("Error en el batch: %v", err)
+	}
+	if err := client.DeleteDNSRecord(record.ID); err != nil {
+		t.Fatalf("Error al eliminar el registro DNS: %v", err)
+	}
+	if records := server.Records(); len(records) != 1 || records[0].Name != "otro.test-domain.com" {
+		t.Errorf("Registros incorrectos: %+v", records)
+	}
+}
+
+func TestReadAuditLogFilter(t *testing.T) {
+	log := `{"timestamp":"2026-10-01T10:00:00Z","action":"create","name":"a.test-domain.com"}
+{"timestamp":"2026-10-02T10:00:00Z","action":"update","name":"b.test-domain.com"}
+{"timestamp":"2026-10-03T10:00:00Z","action":"delete","name":"A.test-domain.com"}
+`
+	tests := []struct {
+		name   string
+		filter core.AuditFilter
+		want   []string
+	}{
+		{"sin filtro", core.AuditFilter{}, []string{"create", "update", "delete"}},
+		{"por nombre", core.AuditFilter{Name: "a.test-domain.com"}, []string{"create", "delete"}},
+		{"desde", core.AuditFilter{Since: time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)}, []string{"update", "delete"}},
+		{"rango", core.AuditFilter{
+			Since: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+			Until: time.Date(2026, 10, 2, 12, 0, 0, 0, time.UTC),
+		}, []string{"update"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := core.ReadAuditLog(strings.NewReader(log), tt.filter)
+			if err != nil {
+				t.Fatalf("Error al leer la auditoría: %v", err)
+			}
+			var actions []string
+			for _, entry := range entries {
+				actions = append(actions, entry.Action)
+			}
+			if strings.Join(actions, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Entradas incorrectas: esperado %v, obtenido %v", tt.want, actions)
+			}
+		})
+	}
+}
@@ -0,0 +1,344 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// BatchOperations agrupa los cambios que se envían al endpoint /dns_records/batch.
+// Cloudflare los ejecuta de forma atómica en el orden: eliminaciones, patches, puts y posts.
+type BatchOperations struct {
+	Deletes []*DNSRecord
+	Patches []*DNSRecord
+	Puts    []*DNSRecord
+	Posts   []*DNSRecord
+}
+
+// Len devuelve el número total de operaciones
+func (b *BatchOperations) Len() int {
+	return len(b.Deletes) + len(b.Patches) + len(b.Puts) + len(b.Posts)
+}
+
+// ErrBatchUnsupported indica que el endpoint batch no está disponible y que, por tanto, las
+// operaciones no se aplicaron. ApplyBatchSequentially las aplica una a una, sin atomicidad.
+var ErrBatchUnsupported = errors.New("el endpoint batch no está disponible; no se aplicó ningún cambio")
+
+// BatchResult contiene los registros devueltos por cada tipo de operación
+type BatchResult struct {
+	Deletes []*DNSRecord `json:"deletes"`
+	Patches []*DNSRecord `json:"patches"`
+	Puts    []*DNSRecord `json:"puts"`
+	Posts   []*DNSRecord `json:"posts"`
+}
+
+// batchID es el cuerpo de una eliminación dentro de una solicitud batch
+type batchID struct {
+	ID string `json:"id"`
+}
+
+// batchRequest es el cuerpo de la solicitud al endpoint /dns_records/batch
+type batchRequest struct {
+	Deletes []batchID    `json:"deletes,omitempty"`
+	Patches []*DNSRecord `json:"patches,omitempty"`
+	Puts    []*DNSRecord `json:"puts,omitempty"`
+	Posts   []*DNSRecord `json:"posts,omitempty"`
+}
+
+// BatchDNSRecords aplica todas las operaciones en una única solicitud atómica.
+// Si el endpoint batch no está disponible devuelve ErrBatchUnsupported sin aplicar nada.
+func (c *CloudflareClient) BatchDNSRecords(ops *BatchOperations) (batch *BatchResult, err error) {
+	c, span := c.startSpan("BatchDNSRecords",
+		attribute.Int("cloudflare.batch.deletes", len(ops.Deletes)),
+		attribute.Int("cloudflare.batch.patches", len(ops.Patches)),
+		attribute.Int("cloudflare.batch.puts", len(ops.Puts)),
+		attribute.Int("cloudflare.batch.posts", len(ops.Posts)),
+	)
+	defer func() { endSpan(span, err) }()
+
+	// Validar configuración
+	if err := c.config.Validate(); err != nil {
+		return nil, err
+	}
+	if ops.Len() == 0 {
+		return &BatchResult{}, nil
+	}
+
+	// Validar todos los registros antes de enviar la solicitud
+	for _, group := range [][]*DNSRecord{ops.Patches, ops.Puts, ops.Posts} {
+		for _, record := range group {
+			if err := ValidateRecord(record); err != nil {
+				return nil, fmt.Errorf("%s: %w", describeBatchRecord(record), err)
+			}
+		}
+	}
+
+	body := batchRequest{Patches: ops.Patches, Puts: ops.Puts, Posts: ops.Posts}
+	for _, record := range ops.Deletes {
+		body.Deletes = append(body.Deletes, batchID{ID: record.ID})
+	}
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Obtener el estado anterior solo si hay que auditar los cambios
+	var befores map[string]*DNSRecord
+	if c.auditor != nil {
+		befores = make(map[string]*DNSRecord)
+		for _, group := range [][]*DNSRecord{ops.Deletes, ops.Patches, ops.Puts} {
+			for _, record := range group {
+				before, err := c.GetDNSRecord(record.ID)
+				if err != nil {
+					return nil, err
+				}
+				befores[record.ID] = before
+			}
+		}
+	}
+
+	url := fmt.Sprintf("%s/zones/%s/dns_records/batch", c.config.BaseURL, c.config.ZoneID)
+	resp, err := c.doRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		if batchUnsupported(err) {
+			return nil, fmt.Errorf("%w: %w", ErrBatchUnsupported, err)
+		}
+		return nil, err
+	}
+
+	var result struct {
+		Result BatchResult `json:"result"`
+	}
+	if err := json.Unmarshal(resp.Body, &result); err != nil {
+		return nil, err
+	}
+
+	if c.auditor != nil {
+		for _, record := range ops.Deletes {
+			c.audit(AuditDelete, befores[record.ID], nil, resp.RayID)
+		}
+		for _, group := range [][]*DNSRecord{result.Result.Patches, result.Result.Puts} {
+			for _, record := range group {
+				c.audit(AuditUpdate, befores[record.ID], record, resp.RayID)
+			}
+		}
+		for _, record := range result.Result.Posts {
+			c.audit(AuditCreate, nil, record, resp.RayID)
+		}
+	}
+
+	return &result.Result, nil
+}
+
+// ApplyBatchSequentially aplica las operaciones una a una, en el mismo orden que el endpoint batch.
+// A diferencia de BatchDNSRecords no es atómico: si una operación falla las anteriores ya se aplicaron.
+func ApplyBatchSequentially(provider DNSProvider, ops *BatchOperations) (*BatchResult, error) {
+	result := &BatchResult{}
+
+	for _, record := range ops.Deletes {
+		if err := provider.DeleteDNSRecord(record.ID); err != nil {
+			return result, fmt.Errorf("error al eliminar %s: %w", describeBatchRecord(record), err)
+		}
+		result.Deletes = append(result.Deletes, record)
+	}
+	for _, record := range ops.Patches {
+		if err := provider.UpdateDNSRecord(record.ID, record); err != nil {
+			return result, fmt.Errorf("error al actualizar %s: %w", describeBatchRecord(record), err)
+		}
+		result.Patches = append(result.Patches, record)
+	}
+	for _, record := range ops.Puts {
+		if err := provider.ReplaceDNSRecord(record.ID, record); err != nil {
+			return result, fmt.Errorf("error al reemplazar %s: %w", describeBatchRecord(record), err)
+		}
+		result.Puts = append(result.Puts, record)
+	}
+	for _, record := range ops.Posts {
+		if err := provider.CreateDNSRecord(record); err != nil {
+			return result, fmt.Errorf("error al crear %s: %w", describeBatchRecord(record), err)
+		}
+		result.Posts = append(result.Posts, record)
+	}
+
+	return result, nil
+}
+
+// batchUnsupported indica si el error se debe a que el endpoint batch no está disponible
+func batchUnsupported(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+// describeBatchRecord devuelve una descripción corta de un registro para los mensajes de error
+func describeBatchRecord(record *DNSRecord) string {
+	if record.Name == "" {
+		return record.ID
+	}
+	return record.Name + " " + record.Type
+}
+
+// Acciones admitidas en los archivos de operaciones batch
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// BatchOperation representa una línea de un archivo de operaciones batch.
+// Los registros se identifican por nombre y, opcionalmente, por tipo y contenido.
+type BatchOperation struct {
+	Action  string `json:"action"`
+	Name    string `json:"name"`
+	Type    string `json:"type,omitempty"`
+	Content string `json:"content,omitempty"`
+	TTL     int    `json:"ttl,omitempty"`
+	Proxied *bool  `json:"proxied,omitempty"`
+
+	// Match permite elegir el registro a actualizar o eliminar por su contenido actual
+	Match string `json:"match,omitempty"`
+
+	Line int `json:"-"`
+}
+
+// ReadBatchOperations lee operaciones en formato JSON, una por línea.
+// Las líneas vacías y las que comienzan con '#' se ignoran.
+func ReadBatchOperations(r io.Reader) ([]BatchOperation, error) {
+	var ops []BatchOperation
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var op BatchOperation
+		decoder := json.NewDecoder(strings.NewReader(line))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&op); err != nil {
+			return nil, fmt.Errorf("línea %d: %v", lineNumber, err)
+		}
+		op.Line = lineNumber
+
+		switch op.Action {
+		case BatchCreate:
+			if op.Type == "" || op.Content == "" {
+				return nil, fmt.Errorf("línea %d: create requiere type y content", lineNumber)
+			}
+		case BatchUpdate:
+			if op.Content == "" && op.TTL == 0 && op.Proxied == nil {
+				return nil, fmt.Errorf("línea %d: update no modifica ningún campo", lineNumber)
+			}
+		case BatchDelete:
+		default:
+			return nil, fmt.Errorf("línea %d: acción desconocida %q", lineNumber, op.Action)
+		}
+		if op.Name == "" {
+			return nil, fmt.Errorf("línea %d: falta el nombre del registro", lineNumber)
+		}
+		ops = append(ops, op)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
+
+// PlanBatch traduce las operaciones a cambios sobre los registros existentes de la zona.
+// Cada registro existente solo puede aparecer en una operación: Cloudflare rechazaría el batch entero.
+func (c *Config) PlanBatch(ops []BatchOperation, existing []*DNSRecord) (*BatchOperations, error) {
+	plan := &BatchOperations{}
+	// Línea de la operación que ya usa cada registro existente
+	targeted := map[string]int{}
+
+	for _, op := range ops {
+		name, err := c.ResolveName(op.Name)
+		if err != nil {
+			return nil, fmt.Errorf("línea %d: %v", op.Line, err)
+		}
+
+		if op.Action == BatchCreate {
+			content, err := NormalizeContent(op.Type, op.Content)
+			if err != nil {
+				return nil, fmt.Errorf("línea %d: %v", op.Line, err)
+			}
+			if strings.EqualFold(op.Type, "TXT") {
+				content = ChunkTXT(content)
+			}
+			record := &DNSRecord{Name: name, Type: op.Type, Content: content, TTL: op.TTL}
+			if record.TTL == 0 {
+				record.TTL = 1 // Auto
+			}
+			if op.Proxied != nil {
+				record.Proxied = *op.Proxied
+			}
+			plan.Posts = append(plan.Posts, record)
+			continue
+		}
+
+		// Buscar el registro existente al que se refiere la operación
+		var matches []*DNSRecord
+		for _, record := range existing {
+			if !strings.EqualFold(record.Name, name) {
+				continue
+			}
+			if op.Type != "" && !strings.EqualFold(record.Type, op.Type) {
+				continue
+			}
+			if op.Match != "" && record.Content != op.Match {
+				continue
+			}
+			matches = append(matches, record)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("línea %d: no se encontró el registro DNS para %s", op.Line, name)
+		}
+		if len(matches) > 1 {
+			return nil, fmt.Errorf("línea %d: %s coincide con %d registros; indica type o match", op.Line, name, len(matches))
+		}
+		current := matches[0]
+		if line, ok := targeted[current.ID]; ok {
+			return nil, fmt.Errorf("línea %d: %s %s ya se modifica en la línea %d; cada registro solo puede aparecer en una operación", op.Line, current.Name, current.Type, line)
+		}
+		targeted[current.ID] = op.Line
+
+		if op.Action == BatchDelete {
+			plan.Deletes = append(plan.Deletes, current)
+			continue
+		}
+
+		record := copyRecord(current)
+		if op.Content != "" {
+			if record.Content, err = NormalizeContent(record.Type, op.Content); err != nil {
+				return nil, fmt.Errorf("línea %d: %v", op.Line, err)
+			}
+			if record.Type == "TXT" {
+				record.Content = ChunkTXT(record.Content)
+			}
+		}
+		if op.TTL != 0 {
+			record.TTL = op.TTL
+		}
+		if op.Proxied != nil {
+			record.Proxied = *op.Proxied
+		}
+		plan.Patches = append(plan.Patches, record)
+	}
+
+	return plan, nil
+}
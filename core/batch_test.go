@@ -0,0 +1,176 @@
+package core_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"cloudflare-domain-controller/core"
+)
+
+func TestReadBatchOperations(t *testing.T) {
+	input := `# cambios de la migración
+{"action":"create","name":"nuevo","type":"A","content":"192.0.2.1"}
+
+{"action":"update","name":"web","type":"A","content":"192.0.2.2","proxied":true}
+{"action":"delete","name":"viejo.test-domain.com"}
+`
+	ops, err := core.ReadBatchOperations(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Error al leer las operaciones: %v", err)
+	}
+	if len(ops) != 3 {
+		t.Fatalf("Número de operaciones incorrecto: esperado 3, obtenido %d", len(ops))
+	}
+	if ops[1].Line != 4 || ops[1].Proxied == nil || !*ops[1].Proxied {
+		t.Errorf("Operación update incorrecta: %+v", ops[1])
+	}
+
+	invalid := []string{
+		`{"action":"create","name":"x","type":"A"}`,
+		`{"action":"rename","name":"x"}`,
+		`{"action":"delete"}`,
+		`{"action":"delete","name":"x","extra":1}`,
+		`no es json`,
+	}
+	for _, line := range invalid {
+		if _, err := core.ReadBatchOperations(strings.NewReader(line)); err == nil {
+			t.Errorf("Se esperaba un error para %q", line)
+		}
+	}
+}
+
+func TestPlanBatch(t *testing.T) {
+	config := &core.Config{DomainName: "test-domain.com"}
+	existing := []*core.DNSRecord{
+		{ID: "1", Name: "web.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1},
+		{ID: "2", Name: "rr.test-domain.com", Type: "A", Content: "192.0.2.10", TTL: 1},
+		{ID: "3", Name: "rr.test-domain.com", Type: "A", Content: "192.0.2.11", TTL: 1},
+	}
+
+	ops := []core.BatchOperation{
+		{Action: core.BatchCreate, Name: "nuevo", Type: "A", Content: "192.0.2.5"},
+		{Action: core.BatchUpdate, Name: "web", Content: "192.0.2.2"},
+		{Action: core.BatchDelete, Name: "rr", Match: "192.0.2.11"},
+	}
+	plan, err := config.PlanBatch(ops, existing)
+	if err != nil {
+		t.Fatalf("Error al planificar las operaciones: %v", err)
+	}
+	if len(plan.Posts) != 1 || plan.Posts[0].Name != "nuevo.test-domain.com" || plan.Posts[0].TTL != 1 {
+		t.Errorf("Creación incorrecta: %+v", plan.Posts)
+	}
+	if len(plan.Patches) != 1 || plan.Patches[0].ID != "1" || plan.Patches[0].Content != "192.0.2.2" {
+		t.Errorf("Actualización incorrecta: %+v", plan.Patches)
+	}
+	if existing[0].Content != "192.0.2.1" {
+		t.Error("PlanBatch no debe modificar los registros existentes")
+	}
+	if len(plan.Deletes) != 1 || plan.Deletes[0].ID != "3" {
+		t.Errorf("Eliminación incorrecta: %+v", plan.Deletes)
+	}
+
+	if _, err := config.PlanBatch([]core.BatchOperation{{Action: core.BatchDelete, Name: "rr", Line: 7}}, existing); err == nil || !strings.Contains(err.Error(), "línea 7") {
+		t.Errorf("Se esperaba un error de registro ambiguo en la línea 7, obtenido %v", err)
+	}
+	if _, err := config.PlanBatch([]core.BatchOperation{{Action: core.BatchUpdate, Name: "falta", Content: "x"}}, existing); err == nil {
+		t.Error("Se esperaba un error para un registro inexistente")
+	}
+
+	// Dos operaciones sobre el mismo registro se rechazan antes de enviar nada
+	duplicated := []core.BatchOperation{
+		{Action: core.BatchUpdate, Name: "web", Content: "192.0.2.2", Line: 1},
+		{Action: core.BatchDelete, Name: "web", Line: 2},
+	}
+	if _, err := config.PlanBatch(duplicated, existing); err == nil || !strings.Contains(err.Error(), "línea 2") {
+		t.Errorf("Se esperaba un error por registro repetido en la línea 2, obtenido %v", err)
+	}
+
+	// Los TXT largos se dividen en cadenas de 255 bytes también al crearlos
+	long := strings.Repeat("a", 300)
+	plan, err = config.PlanBatch([]core.BatchOperation{{Action: core.BatchCreate, Name: "txt", Type: "TXT", Content: long}}, existing)
+	if err != nil {
+		t.Fatalf("Error al planificar las operaciones: %v", err)
+	}
+	if got := plan.Posts[0].Content; got != core.ChunkTXT(long) || got == long {
+		t.Errorf("El TXT no se dividió: %q", got)
+	}
+}
+
+func TestBatchDNSRecords(t *testing.T) {
+	server, client := newTestServer(t)
+	oldID := server.Add(core.DNSRecord{Name: "viejo.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+	webID := server.Add(core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "192.0.2.2", TTL: 1})
+
+	ops := &core.BatchOperations{
+		Deletes: []*core.DNSRecord{{ID: oldID}},
+		Patches: []*core.DNSRecord{{ID: webID, Name: "web.test-domain.com", Type: "A", Content: "192.0.2.3", TTL: 1}},
+		Posts:   []*core.DNSRecord{{Name: "nuevo.test-domain.com", Type: "A", Content: "192.0.2.4", TTL: 1}},
+	}
+	result, err := client.BatchDNSRecords(ops)
+	if err != nil {
+		t.Fatalf("Error al aplicar las operaciones: %v", err)
+	}
+	if len(result.Deletes) != 1 || len(result.Patches) != 1 || len(result.Posts) != 1 {
+		t.Errorf("Resultado incorrecto: %+v", result)
+	}
+
+	if server.Record(oldID) != nil {
+		t.Error("El registro no se eliminó")
+	}
+	if web := server.Record(webID); web == nil || web.Content != "192.0.2.3" {
+		t.Errorf("El registro no se actualizó: %+v", web)
+	}
+	if records, _ := client.FindDNSRecords("nuevo.test-domain.com", "A"); len(records) != 1 {
+		t.Error("El registro no se creó")
+	}
+	if calls := batchRequests(server); calls != 1 {
+		t.Errorf("Se esperaba una única solicitud batch: %d llamadas", calls)
+	}
+}
+
+func TestBatchDNSRecordsUnsupported(t *testing.T) {
+	server, client := newTestServer(t)
+	server.DisableBatch()
+	oldID := server.Add(core.DNSRecord{Name: "viejo.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+
+	// Sin el endpoint batch no se aplica nada: la operación no sería atómica
+	ops := &core.BatchOperations{
+		Deletes: []*core.DNSRecord{{ID: oldID}},
+		Posts:   []*core.DNSRecord{{Name: "nuevo.test-domain.com", Type: "A", Content: "192.0.2.4", TTL: 1}},
+	}
+	if _, err := client.BatchDNSRecords(ops); !errors.Is(err, core.ErrBatchUnsupported) {
+		t.Fatalf("Se esperaba ErrBatchUnsupported: %v", err)
+	}
+	if len(server.Records()) != 1 || server.Record(oldID) == nil {
+		t.Errorf("No se debía modificar la zona: %v", server.Records())
+	}
+	if _, err := server.BatchDNSRecords(ops); !errors.Is(err, core.ErrBatchUnsupported) {
+		t.Errorf("El backend debe devolver el mismo error: %v", err)
+	}
+
+	// ApplyBatchSequentially aplica las operaciones una a una
+	result, err := core.ApplyBatchSequentially(client, ops)
+	if err != nil || len(result.Deletes) != 1 || len(result.Posts) != 1 {
+		t.Fatalf("Error al aplicar las operaciones una a una: %+v, %v", result, err)
+	}
+	if server.Record(oldID) != nil || len(server.Records()) != 1 {
+		t.Errorf("Registros tras aplicar las operaciones: %v", server.Records())
+	}
+}
+
+func TestBatchDNSRecordsIsAtomic(t *testing.T) {
+	server, client := newTestServer(t)
+	id := server.Add(core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+
+	ops := &core.BatchOperations{
+		Deletes: []*core.DNSRecord{{ID: id}},
+		Patches: []*core.DNSRecord{{ID: "no-existe", Name: "x.test-domain.com", Type: "A", Content: "192.0.2.2", TTL: 1}},
+	}
+	if _, err := client.BatchDNSRecords(ops); err == nil {
+		t.Fatal("Se esperaba un error")
+	}
+	if server.Record(id) == nil {
+		t.Error("Una operación batch fallida no debe aplicar ningún cambio")
+	}
+}
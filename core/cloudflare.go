@@ -2,29 +2,94 @@ package core
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Config almacena la configuración de Cloudflare
 type Config struct {
-	APIToken   string
-	ZoneID     string
-	BaseURL    string
-	DomainName string
+	APIToken    string
+	ZoneID      string
+	BaseURL     string
+	DomainName  string
+	Profile     string
+	StateDir    string
+	AuditLog    string
+	JournalSize int
+	Nameservers []string
+	RateLimit   int
 }
 
 // NewConfig crea una nueva configuración desde variables de entorno
 func NewConfig() *Config {
 	return &Config{
-		APIToken:   os.Getenv("CLOUDFLARE_API_TOKEN"),
-		ZoneID:     os.Getenv("CLOUDFLARE_ZONE_ID"),
-		DomainName: os.Getenv("CLOUDFLARE_DOMAIN_NAME"),
-		BaseURL:    "https://api.cloudflare.com/client/v4",
+		APIToken:    os.Getenv("CLOUDFLARE_API_TOKEN"),
+		ZoneID:      os.Getenv("CLOUDFLARE_ZONE_ID"),
+		DomainName:  os.Getenv("CLOUDFLARE_DOMAIN_NAME"),
+		BaseURL:     "https://api.cloudflare.com/client/v4",
+		Profile:     os.Getenv("CLOUDFLARE_PROFILE"),
+		StateDir:    defaultStateDir(),
+		AuditLog:    os.Getenv("CLOUDFLARE_AUDIT_LOG"),
+		JournalSize: envInt("CLOUDFLARE_JOURNAL_SIZE", DefaultJournalSize),
+		Nameservers: envList("CLOUDFLARE_NAMESERVERS"),
+		RateLimit:   envInt("CLOUDFLARE_RATE_LIMIT", DefaultRateLimit),
+	}
+}
+
+// envInt lee una variable de entorno numérica, devolviendo el valor por defecto si no es válida
+func envInt(name string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// envList lee una variable de entorno con valores separados por comas
+func envList(name string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(name), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// defaultStateDir devuelve el directorio donde se guarda el estado local de la herramienta
+func defaultStateDir() string {
+	if dir := os.Getenv("CLOUDFLARE_STATE_DIR"); dir != "" {
+		return dir
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(configDir, "cloudflare-domain-controller")
+}
+
+// StatePath devuelve la ruta de un archivo dentro del directorio de estado, creándolo si es necesario
+func (c *Config) StatePath(name string) (string, error) {
+	if c.StateDir == "" {
+		return "", fmt.Errorf("no se pudo determinar el directorio de estado; configura CLOUDFLARE_STATE_DIR")
+	}
+	if err := os.MkdirAll(c.StateDir, 0o700); err != nil {
+		return "", err
 	}
+	return filepath.Join(c.StateDir, name), nil
 }
 
 // Validate verifica que todas las configuraciones necesarias estén presentes
@@ -43,62 +108,208 @@ func (c *Config) Validate() error {
 
 // DNSRecord representa un registro DNS
 type DNSRecord struct {
-	ID      string `json:"id,omitempty"`
-	Name    string `json:"name"`
-	Type    string `json:"type"`
-	Content string `json:"content"`
-	TTL     int    `json:"ttl"`
-	Proxied bool   `json:"proxied"`
+	ID       string   `json:"id,omitempty"`
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Content  string   `json:"content"`
+	TTL      int      `json:"ttl"`
+	Proxied  bool     `json:"proxied"`
+	Priority *int     `json:"priority,omitempty"`
+	Comment  string   `json:"comment,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
 }
 
 // CloudflareClient representa un cliente para interactuar con la API de Cloudflare
 type CloudflareClient struct {
-	config *Config
+	config  *Config
+	auditor *Auditor
+	limiter *RateLimiter
+	ctx     context.Context
 }
 
 // NewCloudflareClient crea un nuevo cliente de Cloudflare
 func NewCloudflareClient(config *Config) *CloudflareClient {
-	return &CloudflareClient{
+	client := &CloudflareClient{
 		config: config,
 	}
+	if config.RateLimit > 0 {
+		client.limiter = NewRateLimiter(float64(config.RateLimit), rateLimitBurst)
+	}
+	return client
+}
+
+// SetAuditor configura el registro de auditoría en el que se anota cada cambio
+func (c *CloudflareClient) SetAuditor(auditor *Auditor) {
+	c.auditor = auditor
+}
+
+// SetRateLimiter configura el limitador compartido por todas las solicitudes del cliente
+func (c *CloudflareClient) SetRateLimiter(limiter *RateLimiter) {
+	c.limiter = limiter
+}
+
+// WithContext devuelve una copia del cliente cuyas solicitudes se cancelan con ctx.
+// La copia comparte la configuración, la auditoría y el limitador de solicitudes.
+func (c *CloudflareClient) WithContext(ctx context.Context) *CloudflareClient {
+	client := *c
+	client.ctx = ctx
+	return &client
+}
+
+// context devuelve el contexto de las solicitudes del cliente
+func (c *CloudflareClient) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// APIError representa una respuesta de la API de Cloudflare con un código de estado de error
+type APIError struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("error en la solicitud: %s - %s", e.Status, e.Body)
+}
+
+// apiResponse contiene el cuerpo de una respuesta de la API y sus metadatos
+type apiResponse struct {
+	Body  []byte
+	RayID string
 }
 
 // makeRequest realiza una solicitud HTTP a la API de Cloudflare
 func (c *CloudflareClient) makeRequest(method, url string, body io.Reader) ([]byte, error) {
-	req, err := http.NewRequest(method, url, body)
+	resp, err := c.doRequest(method, url, body)
 	if err != nil {
 		return nil, err
 	}
+	return resp.Body, nil
+}
+
+// doRequest realiza una solicitud HTTP y devuelve también el identificador CF-Ray de la respuesta.
+// Respeta el limitador de solicitudes y reintenta las respuestas 429 esperando lo que indique
+// la cabecera Retry-After o, si no la hay, un tiempo creciente.
+func (c *CloudflareClient) doRequest(method, url string, body io.Reader) (*apiResponse, error) {
+	// Leer el cuerpo una sola vez para poder repetir la solicitud
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = io.ReadAll(body); err != nil {
+			return nil, err
+		}
+	}
+	ctx := c.context()
+
+	for attempt := 0; ; attempt++ {
+		if c.limiter != nil {
+			if err := c.limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, respBody, err := c.send(ctx, method, url, payload, attempt)
+		if err != nil {
+			return nil, err
+		}
+		rayID := resp.Header.Get("CF-Ray")
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			apiRateLimited.inc()
+		}
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < maxRetries {
+			apiRetries.inc()
+			delay := retryDelay(resp.Header.Get("Retry-After"), attempt)
+			logger.Warn("límite de solicitudes de la API alcanzado, se reintenta", "method", method, "url", url, "retry_in", delay, "attempt", attempt+1)
+			if err := sleepContext(ctx, delay); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return nil, &APIError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(respBody)}
+		}
+
+		return &apiResponse{Body: respBody, RayID: rayID}, nil
+	}
+}
+
+// send realiza un intento de una solicitud dentro de su propio span y registra sus métricas.
+// No se propaga la traza a Cloudflare: los identificadores solo se exportan al recolector propio.
+func (c *CloudflareClient) send(ctx context.Context, method, url string, payload []byte, attempt int) (*http.Response, []byte, error) {
+	ctx, span := tracer.Start(ctx, "HTTP "+method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("http.request.method", method),
+		attribute.String("url.full", url),
+		attribute.String("url.template", c.endpointLabel(url)),
+		attribute.Int("http.request.resend_count", attempt),
+	))
+
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		endSpan(span, err)
+		return nil, nil, err
+	}
 
 	req.Header.Set("Authorization", "Bearer "+c.config.APIToken)
 	req.Header.Set("Content-Type", "application/json")
 
+	start := time.Now()
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		c.observeRequest(method, url, 0, time.Since(start))
+		logger.Debug("error en la solicitud a la API", "method", method, "url", url, "latency", time.Since(start), "error", err)
+		endSpan(span, err)
+		return nil, nil, err
 	}
-	defer resp.Body.Close()
-
 	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
 	if err != nil {
-		return nil, err
+		endSpan(span, err)
+		return nil, nil, err
 	}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("error en la solicitud: %s - %s", resp.Status, string(respBody))
+	latency := time.Since(start)
+	c.observeRequest(method, url, resp.StatusCode, latency)
+	rayID := resp.Header.Get("CF-Ray")
+	logger.Debug("solicitud a la API", "method", method, "url", url, "status", resp.StatusCode, "latency", latency, "ray_id", rayID, "attempt", attempt+1)
+	if logger.Enabled(ctx, LevelTrace) {
+		logger.Log(ctx, LevelTrace, "traza HTTP", "method", method, "url", url, "status", resp.StatusCode, "latency", latency, "ray_id", rayID,
+			"request_headers", redactHeaders(req.Header), "request_body", redactBody(payload),
+			"response_headers", redactHeaders(resp.Header), "response_body", redactBody(respBody))
 	}
 
-	return respBody, nil
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode), attribute.String("cloudflare.ray_id", rayID))
+	if resp.StatusCode >= 400 {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	span.End()
+	return resp, respBody, nil
 }
 
 // CreateDNSRecord crea un nuevo registro DNS
-func (c *CloudflareClient) CreateDNSRecord(record *DNSRecord) error {
+func (c *CloudflareClient) CreateDNSRecord(record *DNSRecord) (err error) {
+	c, span := c.startSpan("CreateDNSRecord", recordAttributes(record)...)
+	defer func() { endSpan(span, err) }()
+
 	// Validar configuración
 	if err := c.config.Validate(); err != nil {
 		return err
 	}
 	
+	// Validar el registro antes de enviarlo
+	if err := ValidateRecord(record); err != nil {
+		return err
+	}
+	
 	url := fmt.Sprintf("%s/zones/%s/dns_records", c.config.BaseURL, c.config.ZoneID)
 	
 	jsonData, err := json.Marshal(record)
@@ -106,38 +317,48 @@ func (c *CloudflareClient) CreateDNSRecord(record *DNSRecord) error {
 		return err
 	}
 
-	respBody, err := c.makeRequest("POST", url, bytes.NewBuffer(jsonData))
+	resp, err := c.doRequest("POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return err
 	}
 	
-	// Parsear la respuesta para obtener el ID del registro creado
-	var result map[string]interface{}
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return err
-	}
-	
-	// Verificar si hay resultados
-	resultData, ok := result["result"].(map[string]interface{})
-	if !ok {
-		return fmt.Errorf("no se pudo obtener el registro creado")
-	}
-	
-	// Asignar el ID al registro
-	if id, ok := resultData["id"].(string); ok {
-		record.ID = id
+	// Devolver en el registro el estado que guardó Cloudflare, con el ID asignado
+	created, err := parseRecordResult(resp.Body)
+	if err != nil {
+		return fmt.Errorf("no se pudo obtener el registro creado: %w", err)
 	}
-	
+	*record = *created
+
+	c.audit(AuditCreate, nil, created, resp.RayID)
 	return nil
 }
 
 // UpdateDNSRecord actualiza un registro DNS existente
-func (c *CloudflareClient) UpdateDNSRecord(recordID string, record *DNSRecord) error {
+func (c *CloudflareClient) UpdateDNSRecord(recordID string, record *DNSRecord) (err error) {
+	c, span := c.startSpan("UpdateDNSRecord", append(recordAttributes(record), attribute.String("dns.record.id", recordID))...)
+	defer func() { endSpan(span, err) }()
+	return c.writeDNSRecord("PATCH", recordID, record)
+}
+
+// ReplaceDNSRecord sobrescribe por completo un registro DNS existente
+func (c *CloudflareClient) ReplaceDNSRecord(recordID string, record *DNSRecord) (err error) {
+	c, span := c.startSpan("ReplaceDNSRecord", append(recordAttributes(record), attribute.String("dns.record.id", recordID))...)
+	defer func() { endSpan(span, err) }()
+	return c.writeDNSRecord("PUT", recordID, record)
+}
+
+// writeDNSRecord envía un registro existente con el método PATCH o PUT
+func (c *CloudflareClient) writeDNSRecord(method, recordID string, record *DNSRecord) error {
 	// Validar configuración
 	if err := c.config.Validate(); err != nil {
 		return err
 	}
 	
+	// Validar el registro antes de enviarlo
+	if err := ValidateRecord(record); err != nil {
+		return err
+	}
+	
 	url := fmt.Sprintf("%s/zones/%s/dns_records/%s", c.config.BaseURL, c.config.ZoneID, recordID)
 	
 	jsonData, err := json.Marshal(record)
@@ -145,12 +366,34 @@ func (c *CloudflareClient) UpdateDNSRecord(recordID string, record *DNSRecord) e
 		return err
 	}
 
-	_, err = c.makeRequest("PATCH", url, bytes.NewBuffer(jsonData))
-	return err
+	// Obtener el estado anterior solo si hay que auditar el cambio
+	var before *DNSRecord
+	if c.auditor != nil {
+		if before, err = c.GetDNSRecord(recordID); err != nil {
+			return err
+		}
+	}
+
+	resp, err := c.doRequest(method, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+
+	// Devolver en el registro el estado que guardó Cloudflare
+	after, err := parseRecordResult(resp.Body)
+	if err != nil {
+		return err
+	}
+	*record = *after
+	c.audit(AuditUpdate, before, after, resp.RayID)
+	return nil
 }
 
 // DeleteDNSRecord elimina un registro DNS
-func (c *CloudflareClient) DeleteDNSRecord(recordID string) error {
+func (c *CloudflareClient) DeleteDNSRecord(recordID string) (err error) {
+	c, span := c.startSpan("DeleteDNSRecord", attribute.String("dns.record.id", recordID))
+	defer func() { endSpan(span, err) }()
+
 	// Validar configuración
 	if err := c.config.Validate(); err != nil {
 		return err
@@ -158,102 +401,171 @@ func (c *CloudflareClient) DeleteDNSRecord(recordID string) error {
 	
 	url := fmt.Sprintf("%s/zones/%s/dns_records/%s", c.config.BaseURL, c.config.ZoneID, recordID)
 	
-	_, err := c.makeRequest("DELETE", url, nil)
-	return err
+	// Obtener el estado anterior solo si hay que auditar el cambio
+	var before *DNSRecord
+	if c.auditor != nil {
+		var err error
+		if before, err = c.GetDNSRecord(recordID); err != nil {
+			return err
+		}
+	}
+
+	resp, err := c.doRequest("DELETE", url, nil)
+	if err != nil {
+		return err
+	}
+	c.audit(AuditDelete, before, nil, resp.RayID)
+	return nil
+}
+
+// GetDNSRecord obtiene un registro DNS por su ID
+func (c *CloudflareClient) GetDNSRecord(recordID string) (record *DNSRecord, err error) {
+	c, span := c.startSpan("GetDNSRecord", attribute.String("dns.record.id", recordID))
+	defer func() { endSpan(span, err) }()
+
+	// Validar configuración
+	if err := c.config.Validate(); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/zones/%s/dns_records/%s", c.config.BaseURL, c.config.ZoneID, recordID)
+
+	respBody, err := c.makeRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseRecordResult(respBody)
+}
+
+// parseRecordResult extrae el registro del campo "result" de una respuesta de la API
+func parseRecordResult(respBody []byte) (*DNSRecord, error) {
+	var result struct {
+		Result *DNSRecord `json:"result"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, err
+	}
+	if result.Result == nil {
+		return nil, fmt.Errorf("la respuesta no contiene ningún registro")
+	}
+	return result.Result, nil
 }
 
 // GetDNSRecordByName obtiene un registro DNS por su nombre
-func (c *CloudflareClient) GetDNSRecordByName(name string) (*DNSRecord, error) {
+func (c *CloudflareClient) GetDNSRecordByName(name string) (record *DNSRecord, err error) {
+	c, span := c.startSpan("GetDNSRecordByName", attribute.String("dns.record.name", name))
+	defer func() { endSpan(span, err) }()
+
 	// Validar configuración
 	if err := c.config.Validate(); err != nil {
 		return nil, err
 	}
 	
 	// Construir el nombre completo si solo se proporciona el subdominio
-	fullName := name
-	if c.config.DomainName != "" {
-		// Verificar si el nombre ya incluye el dominio
-		if len(name) <= len(c.config.DomainName) || (len(name) > len(c.config.DomainName) && name[len(name)-len(c.config.DomainName)-1:] != "."+c.config.DomainName) {
-			fullName = name + "." + c.config.DomainName
-		}
+	fullName, err := c.config.ResolveName(name)
+	if err != nil {
+		return nil, err
 	}
 	
-	url := fmt.Sprintf("%s/zones/%s/dns_records?name=%s", c.config.BaseURL, c.config.ZoneID, fullName)
+	query := url.Values{}
+	query.Set("name", fullName)
+	endpoint := fmt.Sprintf("%s/zones/%s/dns_records?%s", c.config.BaseURL, c.config.ZoneID, query.Encode())
 	
-	respBody, err := c.makeRequest("GET", url, nil)
+	respBody, err := c.makeRequest("GET", endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	// Parsear la respuesta para obtener el registro
-	var result map[string]interface{}
+	var result struct {
+		Result []*DNSRecord `json:"result"`
+	}
 	if err := json.Unmarshal(respBody, &result); err != nil {
 		return nil, err
 	}
 
 	// Verificar si hay resultados
-	resultArray, ok := result["result"].([]interface{})
-	if !ok || len(resultArray) == 0 {
+	if len(result.Result) == 0 {
 		return nil, fmt.Errorf("no se encontró el registro DNS para %s", name)
 	}
 
 	// Tomar el primer resultado
-	recordData := resultArray[0].(map[string]interface{})
-	
-	record := &DNSRecord{
-		ID:      recordData["id"].(string),
-		Name:    recordData["name"].(string),
-		Type:    recordData["type"].(string),
-		Content: recordData["content"].(string),
-		TTL:     int(recordData["ttl"].(float64)),
-		Proxied: recordData["proxied"].(bool),
-	}
-
-	return record, nil
+	return result.Result[0], nil
 }
 
-// ListDNSRecords lista todos los registros DNS de la zona
-func (c *CloudflareClient) ListDNSRecords() ([]*DNSRecord, error) {
+// FindDNSRecords obtiene todos los registros con el nombre completo indicado.
+// Si recordType no está vacío solo se devuelven los registros de ese tipo.
+func (c *CloudflareClient) FindDNSRecords(name, recordType string) (records []*DNSRecord, err error) {
+	c, span := c.startSpan("FindDNSRecords", attribute.String("dns.record.name", name), attribute.String("dns.record.type", recordType))
+	defer func() { endSpan(span, err) }()
+
 	// Validar configuración
 	if err := c.config.Validate(); err != nil {
 		return nil, err
 	}
-	
-	url := fmt.Sprintf("%s/zones/%s/dns_records", c.config.BaseURL, c.config.ZoneID)
-	
-	respBody, err := c.makeRequest("GET", url, nil)
+
+	query := url.Values{}
+	query.Set("name", name)
+	if recordType != "" {
+		query.Set("type", recordType)
+	}
+	endpoint := fmt.Sprintf("%s/zones/%s/dns_records?%s", c.config.BaseURL, c.config.ZoneID, query.Encode())
+
+	respBody, err := c.makeRequest("GET", endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	// Parsear la respuesta para obtener los registros
-	var result map[string]interface{}
+	var result struct {
+		Result []*DNSRecord `json:"result"`
+	}
 	if err := json.Unmarshal(respBody, &result); err != nil {
 		return nil, err
 	}
-
-	// Verificar si hay resultados
-	resultArray, ok := result["result"].([]interface{})
-	if !ok || len(resultArray) == 0 {
+	if result.Result == nil {
 		return []*DNSRecord{}, nil
 	}
+	return result.Result, nil
+}
 
-	// Convertir los resultados a registros DNS
-	records := make([]*DNSRecord, len(resultArray))
-	for i, item := range resultArray {
-		recordData := item.(map[string]interface{})
+// listPageSize es el número de registros solicitados por página al listar la zona
+const listPageSize = 100
+
+// ListDNSRecords lista todos los registros DNS de la zona, recorriendo todas las páginas
+func (c *CloudflareClient) ListDNSRecords() (records []*DNSRecord, err error) {
+	c, span := c.startSpan("ListDNSRecords")
+	defer func() { endSpan(span, err) }()
+
+	// Validar configuración
+	if err := c.config.Validate(); err != nil {
+		return nil, err
+	}
+	
+	records = []*DNSRecord{}
+	for page := 1; ; page++ {
+		url := fmt.Sprintf("%s/zones/%s/dns_records?page=%d&per_page=%d", c.config.BaseURL, c.config.ZoneID, page, listPageSize)
 		
-		record := &DNSRecord{
-			ID:      recordData["id"].(string),
-			Name:    recordData["name"].(string),
-			Type:    recordData["type"].(string),
-			Content: recordData["content"].(string),
-			TTL:     int(recordData["ttl"].(float64)),
-			Proxied: recordData["proxied"].(bool),
+		respBody, err := c.makeRequest("GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		// Parsear la respuesta para obtener los registros y la paginación
+		var result struct {
+			Result     []*DNSRecord `json:"result"`
+			ResultInfo struct {
+				TotalPages int `json:"total_pages"`
+			} `json:"result_info"`
+		}
+		if err := json.Unmarshal(respBody, &result); err != nil {
+			return nil, err
+		}
+		records = append(records, result.Result...)
+
+		if page >= result.ResultInfo.TotalPages || len(result.Result) == 0 {
+			break
 		}
-		
-		records[i] = record
 	}
 
 	return records, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,150 @@
+package core
+
+import "strings"
+
+// Motivos por los que un registro existente entra en conflicto con uno nuevo
+const (
+	ConflictCNAME      = "cname"
+	ConflictDuplicate  = "duplicate"
+	ConflictDelegation = "delegation"
+)
+
+// Conflict describe un registro existente que impide crear o actualizar otro
+type Conflict struct {
+	Record *DNSRecord
+	Reason string
+}
+
+// Description devuelve una explicación legible del conflicto
+func (c Conflict) Description() string {
+	switch c.Reason {
+	case ConflictCNAME:
+		return "un CNAME no puede coexistir con otros registros con el mismo nombre"
+	case ConflictDuplicate:
+		return "ya existe un registro idéntico"
+	case ConflictDelegation:
+		return "la delegación NS hace que el registro no sea visible"
+	}
+	return c.Reason
+}
+
+// FindConflicts busca en los registros existentes los que entran en conflicto con candidate:
+// un CNAME junto a otros registros con el mismo nombre, un registro idéntico ya existente
+// o una delegación NS de un subdominio que oculta el registro. El registro con el mismo ID
+// que candidate se ignora, de modo que sirve también para comprobar actualizaciones.
+func FindConflicts(existing []*DNSRecord, candidate *DNSRecord, zone string) []Conflict {
+	var conflicts []Conflict
+	name := normalizeHostname(candidate.Name)
+	zone = normalizeHostname(zone)
+	candidateType := strings.ToUpper(candidate.Type)
+
+	for _, record := range existing {
+		if candidate.ID != "" && record.ID == candidate.ID {
+			continue
+		}
+		recordName := normalizeHostname(record.Name)
+		recordType := strings.ToUpper(record.Type)
+
+		if recordName == name {
+			switch {
+			case candidateType == "CNAME" || recordType == "CNAME":
+				conflicts = append(conflicts, Conflict{Record: record, Reason: ConflictCNAME})
+				continue
+			case recordType == candidateType && sameContent(recordType, record.Content, candidate.Content):
+				conflicts = append(conflicts, Conflict{Record: record, Reason: ConflictDuplicate})
+				continue
+			}
+		}
+
+		// Una delegación NS por debajo del ápice oculta todo lo que hay en su nombre y por debajo
+		if recordType == "NS" && recordName != zone && candidateType != "NS" && candidateType != "DS" && isSubdomain(name, recordName) {
+			conflicts = append(conflicts, Conflict{Record: record, Reason: ConflictDelegation})
+			continue
+		}
+		if candidateType == "NS" && name != zone && recordType != "NS" && recordType != "DS" && isSubdomain(recordName, name) {
+			conflicts = append(conflicts, Conflict{Record: record, Reason: ConflictDelegation})
+		}
+	}
+	return conflicts
+}
+
+// CheckConflicts obtiene los registros que pueden entrar en conflicto con record y devuelve los
+// que lo hacen. Solo consulta el nombre del registro y, por las delegaciones, los NS de sus
+// dominios padre por debajo del ápice. Una delegación NS puede ocultar cualquier nombre por
+// debajo del suyo, así que para un registro NS se obtiene la zona completa.
+func CheckConflicts(provider DNSProvider, record *DNSRecord, zone string) ([]Conflict, error) {
+	if strings.EqualFold(record.Type, "NS") {
+		existing, err := provider.ListDNSRecords()
+		if err != nil {
+			return nil, err
+		}
+		return FindConflicts(existing, record, zone), nil
+	}
+
+	name := normalizeHostname(record.Name)
+	zone = normalizeHostname(zone)
+	existing, err := provider.FindDNSRecords(name, "")
+	if err != nil {
+		return nil, err
+	}
+	for parent := parentDomain(name); parent != zone && isSubdomain(parent, zone); parent = parentDomain(parent) {
+		delegations, err := provider.FindDNSRecords(parent, "NS")
+		if err != nil {
+			return nil, err
+		}
+		existing = append(existing, delegations...)
+	}
+	return FindConflicts(existing, record, zone), nil
+}
+
+// ReplaceConflicts elimina los registros en conflicto y crea o actualiza record en una única
+// operación batch. Si record tiene ID se actualiza; si no, se crea. En ambos casos record
+// recibe el registro guardado por Cloudflare.
+func ReplaceConflicts(provider DNSProvider, record *DNSRecord, conflicts []Conflict) (*BatchResult, error) {
+	ops := &BatchOperations{}
+	for _, conflict := range conflicts {
+		ops.Deletes = append(ops.Deletes, conflict.Record)
+	}
+	if record.ID != "" {
+		ops.Patches = []*DNSRecord{record}
+	} else {
+		ops.Posts = []*DNSRecord{record}
+	}
+
+	result, err := provider.BatchDNSRecords(ops)
+	if err != nil {
+		return result, err
+	}
+	fillBatchRecords(ops.Patches, result.Patches)
+	fillBatchRecords(ops.Posts, result.Posts)
+	return result, nil
+}
+
+// isSubdomain indica si name es parent o está por debajo de él
+func isSubdomain(name, parent string) bool {
+	return name == parent || strings.HasSuffix(name, "."+parent)
+}
+
+// parentDomain devuelve el dominio padre de name, o una cadena vacía si no tiene
+func parentDomain(name string) string {
+	_, parent, _ := strings.Cut(name, ".")
+	return parent
+}
+
+// sameContent compara el contenido de dos registros del tipo indicado. Las direcciones se
+// comparan por su valor y los nombres de host (CNAME, NS, MX, PTR y el destino de SRV) sin
+// distinguir mayúsculas ni el punto final; el resto, como TXT o CAA, debe coincidir exactamente.
+func sameContent(recordType, a, b string) bool {
+	switch strings.ToUpper(recordType) {
+	case "A", "AAAA":
+		return a == b || sameAddress(a, b)
+	case "CNAME", "NS", "MX", "PTR", "SRV":
+		return sameHostname(a, b)
+	}
+	return a == b
+}
+
+// sameHostname compara dos nombres de host sin distinguir mayúsculas ni el punto final
+func sameHostname(a, b string) bool {
+	return strings.EqualFold(strings.TrimSuffix(a, "."), strings.TrimSuffix(b, "."))
+}
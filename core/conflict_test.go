@@ -0,0 +1,115 @@
+package core_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"cloudflare-domain-controller/core"
+)
+
+func TestFindConflicts(t *testing.T) {
+	existing := []*core.DNSRecord{
+		{ID: "1", Name: "web.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1},
+		{ID: "2", Name: "web.test-domain.com", Type: "TXT", Content: "hola", TTL: 1},
+		{ID: "3", Name: "blog.test-domain.com", Type: "CNAME", Content: "ghs.example.net", TTL: 1},
+		{ID: "4", Name: "lab.test-domain.com", Type: "NS", Content: "ns1.example.net", TTL: 1},
+		{ID: "5", Name: "test-domain.com", Type: "NS", Content: "ns1.cloudflare.com", TTL: 1},
+		{ID: "6", Name: "app.dev.test-domain.com", Type: "A", Content: "192.0.2.9", TTL: 1},
+		{ID: "7", Name: "test-domain.com", Type: "MX", Content: "mail.example.net", TTL: 1},
+	}
+
+	tests := []struct {
+		name      string
+		candidate core.DNSRecord
+		ids       []string
+		reason    string
+	}{
+		{"CNAME junto a A y TXT", core.DNSRecord{Name: "web.test-domain.com", Type: "CNAME", Content: "x.example.net"}, []string{"1", "2"}, core.ConflictCNAME},
+		{"A junto a CNAME", core.DNSRecord{Name: "blog.test-domain.com", Type: "A", Content: "192.0.2.5"}, []string{"3"}, core.ConflictCNAME},
+		{"duplicado idéntico", core.DNSRecord{Name: "WEB.test-domain.com.", Type: "A", Content: "192.0.2.1"}, []string{"1"}, core.ConflictDuplicate},
+		{"MX con otras mayúsculas", core.DNSRecord{Name: "test-domain.com", Type: "MX", Content: "Mail.Example.net."}, []string{"7"}, core.ConflictDuplicate},
+		{"TXT con otras mayúsculas", core.DNSRecord{Name: "web.test-domain.com", Type: "TXT", Content: "Hola"}, nil, ""},
+		{"A con otro contenido", core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "192.0.2.2"}, nil, ""},
+		{"bajo una delegación", core.DNSRecord{Name: "www.lab.test-domain.com", Type: "A", Content: "192.0.2.5"}, []string{"4"}, core.ConflictDelegation},
+		{"DS en la delegación", core.DNSRecord{Name: "lab.test-domain.com", Type: "DS", Content: "2371 13 2 abcd"}, nil, ""},
+		{"NS del ápice", core.DNSRecord{Name: "nuevo.test-domain.com", Type: "A", Content: "192.0.2.5"}, nil, ""},
+		{"delegación que oculta registros", core.DNSRecord{Name: "dev.test-domain.com", Type: "NS", Content: "ns1.example.net"}, []string{"6"}, core.ConflictDelegation},
+		{"actualización del propio registro", core.DNSRecord{ID: "3", Name: "blog.test-domain.com", Type: "CNAME", Content: "otro.example.net"}, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conflicts := core.FindConflicts(existing, &tt.candidate, "test-domain.com")
+			if len(conflicts) != len(tt.ids) {
+				t.Fatalf("Número de conflictos incorrecto: esperado %v, obtenido %+v", tt.ids, conflicts)
+			}
+			for i, conflict := range conflicts {
+				if conflict.Record.ID != tt.ids[i] || conflict.Reason != tt.reason {
+					t.Errorf("Conflicto incorrecto: esperado %s (%s), obtenido %s (%s)", tt.ids[i], tt.reason, conflict.Record.ID, conflict.Reason)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckConflicts(t *testing.T) {
+	server, client := newTestServer(t)
+	labID := server.Add(core.DNSRecord{Name: "lab.test-domain.com", Type: "NS", Content: "ns1.example.net", TTL: 1})
+	appID := server.Add(core.DNSRecord{Name: "app.dev.test-domain.com", Type: "A", Content: "192.0.2.9", TTL: 1})
+	server.Add(core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+
+	// Un registro que no es NS solo consulta su nombre y los NS de sus dominios padre
+	conflicts, err := core.CheckConflicts(client, &core.DNSRecord{Name: "www.lab.test-domain.com", Type: "A", Content: "192.0.2.5"}, "test-domain.com")
+	if err != nil {
+		t.Fatalf("Error al comprobar los conflictos: %v", err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Record.ID != labID || conflicts[0].Reason != core.ConflictDelegation {
+		t.Errorf("Conflictos incorrectos: %+v", conflicts)
+	}
+	for _, request := range server.Requests() {
+		if request.Method == http.MethodGet && !strings.Contains(request.Query, "name=") {
+			t.Errorf("No se debe listar la zona completa: %s?%s", request.Path, request.Query)
+		}
+	}
+
+	// Una delegación puede ocultar cualquier nombre por debajo, así que se revisa toda la zona
+	conflicts, err = core.CheckConflicts(client, &core.DNSRecord{Name: "dev.test-domain.com", Type: "NS", Content: "ns1.example.net"}, "test-domain.com")
+	if err != nil {
+		t.Fatalf("Error al comprobar los conflictos: %v", err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Record.ID != appID || conflicts[0].Reason != core.ConflictDelegation {
+		t.Errorf("Conflictos incorrectos: %+v", conflicts)
+	}
+}
+
+func TestReplaceConflicts(t *testing.T) {
+	server, client := newTestServer(t)
+	aID := server.Add(core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+	txtID := server.Add(core.DNSRecord{Name: "web.test-domain.com", Type: "TXT", Content: "hola", TTL: 1})
+
+	record := &core.DNSRecord{Name: "web.test-domain.com", Type: "CNAME", Content: "ghs.example.net", TTL: 1}
+	conflicts, err := core.CheckConflicts(client, record, "test-domain.com")
+	if err != nil {
+		t.Fatalf("Error al comprobar los conflictos: %v", err)
+	}
+	if len(conflicts) != 2 {
+		t.Fatalf("Se esperaban 2 conflictos, obtenidos %d", len(conflicts))
+	}
+
+	if _, err := core.ReplaceConflicts(client, record, conflicts); err != nil {
+		t.Fatalf("Error al reemplazar los conflictos: %v", err)
+	}
+	if record.ID == "" {
+		t.Error("El registro creado debe tener ID")
+	}
+	if server.Record(aID) != nil {
+		t.Error("El registro A en conflicto no se eliminó")
+	}
+	if server.Record(txtID) != nil {
+		t.Error("El registro TXT en conflicto no se eliminó")
+	}
+	if calls := batchRequests(server); calls != 1 {
+		t.Errorf("Se esperaba una única operación batch, obtenidas %d", calls)
+	}
+}
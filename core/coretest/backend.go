@@ -0,0 +1,600 @@
+// Package coretest proporciona un backend DNS en memoria que se comporta como la API de
+// Cloudflare. Sirve para probar el cliente, el código que usa core.DNSProvider y los comandos
+// sin acceso a la red: asigna identificadores, rechaza registros duplicados, en conflicto o
+// inválidos, pagina los listados y aplica las operaciones batch de forma atómica.
+package coretest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloudflare-domain-controller/core"
+)
+
+// Credenciales de la zona falsa
+const (
+	Token  = "coretest-token"
+	ZoneID = "coretest-zone-id"
+)
+
+// Tamaños de página de los listados, como en la API de Cloudflare
+const (
+	DefaultPageSize = 100
+	minPageSize     = 5
+	maxPageSize     = 5000
+)
+
+// Códigos de error de la API de Cloudflare que reproduce el backend
+const (
+	CodeBadRoute     = 7003
+	CodeInvalidBody  = 9207
+	CodeAuth         = 10000
+	CodeValidation   = 1004
+	CodeNotFound     = 81044
+	CodeHostConflict = 81053
+	CodeDuplicate    = 81058
+)
+
+// apiError es un error con el formato de la API de Cloudflare
+type apiError struct {
+	status  int
+	code    int
+	message string
+}
+
+func newAPIError(status, code int, format string, args ...interface{}) *apiError {
+	return &apiError{status: status, code: code, message: fmt.Sprintf(format, args...)}
+}
+
+// body devuelve la respuesta JSON del error
+func (e *apiError) body() []byte {
+	body, _ := json.Marshal(map[string]interface{}{
+		"success":  false,
+		"errors":   []map[string]interface{}{{"code": e.code, "message": e.message}},
+		"messages": []string{},
+		"result":   nil,
+	})
+	return body
+}
+
+// err convierte el error en el mismo *core.APIError que devuelve el cliente real
+func (e *apiError) err() error {
+	return &core.APIError{StatusCode: e.status, Status: fmt.Sprintf("%d %s", e.status, http.StatusText(e.status)), Body: string(e.body())}
+}
+
+// zone es el estado de los registros. Las operaciones batch trabajan sobre una copia y solo
+// la guardan si todas las operaciones son correctas.
+type zone struct {
+	name    string
+	records map[string]*core.DNSRecord
+	order   []string
+	nextID  int
+}
+
+func (z *zone) clone() *zone {
+	copied := &zone{name: z.name, records: make(map[string]*core.DNSRecord, len(z.records)), order: append([]string(nil), z.order...), nextID: z.nextID}
+	for id, record := range z.records {
+		copied.records[id] = copyRecord(record)
+	}
+	return copied
+}
+
+// newID genera un identificador con el formato de Cloudflare (32 caracteres hexadecimales)
+func (z *zone) newID() string {
+	z.nextID++
+	return fmt.Sprintf("%032x", z.nextID)
+}
+
+// qualify completa los nombres relativos con el dominio de la zona, como hace Cloudflare
+func (z *zone) qualify(name string) string {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	if name == "@" || name == "" {
+		return z.name
+	}
+	if name != z.name && !strings.HasSuffix(name, "."+z.name) {
+		return name + "." + z.name
+	}
+	return name
+}
+
+// normalize aplica los valores por defecto de Cloudflare
+func (z *zone) normalize(record *core.DNSRecord) {
+	record.Name = z.qualify(record.Name)
+	record.Type = strings.ToUpper(record.Type)
+	if record.TTL == 0 {
+		record.TTL = 1
+	}
+}
+
+// check valida un registro y comprueba que no duplica ni entra en conflicto con otro
+// registro de la zona distinto de exclude
+func (z *zone) check(record *core.DNSRecord, exclude string) *apiError {
+	if err := core.ValidateRecord(record); err != nil {
+		return newAPIError(http.StatusBadRequest, CodeValidation, "DNS Validation Error: %v", err)
+	}
+	for _, id := range z.order {
+		other := z.records[id]
+		if id == exclude || !strings.EqualFold(other.Name, record.Name) {
+			continue
+		}
+		if other.Type == record.Type && other.Content == record.Content {
+			return newAPIError(http.StatusBadRequest, CodeDuplicate, "An identical record already exists.")
+		}
+		if other.Type == "CNAME" || record.Type == "CNAME" {
+			return newAPIError(http.StatusBadRequest, CodeHostConflict, "An A, AAAA, or CNAME record with that host already exists.")
+		}
+	}
+	return nil
+}
+
+func (z *zone) get(id string) (*core.DNSRecord, *apiError) {
+	record, ok := z.records[id]
+	if !ok {
+		return nil, newAPIError(http.StatusNotFound, CodeNotFound, "Record does not exist.")
+	}
+	return record, nil
+}
+
+// create crea un registro a partir del cuerpo JSON de la solicitud
+func (z *zone) create(body []byte) (*core.DNSRecord, *apiError) {
+	record := &core.DNSRecord{}
+	if err := json.Unmarshal(body, record); err != nil {
+		return nil, newAPIError(http.StatusBadRequest, CodeInvalidBody, "Request body is invalid.")
+	}
+	z.normalize(record)
+	if apiErr := z.check(record, ""); apiErr != nil {
+		return nil, apiErr
+	}
+	record.ID = z.newID()
+	z.records[record.ID] = record
+	z.order = append(z.order, record.ID)
+	return record, nil
+}
+
+// write modifica un registro existente. Con replace (PUT) el cuerpo sustituye al registro
+// completo; sin él (PATCH) solo cambian los campos presentes en el cuerpo.
+func (z *zone) write(id string, body []byte, replace bool) (*core.DNSRecord, *apiError) {
+	current, apiErr := z.get(id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	record := &core.DNSRecord{}
+	if !replace {
+		record = copyRecord(current)
+	}
+	if err := json.Unmarshal(body, record); err != nil {
+		return nil, newAPIError(http.StatusBadRequest, CodeInvalidBody, "Request body is invalid.")
+	}
+	record.ID = id
+	z.normalize(record)
+	if apiErr := z.check(record, id); apiErr != nil {
+		return nil, apiErr
+	}
+	z.records[id] = record
+	return record, nil
+}
+
+func (z *zone) remove(id string) (*core.DNSRecord, *apiError) {
+	record, apiErr := z.get(id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	delete(z.records, id)
+	for i, other := range z.order {
+		if other == id {
+			z.order = append(z.order[:i], z.order[i+1:]...)
+			break
+		}
+	}
+	return record, nil
+}
+
+// list devuelve los registros en orden de creación que cumplen los filtros no vacíos
+func (z *zone) list(name, recordType, content string) []*core.DNSRecord {
+	records := []*core.DNSRecord{}
+	for _, id := range z.order {
+		record := z.records[id]
+		if name != "" && !strings.EqualFold(record.Name, z.qualify(name)) {
+			continue
+		}
+		if recordType != "" && !strings.EqualFold(record.Type, recordType) {
+			continue
+		}
+		if content != "" && record.Content != content {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// batchBody es el cuerpo de una solicitud al endpoint /dns_records/batch
+type batchBody struct {
+	Deletes []struct {
+		ID string `json:"id"`
+	} `json:"deletes"`
+	Patches []json.RawMessage `json:"patches"`
+	Puts    []json.RawMessage `json:"puts"`
+	Posts   []json.RawMessage `json:"posts"`
+}
+
+// batch aplica las operaciones en el orden de Cloudflare: eliminaciones, patches, puts y posts
+func (z *zone) batch(body []byte) (*core.BatchResult, *apiError) {
+	var request batchBody
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, newAPIError(http.StatusBadRequest, CodeInvalidBody, "Request body is invalid.")
+	}
+
+	result := &core.BatchResult{Deletes: []*core.DNSRecord{}, Patches: []*core.DNSRecord{}, Puts: []*core.DNSRecord{}, Posts: []*core.DNSRecord{}}
+	for _, op := range request.Deletes {
+		record, apiErr := z.remove(op.ID)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		result.Deletes = append(result.Deletes, record)
+	}
+	for _, group := range []struct {
+		ops     []json.RawMessage
+		replace bool
+		results *[]*core.DNSRecord
+	}{{request.Patches, false, &result.Patches}, {request.Puts, true, &result.Puts}} {
+		for _, op := range group.ops {
+			var target struct {
+				ID string `json:"id"`
+			}
+			json.Unmarshal(op, &target)
+			record, apiErr := z.write(target.ID, op, group.replace)
+			if apiErr != nil {
+				return nil, apiErr
+			}
+			*group.results = append(*group.results, record)
+		}
+	}
+	for _, op := range request.Posts {
+		record, apiErr := z.create(op)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		result.Posts = append(result.Posts, record)
+	}
+	return result, nil
+}
+
+// Request es una solicitud HTTP recibida por el backend
+type Request struct {
+	Method string
+	Path   string
+	Query  string
+}
+
+// Backend es una zona DNS en memoria. Implementa core.DNSProvider directamente y, como
+// http.Handler, la API v4 de Cloudflare para usarlo con el cliente real (ver NewServer).
+type Backend struct {
+	mu          sync.Mutex
+	zone        *zone
+	nameservers []string
+	requests    []Request
+	failures    int
+	failStatus  int
+	noBatch     bool
+
+	// latency retrasa cada solicitud HTTP; inFlight y maxInFlight miden las simultáneas
+	latency               time.Duration
+	inFlight, maxInFlight int
+}
+
+var _ core.DNSProvider = (*Backend)(nil)
+
+// NewBackend crea una zona vacía con el dominio indicado
+func NewBackend(zoneName string) *Backend {
+	zoneName = strings.ToLower(strings.TrimSuffix(zoneName, "."))
+	return &Backend{
+		zone:        &zone{name: zoneName, records: make(map[string]*core.DNSRecord)},
+		nameservers: []string{"ns1.coretest.invalid", "ns2.coretest.invalid"},
+	}
+}
+
+// ZoneName devuelve el dominio de la zona
+func (b *Backend) ZoneName() string {
+	return b.zone.name
+}
+
+// Add guarda un registro sin validarlo, para preparar el estado de una prueba, y devuelve su ID
+func (b *Backend) Add(record core.DNSRecord) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stored := copyRecord(&record)
+	b.zone.normalize(stored)
+	stored.ID = b.zone.newID()
+	b.zone.records[stored.ID] = stored
+	b.zone.order = append(b.zone.order, stored.ID)
+	return stored.ID
+}
+
+// Records devuelve una copia de todos los registros en orden de creación
+func (b *Backend) Records() []*core.DNSRecord {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return copyRecords(b.zone.list("", "", ""))
+}
+
+// Record devuelve una copia del registro con el ID indicado o nil si no existe
+func (b *Backend) Record(id string) *core.DNSRecord {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if record, ok := b.zone.records[id]; ok {
+		return copyRecord(record)
+	}
+	return nil
+}
+
+// Requests devuelve las solicitudes HTTP recibidas, en orden
+func (b *Backend) Requests() []Request {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Request(nil), b.requests...)
+}
+
+// FailRequests hace que las próximas n solicitudes fallen con el código indicado, tanto las
+// HTTP como las llamadas directas a los métodos de core.DNSProvider. Con 429 la respuesta
+// HTTP incluye Retry-After: 0 para que el cliente reintente sin esperar.
+func (b *Backend) FailRequests(n, status int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = n
+	b.failStatus = status
+}
+
+// SetNameservers cambia los servidores de nombres que devuelve la zona
+func (b *Backend) SetNameservers(nameservers ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nameservers = append([]string(nil), nameservers...)
+}
+
+// DisableBatch simula una cuenta sin acceso al endpoint batch: responde 404 como una ruta
+// inexistente, tanto por HTTP como en BatchDNSRecords
+func (b *Backend) DisableBatch() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.noBatch = true
+}
+
+// SetLatency retrasa cada solicitud HTTP la duración indicada, para poder medir cuántas
+// se atienden a la vez con MaxConcurrentRequests
+func (b *Backend) SetLatency(latency time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.latency = latency
+}
+
+// MaxConcurrentRequests devuelve el máximo de solicitudes HTTP atendidas a la vez mientras
+// había latencia configurada
+func (b *Backend) MaxConcurrentRequests() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.maxInFlight
+}
+
+// injectedFailure consume uno de los fallos de FailRequests. Se llama con mu bloqueado.
+func (b *Backend) injectedFailure() *apiError {
+	if b.failures == 0 {
+		return nil
+	}
+	b.failures--
+	return newAPIError(b.failStatus, 0, "%s", http.StatusText(b.failStatus))
+}
+
+// Provider devuelve el propio backend para cualquier configuración. Tiene la firma de
+// cmd.ClientFactory para ejecutar los comandos directamente contra la zona en memoria.
+func (b *Backend) Provider(config *core.Config) core.DNSProvider {
+	return b
+}
+
+// SetEnv configura las variables de entorno de la herramienta para usar la zona, con un
+// directorio de estado temporal, hasta que termine la prueba
+func (b *Backend) SetEnv(t testing.TB) {
+	t.Helper()
+	t.Setenv("CLOUDFLARE_API_TOKEN", Token)
+	t.Setenv("CLOUDFLARE_ZONE_ID", ZoneID)
+	t.Setenv("CLOUDFLARE_DOMAIN_NAME", b.ZoneName())
+	t.Setenv("CLOUDFLARE_STATE_DIR", t.TempDir())
+	t.Setenv("CLOUDFLARE_PROFILE", "")
+	t.Setenv("CLOUDFLARE_AUDIT_LOG", "")
+	t.Setenv("CLOUDFLARE_NAMESERVERS", "")
+}
+
+// CreateDNSRecord crea el registro y lo actualiza con el estado guardado, incluido el ID generado
+func (b *Backend) CreateDNSRecord(record *core.DNSRecord) error {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if apiErr := b.injectedFailure(); apiErr != nil {
+		return apiErr.err()
+	}
+	created, apiErr := b.zone.create(body)
+	if apiErr != nil {
+		return apiErr.err()
+	}
+	*record = *copyRecord(created)
+	return nil
+}
+
+// UpdateDNSRecord modifica los campos del registro presentes en su JSON (PATCH)
+func (b *Backend) UpdateDNSRecord(recordID string, record *core.DNSRecord) error {
+	return b.writeDNSRecord(recordID, record, false)
+}
+
+// ReplaceDNSRecord sobrescribe por completo el registro (PUT)
+func (b *Backend) ReplaceDNSRecord(recordID string, record *core.DNSRecord) error {
+	return b.writeDNSRecord(recordID, record, true)
+}
+
+func (b *Backend) writeDNSRecord(recordID string, record *core.DNSRecord, replace bool) error {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if apiErr := b.injectedFailure(); apiErr != nil {
+		return apiErr.err()
+	}
+	stored, apiErr := b.zone.write(recordID, body, replace)
+	if apiErr != nil {
+		return apiErr.err()
+	}
+	*record = *copyRecord(stored)
+	return nil
+}
+
+// DeleteDNSRecord elimina el registro
+func (b *Backend) DeleteDNSRecord(recordID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if apiErr := b.injectedFailure(); apiErr != nil {
+		return apiErr.err()
+	}
+	if _, apiErr := b.zone.remove(recordID); apiErr != nil {
+		return apiErr.err()
+	}
+	return nil
+}
+
+// GetDNSRecord devuelve una copia del registro
+func (b *Backend) GetDNSRecord(recordID string) (*core.DNSRecord, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if apiErr := b.injectedFailure(); apiErr != nil {
+		return nil, apiErr.err()
+	}
+	record, apiErr := b.zone.get(recordID)
+	if apiErr != nil {
+		return nil, apiErr.err()
+	}
+	return copyRecord(record), nil
+}
+
+// GetDNSRecordByName devuelve el primer registro con el nombre indicado
+func (b *Backend) GetDNSRecordByName(name string) (*core.DNSRecord, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if apiErr := b.injectedFailure(); apiErr != nil {
+		return nil, apiErr.err()
+	}
+	records := b.zone.list(name, "", "")
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no se encontró el registro DNS para %s", name)
+	}
+	return copyRecord(records[0]), nil
+}
+
+// FindDNSRecords devuelve los registros del nombre y, si no está vacío, del tipo indicados
+func (b *Backend) FindDNSRecords(name, recordType string) ([]*core.DNSRecord, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if apiErr := b.injectedFailure(); apiErr != nil {
+		return nil, apiErr.err()
+	}
+	return copyRecords(b.zone.list(name, recordType, "")), nil
+}
+
+// ListDNSRecords devuelve todos los registros de la zona
+func (b *Backend) ListDNSRecords() ([]*core.DNSRecord, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if apiErr := b.injectedFailure(); apiErr != nil {
+		return nil, apiErr.err()
+	}
+	return copyRecords(b.zone.list("", "", "")), nil
+}
+
+// GetZoneNameservers devuelve los servidores de nombres de la zona
+func (b *Backend) GetZoneNameservers() ([]string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if apiErr := b.injectedFailure(); apiErr != nil {
+		return nil, apiErr.err()
+	}
+	return append([]string(nil), b.nameservers...), nil
+}
+
+// BatchDNSRecords aplica las operaciones de forma atómica: si una falla no se aplica ninguna
+func (b *Backend) BatchDNSRecords(ops *core.BatchOperations) (*core.BatchResult, error) {
+	var request struct {
+		Deletes []map[string]string `json:"deletes"`
+		Patches []*core.DNSRecord   `json:"patches"`
+		Puts    []*core.DNSRecord   `json:"puts"`
+		Posts   []*core.DNSRecord   `json:"posts"`
+	}
+	for _, record := range ops.Deletes {
+		request.Deletes = append(request.Deletes, map[string]string{"id": record.ID})
+	}
+	request.Patches, request.Puts, request.Posts = ops.Patches, ops.Puts, ops.Posts
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if apiErr := b.injectedFailure(); apiErr != nil {
+		return nil, apiErr.err()
+	}
+	result, apiErr := b.applyBatch(body)
+	if apiErr != nil {
+		if b.noBatch {
+			return nil, fmt.Errorf("%w: %w", core.ErrBatchUnsupported, apiErr.err())
+		}
+		return nil, apiErr.err()
+	}
+	return result, nil
+}
+
+// applyBatch aplica una solicitud batch sobre una copia de la zona y la guarda si no hay errores.
+// Como en Cloudflare, el fallo de una operación responde 400 con el código de error de esa
+// operación, aunque por separado hubiera sido otro estado (por ejemplo 404).
+func (b *Backend) applyBatch(body []byte) (*core.BatchResult, *apiError) {
+	if b.noBatch {
+		return nil, newAPIError(http.StatusNotFound, CodeBadRoute, "Could not route to /dns_records/batch, perhaps your object identifier is invalid?")
+	}
+	working := b.zone.clone()
+	result, apiErr := working.batch(body)
+	if apiErr != nil {
+		return nil, newAPIError(http.StatusBadRequest, apiErr.code, "%s", apiErr.message)
+	}
+	b.zone = working
+	for _, group := range [][]*core.DNSRecord{result.Deletes, result.Patches, result.Puts, result.Posts} {
+		for i, record := range group {
+			group[i] = copyRecord(record)
+		}
+	}
+	return result, nil
+}
+
+func copyRecord(record *core.DNSRecord) *core.DNSRecord {
+	copied := *record
+	if record.Priority != nil {
+		priority := *record.Priority
+		copied.Priority = &priority
+	}
+	copied.Tags = append([]string(nil), record.Tags...)
+	return &copied
+}
+
+func copyRecords(records []*core.DNSRecord) []*core.DNSRecord {
+	copied := make([]*core.DNSRecord, len(records))
+	for i, record := range records {
+		copied[i] = copyRecord(record)
+	}
+	return copied
+}
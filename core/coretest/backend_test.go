@@ -0,0 +1,287 @@
+package coretest_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"cloudflare-domain-controller/core"
+	"cloudflare-domain-controller/core/coretest"
+)
+
+const zoneName = "example.com"
+
+// forEachProvider ejecuta la prueba con el backend en memoria y con el cliente real contra
+// el servidor, para comprobar que las dos formas de usar el fake se comportan igual
+func forEachProvider(t *testing.T, test func(t *testing.T, provider core.DNSProvider, backend *coretest.Backend)) {
+	t.Run("backend", func(t *testing.T) {
+		backend := coretest.NewBackend(zoneName)
+		test(t, backend, backend)
+	})
+	t.Run("client", func(t *testing.T) {
+		server := coretest.NewServer(t, zoneName)
+		test(t, server.NewClient(server.Config()), server.Backend)
+	})
+}
+
+// apiErrorCode devuelve el código de error de Cloudflare de una respuesta de error
+func apiErrorCode(t *testing.T, err error) (int, int) {
+	t.Helper()
+	var apiErr *core.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Se esperaba un *core.APIError y se obtuvo %v", err)
+	}
+	var body struct {
+		Errors []struct {
+			Code int `json:"code"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal([]byte(apiErr.Body), &body); err != nil || len(body.Errors) == 0 {
+		t.Fatalf("Cuerpo de error inválido: %s", apiErr.Body)
+	}
+	return apiErr.StatusCode, body.Errors[0].Code
+}
+
+func TestCRUD(t *testing.T) {
+	forEachProvider(t, func(t *testing.T, provider core.DNSProvider, backend *coretest.Backend) {
+		web := &core.DNSRecord{Name: "web.example.com", Type: "A", Content: "192.0.2.1", TTL: 1}
+		api := &core.DNSRecord{Name: "api.example.com", Type: "A", Content: "192.0.2.2", TTL: 300}
+		for _, record := range []*core.DNSRecord{web, api} {
+			if err := provider.CreateDNSRecord(record); err != nil {
+				t.Fatalf("Error al crear %s: %v", record.Name, err)
+			}
+		}
+		if web.ID == "" || web.ID == api.ID || len(web.ID) != 32 {
+			t.Fatalf("IDs incorrectos: %q, %q", web.ID, api.ID)
+		}
+
+		got, err := provider.GetDNSRecordByName("web")
+		if err != nil || got.ID != web.ID {
+			t.Fatalf("GetDNSRecordByName con nombre relativo: %v, %v", got, err)
+		}
+
+		update := *web
+		update.Content = "192.0.2.10"
+		if err := provider.UpdateDNSRecord(web.ID, &update); err != nil {
+			t.Fatalf("Error al actualizar: %v", err)
+		}
+		if got := backend.Record(web.ID); got.Content != "192.0.2.10" || got.TTL != 1 {
+			t.Errorf("Registro actualizado incorrecto: %+v", got)
+		}
+
+		replacement := &core.DNSRecord{Name: "api.example.com", Type: "A", Content: "192.0.2.20", TTL: 600}
+		if err := provider.ReplaceDNSRecord(api.ID, replacement); err != nil {
+			t.Fatalf("Error al reemplazar: %v", err)
+		}
+		if got, _ := provider.GetDNSRecord(api.ID); got.Content != "192.0.2.20" || got.TTL != 600 {
+			t.Errorf("Registro reemplazado incorrecto: %+v", got)
+		}
+
+		found, err := provider.FindDNSRecords("api.example.com", "A")
+		if err != nil || len(found) != 1 {
+			t.Fatalf("FindDNSRecords: %v, %v", found, err)
+		}
+		if found, _ := provider.FindDNSRecords("api.example.com", "AAAA"); len(found) != 0 {
+			t.Errorf("El filtro por tipo no se aplicó: %v", found)
+		}
+
+		if err := provider.DeleteDNSRecord(web.ID); err != nil {
+			t.Fatalf("Error al eliminar: %v", err)
+		}
+		_, err = provider.GetDNSRecord(web.ID)
+		if status, code := apiErrorCode(t, err); status != http.StatusNotFound || code != coretest.CodeNotFound {
+			t.Errorf("Registro eliminado: %d, %d", status, code)
+		}
+		if records, _ := provider.ListDNSRecords(); len(records) != 1 {
+			t.Errorf("Quedan %d registros", len(records))
+		}
+	})
+}
+
+func TestConflicts(t *testing.T) {
+	forEachProvider(t, func(t *testing.T, provider core.DNSProvider, backend *coretest.Backend) {
+		backend.Add(core.DNSRecord{Name: "web.example.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+		backend.Add(core.DNSRecord{Name: "www.example.com", Type: "CNAME", Content: "web.example.com", TTL: 1})
+
+		tests := []struct {
+			record *core.DNSRecord
+			code   int
+		}{
+			{&core.DNSRecord{Name: "web.example.com", Type: "A", Content: "192.0.2.1", TTL: 1}, coretest.CodeDuplicate},
+			{&core.DNSRecord{Name: "web.example.com", Type: "CNAME", Content: "other.example.com", TTL: 1}, coretest.CodeHostConflict},
+			{&core.DNSRecord{Name: "www.example.com", Type: "A", Content: "192.0.2.2", TTL: 1}, coretest.CodeHostConflict},
+		}
+		for _, test := range tests {
+			err := provider.CreateDNSRecord(test.record)
+			if status, code := apiErrorCode(t, err); status != http.StatusBadRequest || code != test.code {
+				t.Errorf("%s %s: %d, %d", test.record.Name, test.record.Type, status, code)
+			}
+		}
+
+		// Un segundo registro A con otro contenido forma un conjunto round-robin válido
+		if err := provider.CreateDNSRecord(&core.DNSRecord{Name: "web.example.com", Type: "A", Content: "192.0.2.2", TTL: 1}); err != nil {
+			t.Errorf("Error inesperado: %v", err)
+		}
+		if len(backend.Records()) != 3 {
+			t.Errorf("Se esperaban 3 registros, hay %d", len(backend.Records()))
+		}
+	})
+}
+
+func TestValidation(t *testing.T) {
+	backend := coretest.NewBackend(zoneName)
+	err := backend.CreateDNSRecord(&core.DNSRecord{Name: "web.example.com", Type: "A", Content: "not-an-ip", TTL: 1})
+	if status, code := apiErrorCode(t, err); status != http.StatusBadRequest || code != coretest.CodeValidation {
+		t.Errorf("Registro inválido: %d, %d", status, code)
+	}
+
+	// El cliente valida antes de enviar, así que se comprueba la respuesta del servidor directamente
+	server := coretest.NewServer(t, zoneName)
+	resp := request(t, server, http.MethodPost, "/zones/"+coretest.ZoneID+"/dns_records", `{"name":"web","type":"A","content":"not-an-ip"}`)
+	if resp.StatusCode != http.StatusBadRequest || !strings.Contains(readBody(t, resp), fmt.Sprint(coretest.CodeValidation)) {
+		t.Errorf("Respuesta a un registro inválido: %s", resp.Status)
+	}
+	if len(server.Records()) != 0 {
+		t.Error("No se debe guardar un registro inválido")
+	}
+}
+
+func TestBatchAtomic(t *testing.T) {
+	forEachProvider(t, func(t *testing.T, provider core.DNSProvider, backend *coretest.Backend) {
+		id := backend.Add(core.DNSRecord{Name: "old.example.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+
+		// El segundo post duplica el primero: no se aplica ninguna operación
+		ops := &core.BatchOperations{
+			Deletes: []*core.DNSRecord{{ID: id}},
+			Posts: []*core.DNSRecord{
+				{Name: "new.example.com", Type: "A", Content: "192.0.2.2", TTL: 1},
+				{Name: "new.example.com", Type: "A", Content: "192.0.2.2", TTL: 1},
+			},
+		}
+		if _, err := provider.BatchDNSRecords(ops); err == nil {
+			t.Fatal("Se esperaba un error")
+		}
+		if records := backend.Records(); len(records) != 1 || records[0].ID != id {
+			t.Fatalf("El batch fallido modificó la zona: %v", records)
+		}
+
+		ops.Posts = ops.Posts[:1]
+		result, err := provider.BatchDNSRecords(ops)
+		if err != nil {
+			t.Fatalf("Error inesperado: %v", err)
+		}
+		if len(result.Deletes) != 1 || len(result.Posts) != 1 || result.Posts[0].ID == "" {
+			t.Errorf("Resultado del batch incorrecto: %+v", result)
+		}
+		if records := backend.Records(); len(records) != 1 || records[0].Name != "new.example.com" {
+			t.Errorf("Zona tras el batch: %v", records)
+		}
+	})
+}
+
+func TestPagination(t *testing.T) {
+	server := coretest.NewServer(t, zoneName)
+	for i := 0; i < 250; i++ {
+		server.Add(core.DNSRecord{Name: fmt.Sprintf("host%d", i), Type: "A", Content: "192.0.2.1", TTL: 1})
+	}
+
+	resp := request(t, server, http.MethodGet, "/zones/"+coretest.ZoneID+"/dns_records?page=3&per_page=100", "")
+	var page struct {
+		Result     []*core.DNSRecord `json:"result"`
+		ResultInfo struct {
+			Page       int `json:"page"`
+			Count      int `json:"count"`
+			TotalCount int `json:"total_count"`
+			TotalPages int `json:"total_pages"`
+		} `json:"result_info"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		t.Fatalf("Respuesta inválida: %v", err)
+	}
+	resp.Body.Close()
+	if len(page.Result) != 50 || page.ResultInfo.Count != 50 || page.ResultInfo.TotalCount != 250 || page.ResultInfo.TotalPages != 3 {
+		t.Errorf("Página incorrecta: %d registros, %+v", len(page.Result), page.ResultInfo)
+	}
+	if page.Result[0].Name != "host200.example.com" {
+		t.Errorf("Primer registro de la página: %s", page.Result[0].Name)
+	}
+
+	// El cliente recorre todas las páginas
+	records, err := server.NewClient(server.Config()).ListDNSRecords()
+	if err != nil || len(records) != 250 {
+		t.Fatalf("ListDNSRecords: %d registros, %v", len(records), err)
+	}
+	gets := 0
+	for _, req := range server.Requests() {
+		if req.Method == http.MethodGet && strings.HasSuffix(req.Path, "/dns_records") {
+			gets++
+		}
+	}
+	if gets != 1+3 {
+		t.Errorf("Se esperaban 3 solicitudes del cliente y se hicieron %d", gets-1)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	server := coretest.NewServer(t, zoneName)
+	client := server.NewClient(server.Config())
+
+	// Las respuestas 429 se reintentan sin esperar
+	server.FailRequests(2, http.StatusTooManyRequests)
+	if _, err := client.ListDNSRecords(); err != nil {
+		t.Errorf("El cliente debía reintentar las respuestas 429: %v", err)
+	}
+
+	server.FailRequests(1, http.StatusInternalServerError)
+	if _, err := client.ListDNSRecords(); err == nil {
+		t.Error("Se esperaba un error 500")
+	}
+
+	config := server.Config()
+	config.APIToken = "incorrecto"
+	_, err := server.NewClient(config).ListDNSRecords()
+	if status, code := apiErrorCode(t, err); status != http.StatusForbidden || code != coretest.CodeAuth {
+		t.Errorf("Token incorrecto: %d, %d", status, code)
+	}
+
+	config = server.Config()
+	config.ZoneID = "otra-zona"
+	_, err = server.NewClient(config).ListDNSRecords()
+	if status, code := apiErrorCode(t, err); status != http.StatusNotFound || code != coretest.CodeBadRoute {
+		t.Errorf("Zona desconocida: %d, %d", status, code)
+	}
+
+	nameservers, err := client.GetZoneNameservers()
+	if err != nil || len(nameservers) != 2 {
+		t.Errorf("Servidores de nombres: %v, %v", nameservers, err)
+	}
+}
+
+// request envía una solicitud autenticada directamente al servidor
+func request(t *testing.T, server *coretest.Server, method, path, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+coretest.Token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
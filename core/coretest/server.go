@@ -0,0 +1,179 @@
+package coretest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"cloudflare-domain-controller/core"
+)
+
+// apiPrefix es la ruta base de la API, como en https://api.cloudflare.com/client/v4
+const apiPrefix = "/client/v4"
+
+// ServeHTTP atiende las solicitudes de la API v4 de Cloudflare sobre la zona
+func (b *Backend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.latency > 0 {
+		b.inFlight++
+		b.maxInFlight = max(b.maxInFlight, b.inFlight)
+		latency := b.latency
+		b.mu.Unlock()
+		time.Sleep(latency)
+		b.mu.Lock()
+		b.inFlight--
+	}
+	b.requests = append(b.requests, Request{Method: r.Method, Path: r.URL.Path, Query: r.URL.RawQuery})
+
+	if apiErr := b.injectedFailure(); apiErr != nil {
+		if apiErr.status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "0")
+		}
+		writeError(w, apiErr)
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer "+Token {
+		writeError(w, newAPIError(http.StatusForbidden, CodeAuth, "Authentication error"))
+		return
+	}
+
+	// /zones/{zona}[/dns_records[/{id}|/batch]]
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, apiPrefix), "/"), "/")
+	if len(segments) < 2 || segments[0] != "zones" || segments[1] != ZoneID || len(segments) > 4 || (len(segments) > 2 && segments[2] != "dns_records") {
+		writeError(w, newAPIError(http.StatusNotFound, CodeBadRoute, "Could not route to %s, perhaps your object identifier is invalid?", r.URL.Path))
+		return
+	}
+
+	switch {
+	case len(segments) == 2 && r.Method == http.MethodGet:
+		writeResult(w, map[string]interface{}{"id": ZoneID, "name": b.zone.name, "status": "active", "name_servers": b.nameservers}, nil)
+
+	case len(segments) == 3 && r.Method == http.MethodGet:
+		b.serveList(w, r)
+
+	case len(segments) == 3 && r.Method == http.MethodPost:
+		record, apiErr := b.zone.create(body)
+		writeRecord(w, record, apiErr)
+
+	case len(segments) == 4 && segments[3] == "batch" && r.Method == http.MethodPost:
+		result, apiErr := b.applyBatch(body)
+		if apiErr != nil {
+			writeError(w, apiErr)
+			return
+		}
+		writeResult(w, result, nil)
+
+	case len(segments) == 4 && r.Method == http.MethodGet:
+		record, apiErr := b.zone.get(segments[3])
+		writeRecord(w, record, apiErr)
+
+	case len(segments) == 4 && (r.Method == http.MethodPatch || r.Method == http.MethodPut):
+		record, apiErr := b.zone.write(segments[3], body, r.Method == http.MethodPut)
+		writeRecord(w, record, apiErr)
+
+	case len(segments) == 4 && r.Method == http.MethodDelete:
+		if _, apiErr := b.zone.remove(segments[3]); apiErr != nil {
+			writeError(w, apiErr)
+			return
+		}
+		writeResult(w, map[string]string{"id": segments[3]}, nil)
+
+	default:
+		writeError(w, newAPIError(http.StatusMethodNotAllowed, CodeBadRoute, "Method %s not allowed for %s", r.Method, r.URL.Path))
+	}
+}
+
+// serveList devuelve una página de registros filtrados por name, type y content
+func (b *Backend) serveList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	records := b.zone.list(query.Get("name"), query.Get("type"), query.Get("content"))
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	perPage, err := strconv.Atoi(query.Get("per_page"))
+	if err != nil {
+		perPage = DefaultPageSize
+	}
+	perPage = min(max(perPage, minPageSize), maxPageSize)
+
+	totalPages := (len(records) + perPage - 1) / perPage
+	start := min((page-1)*perPage, len(records))
+	end := min(start+perPage, len(records))
+	writeResult(w, records[start:end], map[string]int{
+		"page":        page,
+		"per_page":    perPage,
+		"count":       end - start,
+		"total_count": len(records),
+		"total_pages": totalPages,
+	})
+}
+
+func writeRecord(w http.ResponseWriter, record *core.DNSRecord, apiErr *apiError) {
+	if apiErr != nil {
+		writeError(w, apiErr)
+		return
+	}
+	writeResult(w, record, nil)
+}
+
+func writeResult(w http.ResponseWriter, result interface{}, resultInfo interface{}) {
+	response := map[string]interface{}{"success": true, "errors": []string{}, "messages": []string{}, "result": result}
+	if resultInfo != nil {
+		response["result_info"] = resultInfo
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("CF-Ray", "coretest")
+	json.NewEncoder(w).Encode(response)
+}
+
+func writeError(w http.ResponseWriter, apiErr *apiError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(apiErr.status)
+	w.Write(apiErr.body())
+}
+
+// Server publica un Backend como la API de Cloudflare en un servidor HTTP local
+type Server struct {
+	*Backend
+	// URL es la URL base de la API, para usarla como core.Config.BaseURL
+	URL string
+}
+
+// NewServer crea una zona vacía con el dominio indicado y la publica hasta que termine la prueba
+func NewServer(t testing.TB, zoneName string) *Server {
+	t.Helper()
+	backend := NewBackend(zoneName)
+	server := httptest.NewServer(backend)
+	t.Cleanup(server.Close)
+	return &Server{Backend: backend, URL: server.URL + apiPrefix}
+}
+
+// Config devuelve una configuración para acceder a la zona del servidor
+func (s *Server) Config() *core.Config {
+	return &core.Config{APIToken: Token, ZoneID: ZoneID, DomainName: s.ZoneName(), BaseURL: s.URL}
+}
+
+// NewClient crea un cliente real que usa el servidor en lugar de la API de Cloudflare, sin
+// limitador de solicitudes
+func (s *Server) NewClient(config *core.Config) *core.CloudflareClient {
+	copied := *config
+	copied.BaseURL = s.URL
+	copied.RateLimit = 0
+	return core.NewCloudflareClient(&copied)
+}
+
+// Provider devuelve un cliente real conectado al servidor. Tiene la firma de cmd.ClientFactory
+// para ejecutar los comandos a través de HTTP, con el cliente de Cloudflare completo.
+func (s *Server) Provider(config *core.Config) core.DNSProvider {
+	return s.NewClient(config)
+}
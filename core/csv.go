@@ -0,0 +1,323 @@
+package core
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSVColumns es el orden de columnas que se usa al exportar y que se documenta para importar.
+// Al importar, la primera fila debe ser una cabecera con estos nombres, en cualquier orden;
+// solo name, type y content son obligatorias. Las etiquetas se separan con ';'.
+var CSVColumns = []string{"name", "type", "content", "ttl", "proxied", "priority", "comment", "tags"}
+
+// csvTagSeparator separa las etiquetas dentro de la columna tags
+const csvTagSeparator = ";"
+
+// CSVRow es un registro leído de un archivo CSV junto con su número de línea
+type CSVRow struct {
+	Line   int
+	Record *DNSRecord
+}
+
+// CSVError es un error de validación en una línea de un archivo CSV
+type CSVError struct {
+	Line int
+	Err  error
+}
+
+func (e *CSVError) Error() string {
+	return fmt.Sprintf("línea %d: %v", e.Line, e.Err)
+}
+
+func (e *CSVError) Unwrap() error {
+	return e.Err
+}
+
+// ReadRecordsCSV lee registros desde un CSV con cabecera y valida cada fila.
+// Devuelve las filas válidas y un error por cada fila inválida.
+func (c *Config) ReadRecordsCSV(r io.Reader) ([]CSVRow, []error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, []error{&CSVError{Line: 1, Err: errors.New("el archivo está vacío")}}
+	}
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	columns := make(map[string]int)
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if !containsString(CSVColumns, column) {
+			return nil, []error{&CSVError{Line: 1, Err: fmt.Errorf("columna desconocida %q", column)}}
+		}
+		columns[column] = i
+	}
+	for _, required := range []string{"name", "type", "content"} {
+		if _, ok := columns[required]; !ok {
+			return nil, []error{&CSVError{Line: 1, Err: fmt.Errorf("falta la columna obligatoria %q", required)}}
+		}
+	}
+
+	var rows []CSVRow
+	var errs []error
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		if len(fields) == 1 && strings.TrimSpace(fields[0]) == "" {
+			continue
+		}
+
+		record, err := c.parseCSVRecord(fields, columns)
+		if err != nil {
+			errs = append(errs, &CSVError{Line: line, Err: err})
+			continue
+		}
+		rows = append(rows, CSVRow{Line: line, Record: record})
+	}
+	return rows, errs
+}
+
+// parseCSVRecord construye un registro a partir de los campos de una fila
+func (c *Config) parseCSVRecord(fields []string, columns map[string]int) (*DNSRecord, error) {
+	value := func(column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(fields) {
+			return ""
+		}
+		return strings.TrimSpace(fields[i])
+	}
+
+	record := &DNSRecord{
+		Name:    value("name"),
+		Type:    strings.ToUpper(value("type")),
+		Content: value("content"),
+		Comment: value("comment"),
+		TTL:     1, // Auto
+	}
+	if record.Name == "" {
+		return nil, errors.New("falta el nombre")
+	}
+	if record.Type == "" {
+		return nil, errors.New("falta el tipo")
+	}
+	if record.Content == "" {
+		return nil, errors.New("falta el contenido")
+	}
+	name, err := c.ResolveName(record.Name)
+	if err != nil {
+		return nil, err
+	}
+	record.Name = name
+	if record.Content, err = NormalizeContent(record.Type, record.Content); err != nil {
+		return nil, err
+	}
+
+	if ttl := value("ttl"); ttl != "" && !strings.EqualFold(ttl, "auto") {
+		n, err := strconv.Atoi(ttl)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("ttl inválido %q", ttl)
+		}
+		record.TTL = n
+	}
+
+	if proxied := value("proxied"); proxied != "" {
+		switch strings.ToLower(proxied) {
+		case "true", "yes", "si", "sí", "1":
+			record.Proxied = true
+		case "false", "no", "0":
+		default:
+			return nil, fmt.Errorf("valor de proxied inválido %q", proxied)
+		}
+	}
+
+	if priority := value("priority"); priority != "" {
+		n, err := strconv.Atoi(priority)
+		if err != nil || n < 0 || n > 65535 {
+			return nil, fmt.Errorf("prioridad inválida %q", priority)
+		}
+		record.Priority = &n
+	}
+
+	for _, tag := range strings.Split(value("tags"), csvTagSeparator) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			record.Tags = append(record.Tags, tag)
+		}
+	}
+
+	if err := ValidateRecord(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// WriteRecordsCSV escribe los registros en formato CSV con la cabecera de CSVColumns
+func WriteRecordsCSV(w io.Writer, records []*DNSRecord) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(CSVColumns); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		priority := ""
+		if record.Priority != nil {
+			priority = strconv.Itoa(*record.Priority)
+		}
+		row := []string{
+			record.Name,
+			record.Type,
+			record.Content,
+			strconv.Itoa(record.TTL),
+			strconv.FormatBool(record.Proxied),
+			priority,
+			record.Comment,
+			strings.Join(record.Tags, csvTagSeparator),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// Resultados posibles de la importación de una fila
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportSkipped = "skipped"
+	ImportFailed  = "failed"
+)
+
+// ImportResult describe qué se hizo con una fila importada
+type ImportResult struct {
+	Line   int
+	Action string
+	Record *DNSRecord
+	Reason string
+	Err    error
+}
+
+// ImportSummary resume el resultado de una importación
+type ImportSummary struct {
+	Created int
+	Updated int
+	Skipped int
+	Failed  int
+	Results []ImportResult
+}
+
+// add registra el resultado de una fila y actualiza los contadores
+func (s *ImportSummary) add(result ImportResult) {
+	switch result.Action {
+	case ImportCreated:
+		s.Created++
+	case ImportUpdated:
+		s.Updated++
+	case ImportSkipped:
+		s.Skipped++
+	case ImportFailed:
+		s.Failed++
+	}
+	s.Results = append(s.Results, result)
+}
+
+// ImportRecords crea los registros de las filas que no existen en la zona.
+// Con upsert, los registros existentes con el mismo nombre y tipo se actualizan;
+// sin upsert se omiten. Con dryRun solo se calcula el resultado sin aplicar cambios.
+func ImportRecords(provider DNSProvider, rows []CSVRow, existing []*DNSRecord, upsert, dryRun bool) *ImportSummary {
+	summary := &ImportSummary{}
+	consumed := make(map[*DNSRecord]bool)
+
+	for _, row := range rows {
+		record := row.Record
+
+		// Buscar los registros existentes con el mismo nombre y tipo que no se hayan usado
+		var candidates []*DNSRecord
+		var identical *DNSRecord
+		for _, current := range existing {
+			if consumed[current] || !sameRecordKey(current, record) {
+				continue
+			}
+			candidates = append(candidates, current)
+			if identical == nil && current.Content == record.Content {
+				identical = current
+			}
+		}
+
+		var target *DNSRecord
+		switch {
+		case identical != nil:
+			target = identical
+		case len(candidates) == 0:
+			result := ImportResult{Line: row.Line, Action: ImportCreated, Record: record}
+			if !dryRun {
+				if err := provider.CreateDNSRecord(record); err != nil {
+					result.Action, result.Err = ImportFailed, err
+				}
+			}
+			summary.add(result)
+			continue
+		case !upsert:
+			summary.add(ImportResult{Line: row.Line, Action: ImportSkipped, Record: record, Reason: "ya existe"})
+			continue
+		case len(candidates) > 1:
+			summary.add(ImportResult{
+				Line:   row.Line,
+				Action: ImportFailed,
+				Record: record,
+				Err:    fmt.Errorf("%s %s coincide con %d registros existentes", record.Name, record.Type, len(candidates)),
+			})
+			continue
+		default:
+			target = candidates[0]
+		}
+
+		consumed[target] = true
+		if sameRecordData(target, record) {
+			summary.add(ImportResult{Line: row.Line, Action: ImportSkipped, Record: record, Reason: "sin cambios"})
+			continue
+		}
+		if !upsert {
+			summary.add(ImportResult{Line: row.Line, Action: ImportSkipped, Record: record, Reason: "ya existe"})
+			continue
+		}
+
+		result := ImportResult{Line: row.Line, Action: ImportUpdated, Record: record}
+		if !dryRun {
+			updated := copyRecord(record)
+			updated.ID = target.ID
+			if err := provider.UpdateDNSRecord(updated.ID, updated); err != nil {
+				result.Action, result.Err = ImportFailed, err
+			}
+		}
+		summary.add(result)
+	}
+
+	return summary
+}
+
+// containsString indica si la lista contiene el valor
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
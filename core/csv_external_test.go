@@ -0,0 +1,48 @@
+package core_test
+
+import (
+	"strings"
+	"testing"
+
+	"cloudflare-domain-controller/core"
+)
+
+func TestImportRecords(t *testing.T) {
+	server, client := newTestServer(t)
+	webID := server.Add(core.DNSRecord{Name: "www.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+	server.Add(core.DNSRecord{Name: "same.test-domain.com", Type: "A", Content: "192.0.2.9", TTL: 1})
+
+	input := `name,type,content
+www,A,192.0.2.2
+same,A,192.0.2.9
+nuevo,A,192.0.2.3
+`
+	rows, errs := server.Config().ReadRecordsCSV(strings.NewReader(input))
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	existing, err := client.ListDNSRecords()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sin upsert los registros existentes se omiten
+	summary := core.ImportRecords(client, rows, existing, false, true)
+	if summary.Created != 1 || summary.Updated != 0 || summary.Skipped != 2 {
+		t.Errorf("Resumen incorrecto sin upsert: %+v", summary)
+	}
+	if len(server.Records()) != 2 {
+		t.Error("El modo dry-run no debe crear registros")
+	}
+
+	summary = core.ImportRecords(client, rows, existing, true, false)
+	if summary.Created != 1 || summary.Updated != 1 || summary.Skipped != 1 || summary.Failed != 0 {
+		t.Errorf("Resumen incorrecto con upsert: %+v", summary)
+	}
+	if web := server.Record(webID); web == nil || web.Content != "192.0.2.2" {
+		t.Errorf("El registro existente no se actualizó: %+v", web)
+	}
+	if count := len(server.Records()); count != 3 {
+		t.Errorf("Número de registros incorrecto: esperado 3, obtenido %d", count)
+	}
+}
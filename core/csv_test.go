@@ -0,0 +1,76 @@
+package core
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReadRecordsCSV(t *testing.T) {
+	config := &Config{DomainName: "test-domain.com"}
+	input := `name,type,content,ttl,proxied,priority,comment,tags
+www,A,192.0.2.1,auto,true,,servidor web,env:prod;team:web
+mail.test-domain.com,MX,mx.test-domain.com,300,,10,,
+sin-contenido,A,,,,,,
+ttl-malo,A,192.0.2.2,abc,,,,
+,A,192.0.2.3,,,,,
+prioridad,MX,mx.test-domain.com,,,-1,,
+`
+	rows, errs := config.ReadRecordsCSV(strings.NewReader(input))
+	if len(rows) != 2 {
+		t.Fatalf("Número de filas válidas incorrecto: esperado 2, obtenido %d", len(rows))
+	}
+
+	www := rows[0].Record
+	if rows[0].Line != 2 || www.Name != "www.test-domain.com" || www.TTL != 1 || !www.Proxied || www.Comment != "servidor web" {
+		t.Errorf("Fila www incorrecta: %+v", www)
+	}
+	if len(www.Tags) != 2 || www.Tags[1] != "team:web" {
+		t.Errorf("Etiquetas incorrectas: %v", www.Tags)
+	}
+	mail := rows[1].Record
+	if mail.Name != "mail.test-domain.com" || mail.TTL != 300 || mail.Priority == nil || *mail.Priority != 10 {
+		t.Errorf("Fila mail incorrecta: %+v", mail)
+	}
+
+	wantLines := []string{"línea 4:", "línea 5:", "línea 6:", "línea 7:"}
+	if len(errs) != len(wantLines) {
+		t.Fatalf("Número de errores incorrecto: esperado %d, obtenido %d: %v", len(wantLines), len(errs), errs)
+	}
+	for i, err := range errs {
+		if !strings.HasPrefix(err.Error(), wantLines[i]) {
+			t.Errorf("Error sin el número de línea esperado %q: %v", wantLines[i], err)
+		}
+	}
+
+	if _, errs := config.ReadRecordsCSV(strings.NewReader("name,type,weight\n")); len(errs) != 1 {
+		t.Errorf("Se esperaba un error por una columna desconocida: %v", errs)
+	}
+	if _, errs := config.ReadRecordsCSV(strings.NewReader("name,type\n")); len(errs) != 1 {
+		t.Errorf("Se esperaba un error por falta de la columna content: %v", errs)
+	}
+}
+
+func TestWriteRecordsCSVRoundTrip(t *testing.T) {
+	priority := 5
+	records := []*DNSRecord{
+		{Name: "www.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1, Proxied: true, Tags: []string{"a:1", "b:2"}},
+		{Name: "test-domain.com", Type: "MX", Content: "mx.test-domain.com", TTL: 3600, Priority: &priority, Comment: "correo, principal"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteRecordsCSV(&buf, records); err != nil {
+		t.Fatalf("Error al exportar: %v", err)
+	}
+
+	config := &Config{DomainName: "test-domain.com"}
+	rows, errs := config.ReadRecordsCSV(&buf)
+	if len(errs) != 0 {
+		t.Fatalf("Errores al volver a importar: %v", errs)
+	}
+	for i, row := range rows {
+		if !sameRecordData(row.Record, records[i]) {
+			t.Errorf("El registro %d no coincide después de exportar e importar: %+v", i, row.Record)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Respuestas estándar del protocolo dyndns2
+const (
+	DynDNSGood    = "good"
+	DynDNSNoChg   = "nochg"
+	DynDNSBadAuth = "badauth"
+	DynDNSNoHost  = "nohost"
+	DynDNSNotFQDN = "notfqdn"
+	DynDNSNumHost = "numhost"
+	DynDNSDNSErr  = "dnserr"
+)
+
+// dynDNSMaxHosts es el número máximo de hostnames por solicitud según el protocolo
+const dynDNSMaxHosts = 20
+
+// DynDNSUser representa un usuario del servidor dyndns2 y los hostnames que puede actualizar
+type DynDNSUser struct {
+	Username  string
+	Password  string
+	Hostnames []string
+}
+
+// CanUpdate indica si el usuario tiene permiso para actualizar el hostname
+func (u *DynDNSUser) CanUpdate(hostname string) bool {
+	hostname = normalizeHostname(hostname)
+	for _, allowed := range u.Hostnames {
+		if normalizeHostname(allowed) == hostname {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadDynDNSUsers lee la lista de usuarios en formato "usuario:contraseña:host1,host2".
+// Las líneas vacías y las que comienzan con '#' se ignoran.
+func LoadDynDNSUsers(r io.Reader) (map[string]*DynDNSUser, error) {
+	users := make(map[string]*DynDNSUser)
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("línea %d: se esperaba el formato usuario:contraseña:host1,host2", lineNumber)
+		}
+		if _, exists := users[parts[0]]; exists {
+			return nil, fmt.Errorf("línea %d: el usuario %s está duplicado", lineNumber, parts[0])
+		}
+
+		user := &DynDNSUser{Username: parts[0], Password: parts[1]}
+		for _, hostname := range strings.Split(parts[2], ",") {
+			if hostname = strings.TrimSpace(hostname); hostname != "" {
+				user.Hostnames = append(user.Hostnames, hostname)
+			}
+		}
+		users[user.Username] = user
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// DynDNSHandler implementa el endpoint /nic/update del protocolo dyndns2
+type DynDNSHandler struct {
+	provider DNSProvider
+	users    map[string]*DynDNSUser
+}
+
+// NewDynDNSHandler crea un nuevo handler dyndns2 que actualiza los registros mediante el proveedor
+func NewDynDNSHandler(provider DNSProvider, users map[string]*DynDNSUser) *DynDNSHandler {
+	return &DynDNSHandler{
+		provider: provider,
+		users:    users,
+	}
+}
+
+// ServeHTTP atiende las solicitudes /nic/update?hostname=&myip=
+func (h *DynDNSHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user := h.authenticate(r)
+	if user == nil {
+		logger.Warn("solicitud dyndns no autorizada", "remote", r.RemoteAddr)
+		w.Header().Set("WWW-Authenticate", `Basic realm="dyndns"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprintln(w, DynDNSBadAuth)
+		return
+	}
+
+	query := r.URL.Query()
+	var hostnames []string
+	for _, hostname := range strings.Split(query.Get("hostname"), ",") {
+		if hostname = strings.TrimSpace(hostname); hostname != "" {
+			hostnames = append(hostnames, hostname)
+		}
+	}
+	if len(hostnames) == 0 {
+		fmt.Fprintln(w, DynDNSNotFQDN)
+		return
+	}
+	if len(hostnames) > dynDNSMaxHosts {
+		fmt.Fprintln(w, DynDNSNumHost)
+		return
+	}
+
+	// Si el router no envía la IP se usa la dirección de origen de la conexión
+	ips := parseDynDNSAddresses(query.Get("myip"))
+	if len(ips) == 0 {
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			ips = parseDynDNSAddresses(host)
+		}
+	}
+
+	// El protocolo responde una línea por cada hostname, en el mismo orden. La actualización
+	// termina aunque el router cierre la conexión, para no dejar un host a medio actualizar.
+	for _, hostname := range hostnames {
+		ctx, span := tracer.Start(context.WithoutCancel(r.Context()), "dyndns.update", trace.WithAttributes(attribute.String("dns.record.name", hostname)))
+		result := h.updateHostname(ProviderWithContext(h.provider, ctx), user, hostname, ips)
+		span.SetAttributes(attribute.String("dyndns.result", result))
+		span.End()
+		var err error
+		if !strings.HasPrefix(result, DynDNSGood) && !strings.HasPrefix(result, DynDNSNoChg) {
+			err = errors.New(result)
+		}
+		RecordReconcile("dyndns", err)
+		logger.Info("actualización dyndns", "user", user.Username, "hostname", hostname, "result", result, "remote", r.RemoteAddr)
+		fmt.Fprintln(w, result)
+	}
+}
+
+// authenticate valida las credenciales basic-auth y devuelve el usuario correspondiente
+func (h *DynDNSHandler) authenticate(r *http.Request) *DynDNSUser {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return nil
+	}
+	user, exists := h.users[username]
+	if !exists {
+		return nil
+	}
+	if subtle.ConstantTimeCompare([]byte(user.Password), []byte(password)) != 1 {
+		return nil
+	}
+	return user
+}
+
+// updateHostname actualiza un hostname y devuelve la respuesta dyndns2 correspondiente.
+// Cada dirección de myip actualiza el registro de su familia (A para IPv4, AAAA para IPv6),
+// así un host con doble pila se actualiza en una sola solicitud.
+func (h *DynDNSHandler) updateHostname(provider DNSProvider, user *DynDNSUser, hostname string, ips []net.IP) string {
+	if !strings.Contains(hostname, ".") {
+		return DynDNSNotFQDN
+	}
+	if !user.CanUpdate(hostname) {
+		return DynDNSNoHost
+	}
+
+	name := normalizeHostname(hostname)
+	var found, changed bool
+	var applied []string
+	for _, recordType := range []string{"A", "AAAA"} {
+		ip := dynDNSAddressFor(recordType, ips)
+		if ip == nil {
+			continue
+		}
+
+		// Un error de la API no significa que el host no exista: el router debe reintentar
+		records, err := provider.FindDNSRecords(name, recordType)
+		if err != nil {
+			logger.Error("error al buscar el registro dyndns", "hostname", hostname, "type", recordType, "error", err)
+			return DynDNSDNSErr
+		}
+		if len(records) == 0 {
+			continue
+		}
+		found = true
+
+		record := records[0]
+		if !net.ParseIP(record.Content).Equal(ip) {
+			record.Content = ip.String()
+			if err := provider.UpdateDNSRecord(record.ID, record); err != nil {
+				logger.Error("error al actualizar el registro dyndns", "hostname", hostname, "type", recordType, "error", err)
+				return DynDNSDNSErr
+			}
+			changed = true
+		}
+		applied = append(applied, ip.String())
+	}
+
+	if !found {
+		logger.Debug("registro dyndns no encontrado", "hostname", hostname)
+		return DynDNSNoHost
+	}
+	if changed {
+		return DynDNSGood + " " + strings.Join(applied, ",")
+	}
+	return DynDNSNoChg + " " + strings.Join(applied, ",")
+}
+
+// dynDNSAddressFor devuelve la primera dirección de la familia del tipo de registro, o nil
+func dynDNSAddressFor(recordType string, ips []net.IP) net.IP {
+	for _, ip := range ips {
+		if (recordType == "A") == (ip.To4() != nil) {
+			return ip
+		}
+	}
+	return nil
+}
+
+// parseDynDNSAddresses interpreta el parámetro myip, que puede contener varias IPs separadas por comas
+func parseDynDNSAddresses(value string) []net.IP {
+	var ips []net.IP
+	for _, part := range strings.Split(value, ",") {
+		if ip := net.ParseIP(strings.TrimSpace(part)); ip != nil {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
+// normalizeHostname convierte un hostname a minúsculas, sin punto final y con las etiquetas IDN en punycode
+func normalizeHostname(hostname string) string {
+	hostname = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(hostname)), ".")
+	if normalized, err := normalizeName(hostname); err == nil {
+		return normalized
+	}
+	return hostname
+}
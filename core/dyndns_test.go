@@ -0,0 +1,135 @@
+package core_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cloudflare-domain-controller/core"
+)
+
+func TestLoadDynDNSUsers(t *testing.T) {
+	input := `
+# usuarios del router
+fritzbox:secreto:casa.test-domain.com, oficina.test-domain.com
+unifi:otro:vpn.test-domain.com
+`
+	users, err := core.LoadDynDNSUsers(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Error al leer los usuarios: %v", err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("Número de usuarios incorrecto: esperado 2, obtenido %d", len(users))
+	}
+	if !users["fritzbox"].CanUpdate("Oficina.Test-Domain.com.") {
+		t.Error("El usuario fritzbox debería poder actualizar oficina.test-domain.com")
+	}
+	if users["unifi"].CanUpdate("casa.test-domain.com") {
+		t.Error("El usuario unifi no debería poder actualizar casa.test-domain.com")
+	}
+
+	if _, err := core.LoadDynDNSUsers(strings.NewReader("sin-hosts:clave")); err == nil {
+		t.Error("Se esperaba un error para una línea sin hostnames")
+	}
+}
+
+func TestDynDNSHandler(t *testing.T) {
+	server, client := newTestServer(t)
+	casaID := server.Add(core.DNSRecord{Name: "casa.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+	server.Add(core.DNSRecord{Name: "v6.test-domain.com", Type: "AAAA", Content: "2001:db8::1", TTL: 1})
+	dualV4 := server.Add(core.DNSRecord{Name: "dual.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+	dualV6 := server.Add(core.DNSRecord{Name: "dual.test-domain.com", Type: "AAAA", Content: "2001:db8::1", TTL: 1})
+	server.Add(core.DNSRecord{Name: "dual.test-domain.com", Type: "TXT", Content: "\"v=spf1 -all\"", TTL: 1})
+
+	users := map[string]*core.DynDNSUser{
+		"router": {
+			Username:  "router",
+			Password:  "secreto",
+			Hostnames: []string{"casa.test-domain.com", "v6.test-domain.com", "dual.test-domain.com", "falta.test-domain.com"},
+		},
+	}
+	dyndns := httptest.NewServer(core.NewDynDNSHandler(client, users))
+	defer dyndns.Close()
+
+	update := func(user, password, query string) (int, string) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, dyndns.URL+"/nic/update?"+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user != "" {
+			req.SetBasicAuth(user, password)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, strings.TrimSpace(string(body))
+	}
+
+	tests := []struct {
+		name     string
+		user     string
+		password string
+		query    string
+		want     string
+	}{
+		{"sin credenciales", "", "", "hostname=casa.test-domain.com&myip=192.0.2.2", "badauth"},
+		{"contraseña incorrecta", "router", "mala", "hostname=casa.test-domain.com&myip=192.0.2.2", "badauth"},
+		{"sin hostname", "router", "secreto", "myip=192.0.2.2", "notfqdn"},
+		{"hostname no permitido", "router", "secreto", "hostname=otro.test-domain.com&myip=192.0.2.2", "nohost"},
+		{"registro inexistente", "router", "secreto", "hostname=falta.test-domain.com&myip=192.0.2.2", "nohost"},
+		{"actualización", "router", "secreto", "hostname=casa.test-domain.com&myip=192.0.2.2", "good 192.0.2.2"},
+		{"sin cambios", "router", "secreto", "hostname=casa.test-domain.com&myip=192.0.2.2", "nochg 192.0.2.2"},
+		{"sin registro de la familia", "router", "secreto", "hostname=v6.test-domain.com&myip=192.0.2.2", "nohost"},
+		{"doble pila", "router", "secreto", "hostname=dual.test-domain.com&myip=192.0.2.2,2001:db8::2", "good 192.0.2.2,2001:db8::2"},
+		{"doble pila solo IPv6", "router", "secreto", "hostname=dual.test-domain.com&myip=2001:db8::2", "nochg 2001:db8::2"},
+		{"varios hostnames", "router", "secreto", "hostname=casa.test-domain.com,v6.test-domain.com&myip=192.0.2.3,2001:db8::2", "good 192.0.2.3\ngood 2001:db8::2"},
+	}
+
+	const (
+		success = `cfdc_reconcile_total{component="dyndns",result="success"}`
+		failure = `cfdc_reconcile_total{component="dyndns",result="failure"}`
+	)
+	successBefore, failureBefore := metricValue(t, success), metricValue(t, failure)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := update(tt.user, tt.password, tt.query)
+			if body != tt.want {
+				t.Errorf("Respuesta incorrecta: esperado %q, obtenido %q", tt.want, body)
+			}
+			if tt.want == "badauth" && status != http.StatusUnauthorized {
+				t.Errorf("Código de estado incorrecto: esperado 401, obtenido %d", status)
+			}
+		})
+	}
+
+	if casa := server.Record(casaID); casa.Content != "192.0.2.3" {
+		t.Errorf("El contenido del registro no se actualizó: obtenido %q", casa.Content)
+	}
+	if v4 := server.Record(dualV4); v4.Content != "192.0.2.2" {
+		t.Errorf("El registro A de doble pila no se actualizó: obtenido %q", v4.Content)
+	}
+	if v6 := server.Record(dualV6); v6.Content != "2001:db8::2" {
+		t.Errorf("El registro AAAA de doble pila no se actualizó: obtenido %q", v6.Content)
+	}
+
+	// Un error de la API no debe responder nohost, que los routers interpretan como definitivo
+	server.FailRequests(1, http.StatusForbidden)
+	if _, body := update("router", "secreto", "hostname=casa.test-domain.com&myip=192.0.2.4"); body != core.DynDNSDNSErr {
+		t.Errorf("Respuesta ante un error de la API: esperado %q, obtenido %q", core.DynDNSDNSErr, body)
+	}
+
+	// Cada hostname cuenta una reconciliación: good y nochg son correctas, el resto fallidas
+	if got := metricValue(t, success) - successBefore; got != 6 {
+		t.Errorf("Reconciliaciones correctas contadas: %v", got)
+	}
+	if got := metricValue(t, failure) - failureBefore; got != 4 {
+		t.Errorf("Reconciliaciones fallidas contadas: %v", got)
+	}
+}
@@ -0,0 +1,226 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// DefaultConcurrency es el número de operaciones que se ejecutan a la vez por defecto
+const DefaultConcurrency = 8
+
+// ErrDependencyFailed indica que una operación no se ejecutó porque falló otra de la que depende
+var ErrDependencyFailed = errors.New("no se ejecutó porque falló una operación de la que depende")
+
+// Operation es un cambio sobre un registro que ejecuta el Executor. Una operación no empieza
+// hasta que terminan correctamente todas las indicadas en DependsOn.
+type Operation struct {
+	ID        string
+	Change    RecordChange
+	DependsOn []string
+}
+
+// OperationResult es el resultado de ejecutar una operación. Record es el registro resultante
+// (con su ID en el caso de una creación) o nil si la operación era una eliminación o falló.
+type OperationResult struct {
+	Operation Operation
+	Record    *DNSRecord
+	Err       error
+}
+
+// Executor ejecuta operaciones sobre registros DNS en paralelo con un límite de concurrencia.
+// Todas las operaciones comparten el proveedor y por tanto su limitador de solicitudes.
+type Executor struct {
+	provider    DNSProvider
+	concurrency int
+	progress    func(result OperationResult)
+}
+
+// NewExecutor crea un ejecutor que realiza como máximo concurrency operaciones a la vez
+func NewExecutor(provider DNSProvider, concurrency int) *Executor {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Executor{provider: provider, concurrency: concurrency}
+}
+
+// OnProgress configura una función a la que se llama al terminar cada operación.
+// Las llamadas nunca se solapan, por lo que la función no necesita sincronización.
+func (e *Executor) OnProgress(progress func(result OperationResult)) {
+	e.progress = progress
+}
+
+// Run ejecuta las operaciones y devuelve sus resultados en el mismo orden. Los errores de cada
+// operación se recogen en su resultado; Run solo devuelve un error si las dependencias no son
+// válidas (identificadores repetidos o desconocidos, o ciclos). Si se cancela ctx, las
+// operaciones pendientes terminan con el error del contexto.
+func (e *Executor) Run(ctx context.Context, ops []Operation) ([]OperationResult, error) {
+	index, err := operationIndex(ops)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Debug("ejecutando operaciones", "operations", len(ops), "concurrency", e.concurrency)
+	provider := ProviderWithContext(e.provider, ctx)
+	results := make([]OperationResult, len(ops))
+	done := make([]chan struct{}, len(ops))
+	for i := range ops {
+		done[i] = make(chan struct{})
+	}
+	slots := make(chan struct{}, e.concurrency)
+
+	var progressMu sync.Mutex
+	var wg sync.WaitGroup
+	for i, op := range ops {
+		wg.Add(1)
+		go func(i int, op Operation) {
+			defer wg.Done()
+			defer close(done[i])
+
+			results[i] = OperationResult{Operation: op}
+			results[i].Record, results[i].Err = e.execute(ctx, provider, op, index, results, done, slots)
+			if results[i].Err != nil {
+				logger.Warn("operación fallida", "id", op.ID, "action", op.Change.Action, "error", results[i].Err)
+			} else {
+				record := op.Change.Record()
+				logger.Debug("operación aplicada", "id", op.ID, "action", op.Change.Action, "name", record.Name, "type", record.Type)
+			}
+
+			if e.progress != nil {
+				progressMu.Lock()
+				e.progress(results[i])
+				progressMu.Unlock()
+			}
+		}(i, op)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// execute espera a las dependencias y a un hueco libre y aplica la operación
+func (e *Executor) execute(ctx context.Context, provider DNSProvider, op Operation, index map[string]int, results []OperationResult, done []chan struct{}, slots chan struct{}) (*DNSRecord, error) {
+	for _, dep := range op.DependsOn {
+		j := index[dep]
+		select {
+		case <-done[j]:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if results[j].Err != nil {
+			return nil, fmt.Errorf("%w (%s)", ErrDependencyFailed, dep)
+		}
+	}
+
+	select {
+	case slots <- struct{}{}:
+		defer func() { <-slots }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return applyChange(provider, op.Change)
+}
+
+// applyChange aplica un cambio individual con la API de registros
+func applyChange(provider DNSProvider, change RecordChange) (*DNSRecord, error) {
+	switch change.Action {
+	case AuditCreate:
+		record := copyRecord(change.Desired)
+		if err := provider.CreateDNSRecord(record); err != nil {
+			return nil, err
+		}
+		return record, nil
+	case AuditUpdate:
+		if err := provider.UpdateDNSRecord(change.Desired.ID, change.Desired); err != nil {
+			return nil, err
+		}
+		return change.Desired, nil
+	case AuditDelete:
+		return nil, provider.DeleteDNSRecord(change.Current.ID)
+	}
+	return nil, fmt.Errorf("acción desconocida: %s", change.Action)
+}
+
+// operationIndex asocia cada identificador con su posición y comprueba que las dependencias
+// existen y no forman ciclos, ya que un ciclo bloquearía la ejecución
+func operationIndex(ops []Operation) (map[string]int, error) {
+	index := make(map[string]int, len(ops))
+	for i, op := range ops {
+		if op.ID == "" {
+			continue
+		}
+		if _, ok := index[op.ID]; ok {
+			return nil, fmt.Errorf("operación repetida: %s", op.ID)
+		}
+		index[op.ID] = i
+	}
+	for _, op := range ops {
+		for _, dep := range op.DependsOn {
+			if _, ok := index[dep]; !ok {
+				return nil, fmt.Errorf("la operación %q depende de una operación desconocida: %s", op.ID, dep)
+			}
+		}
+	}
+
+	// Búsqueda en profundidad: 1 = en curso, 2 = terminada
+	state := make([]int, len(ops))
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case 1:
+			return fmt.Errorf("dependencia circular en la operación %s", ops[i].ID)
+		case 2:
+			return nil
+		}
+		state[i] = 1
+		for _, dep := range ops[i].DependsOn {
+			if err := visit(index[dep]); err != nil {
+				return err
+			}
+		}
+		state[i] = 2
+		return nil
+	}
+	for i := range ops {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return index, nil
+}
+
+// OperationsFromChanges convierte cambios en operaciones. Las creaciones y actualizaciones
+// dependen de las eliminaciones de registros con el mismo nombre, de modo que un registro en
+// conflicto se elimina antes de crear el que lo sustituye.
+func OperationsFromChanges(changes []RecordChange) []Operation {
+	deletes := make(map[string][]string)
+	ops := make([]Operation, len(changes))
+	for i, change := range changes {
+		ops[i] = Operation{ID: strconv.Itoa(i + 1), Change: change}
+		if change.Action == AuditDelete {
+			name := normalizeHostname(change.Current.Name)
+			deletes[name] = append(deletes[name], ops[i].ID)
+		}
+	}
+	for i, change := range changes {
+		if change.Action != AuditDelete {
+			ops[i].DependsOn = deletes[normalizeHostname(change.Desired.Name)]
+		}
+	}
+	return ops
+}
+
+// FailedOperations devuelve los resultados de las operaciones que no se aplicaron
+func FailedOperations(results []OperationResult) []OperationResult {
+	var failed []OperationResult
+	for _, result := range results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
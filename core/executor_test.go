@@ -0,0 +1,148 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"cloudflare-domain-controller/core"
+)
+
+func TestExecutorConcurrency(t *testing.T) {
+	server, client := newTestServer(t)
+	server.SetLatency(20 * time.Millisecond)
+
+	var changes []core.RecordChange
+	for i := 0; i < 8; i++ {
+		record := &core.DNSRecord{Name: fmt.Sprintf("web%d.test-domain.com", i), Type: "A", Content: "192.0.2.1", TTL: 1}
+		record.ID = server.Add(*record)
+		desired := *record
+		desired.Content = "198.51.100.7"
+		changes = append(changes, core.RecordChange{Action: core.AuditUpdate, Current: record, Desired: &desired})
+	}
+
+	executor := core.NewExecutor(client, 3)
+	progress := 0
+	executor.OnProgress(func(result core.OperationResult) { progress++ })
+	results, err := executor.Run(context.Background(), core.OperationsFromChanges(changes))
+	if err != nil {
+		t.Fatalf("Error inesperado: %v", err)
+	}
+	if failed := core.FailedOperations(results); len(failed) != 0 {
+		t.Errorf("Operaciones fallidas: %+v", failed)
+	}
+	if progress != len(changes) {
+		t.Errorf("Se esperaba progreso para cada operación: %d", progress)
+	}
+	if maxFlight := server.MaxConcurrentRequests(); maxFlight > 3 || maxFlight < 2 {
+		t.Errorf("Concurrencia incorrecta: %d solicitudes simultáneas", maxFlight)
+	}
+	for i, result := range results {
+		if result.Operation.Change.Desired != changes[i].Desired || result.Record.Content != "198.51.100.7" {
+			t.Errorf("Resultado %d fuera de orden: %+v", i, result)
+		}
+	}
+}
+
+func TestExecutorDependencies(t *testing.T) {
+	server, client := newTestServer(t)
+	server.SetLatency(10 * time.Millisecond)
+	old := &core.DNSRecord{Name: "web.test-domain.com", Type: "CNAME", Content: "old.example.net", TTL: 1}
+	old.ID = server.Add(*old)
+
+	changes := []core.RecordChange{
+		{Action: core.AuditCreate, Desired: &core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1}},
+		{Action: core.AuditDelete, Current: old},
+	}
+	ops := core.OperationsFromChanges(changes)
+	if len(ops[0].DependsOn) != 1 || ops[0].DependsOn[0] != ops[1].ID {
+		t.Fatalf("La creación debe depender de la eliminación: %+v", ops)
+	}
+
+	var order []string
+	executor := core.NewExecutor(client, 4)
+	executor.OnProgress(func(result core.OperationResult) { order = append(order, result.Operation.Change.Action) })
+	results, err := executor.Run(context.Background(), ops)
+	if err != nil {
+		t.Fatalf("Error inesperado: %v", err)
+	}
+	if len(order) != 2 || order[0] != core.AuditDelete || order[1] != core.AuditCreate {
+		t.Errorf("Orden incorrecto: %v", order)
+	}
+	if results[0].Record == nil || results[0].Record.ID == "" {
+		t.Errorf("La creación debe devolver el registro con su ID: %+v", results[0])
+	}
+	if server.Record(old.ID) != nil {
+		t.Error("El registro en conflicto no se eliminó")
+	}
+}
+
+func TestExecutorDependencyFailed(t *testing.T) {
+	server, client := newTestServer(t)
+
+	// La eliminación falla porque el registro no existe, así que la creación no se ejecuta
+	changes := []core.RecordChange{
+		{Action: core.AuditDelete, Current: &core.DNSRecord{ID: "no-existe", Name: "web.test-domain.com", Type: "A"}},
+		{Action: core.AuditCreate, Desired: &core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1}},
+	}
+	results, err := core.NewExecutor(client, 2).Run(context.Background(), core.OperationsFromChanges(changes))
+	if err != nil {
+		t.Fatalf("Error inesperado: %v", err)
+	}
+	if results[0].Err == nil || !errors.Is(results[1].Err, core.ErrDependencyFailed) {
+		t.Errorf("Resultados incorrectos: %+v", results)
+	}
+	if count := len(server.Records()); count != 0 {
+		t.Errorf("No se debía crear ningún registro: %d", count)
+	}
+	if len(core.FailedOperations(results)) != 2 {
+		t.Errorf("Se esperaban dos operaciones fallidas: %+v", results)
+	}
+}
+
+func TestExecutorInvalidDependencies(t *testing.T) {
+	_, client := newTestServer(t)
+	executor := core.NewExecutor(client, 2)
+
+	tests := []struct {
+		name string
+		ops  []core.Operation
+	}{
+		{"desconocida", []core.Operation{{ID: "a", DependsOn: []string{"b"}}}},
+		{"repetida", []core.Operation{{ID: "a"}, {ID: "a"}}},
+		{"ciclo", []core.Operation{{ID: "a", DependsOn: []string{"b"}}, {ID: "b", DependsOn: []string{"a"}}}},
+	}
+	for _, tt := range tests {
+		if _, err := executor.Run(context.Background(), tt.ops); err == nil {
+			t.Errorf("%s: se esperaba un error", tt.name)
+		}
+	}
+}
+
+func TestExecutorCancel(t *testing.T) {
+	server, client := newTestServer(t)
+	server.SetLatency(50 * time.Millisecond)
+
+	var changes []core.RecordChange
+	for i := 0; i < 6; i++ {
+		changes = append(changes, core.RecordChange{Action: core.AuditCreate, Desired: &core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1}})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	results, err := core.NewExecutor(client, 1).Run(ctx, core.OperationsFromChanges(changes))
+	if err != nil {
+		t.Fatalf("Error inesperado: %v", err)
+	}
+	cancelled := 0
+	for _, result := range results {
+		if errors.Is(result.Err, context.DeadlineExceeded) {
+			cancelled++
+		}
+	}
+	if cancelled != len(changes) {
+		t.Errorf("Las operaciones pendientes se debían cancelar: %d de %d", cancelled, len(changes))
+	}
+}
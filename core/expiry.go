@@ -0,0 +1,116 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// expiryMarker precede a la fecha de caducidad que se guarda en el comentario del registro.
+// Se usa el comentario y no las etiquetas porque las etiquetas no están disponibles en todos
+// los planes de Cloudflare.
+const expiryMarker = "cfdc-expires="
+
+// DefaultGCInterval es el intervalo por defecto entre revisiones del recolector en modo servicio
+const DefaultGCInterval = 5 * time.Minute
+
+// SetExpiry marca el registro para que 'gc' lo elimine a partir de expires. Se conserva el
+// resto del comentario y se reemplaza la marca anterior si la había.
+func SetExpiry(record *DNSRecord, expires time.Time) {
+	comment := removeExpiry(record.Comment)
+	marker := expiryMarker + expires.UTC().Format(time.RFC3339)
+	if comment == "" {
+		record.Comment = marker
+	} else {
+		record.Comment = comment + " " + marker
+	}
+}
+
+// RecordExpiry devuelve la fecha de caducidad del registro y si tiene una válida
+func RecordExpiry(record *DNSRecord) (time.Time, bool) {
+	for _, field := range strings.Fields(record.Comment) {
+		value, ok := strings.CutPrefix(field, expiryMarker)
+		if !ok {
+			continue
+		}
+		expires, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return expires, true
+	}
+	return time.Time{}, false
+}
+
+// removeExpiry elimina la marca de caducidad de un comentario
+func removeExpiry(comment string) string {
+	var kept []string
+	for _, field := range strings.Fields(comment) {
+		if !strings.HasPrefix(field, expiryMarker) {
+			kept = append(kept, field)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+// ExpiredRecords devuelve los registros cuya fecha de caducidad es anterior o igual a now
+func ExpiredRecords(records []*DNSRecord, now time.Time) []*DNSRecord {
+	var expired []*DNSRecord
+	for _, record := range records {
+		if expires, ok := RecordExpiry(record); ok && !expires.After(now) {
+			expired = append(expired, record)
+		}
+	}
+	return expired
+}
+
+// GCReport resume una pasada del recolector de registros caducados
+type GCReport struct {
+	Time      time.Time
+	Checked   int
+	Collected []*DNSRecord
+	Failed    []OperationResult
+}
+
+// CollectExpired elimina los registros caducados de la zona, como máximo concurrency a la vez.
+// Con dryRun solo los busca: el informe los incluye en Collected sin eliminarlos.
+func CollectExpired(ctx context.Context, provider DNSProvider, now time.Time, concurrency int, dryRun bool) (*GCReport, error) {
+	records, err := ProviderWithContext(provider, ctx).ListDNSRecords()
+	if err != nil {
+		RecordReconcile("gc", err)
+		return nil, fmt.Errorf("error al obtener los registros DNS: %w", err)
+	}
+
+	report := &GCReport{Time: now, Checked: len(records)}
+	expired := ExpiredRecords(records, now)
+	logger.Info("revisión de registros caducados", "checked", len(records), "expired", len(expired), "dry_run", dryRun)
+	if dryRun || len(expired) == 0 {
+		report.Collected = expired
+		RecordReconcile("gc", nil)
+		return report, nil
+	}
+
+	changes := make([]RecordChange, len(expired))
+	for i, record := range expired {
+		changes[i] = RecordChange{Action: AuditDelete, Current: record}
+	}
+	results, err := NewExecutor(provider, concurrency).Run(ctx, OperationsFromChanges(changes))
+	if err != nil {
+		RecordReconcile("gc", err)
+		return nil, err
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			report.Failed = append(report.Failed, result)
+		} else {
+			report.Collected = append(report.Collected, result.Operation.Change.Current)
+		}
+	}
+	var failed error
+	if len(report.Failed) > 0 {
+		failed = report.Failed[0].Err
+	}
+	RecordReconcile("gc", failed)
+	return report, nil
+}
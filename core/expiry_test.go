@@ -0,0 +1,90 @@
+package core_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"cloudflare-domain-controller/core"
+)
+
+func TestSetExpiry(t *testing.T) {
+	record := &core.DNSRecord{Comment: "demo para el cliente"}
+	expires := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	core.SetExpiry(record, expires)
+	if record.Comment != "demo para el cliente cfdc-expires=2026-10-19T12:00:00Z" {
+		t.Errorf("Comentario incorrecto: %q", record.Comment)
+	}
+	if got, ok := core.RecordExpiry(record); !ok || !got.Equal(expires) {
+		t.Errorf("Caducidad incorrecta: %v, %v", got, ok)
+	}
+
+	// Una nueva caducidad reemplaza a la anterior
+	core.SetExpiry(record, expires.Add(time.Hour))
+	if record.Comment != "demo para el cliente cfdc-expires=2026-10-19T13:00:00Z" {
+		t.Errorf("Comentario incorrecto: %q", record.Comment)
+	}
+
+	for _, comment := range []string{"", "sin caducidad", "cfdc-expires=mañana"} {
+		if _, ok := core.RecordExpiry(&core.DNSRecord{Comment: comment}); ok {
+			t.Errorf("No se esperaba caducidad en %q", comment)
+		}
+	}
+}
+
+func TestCollectExpired(t *testing.T) {
+	server, client := newTestServer(t)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	expired := core.DNSRecord{Name: "demo.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1}
+	core.SetExpiry(&expired, now.Add(-time.Minute))
+	pending := core.DNSRecord{Name: "preview.test-domain.com", Type: "A", Content: "192.0.2.2", TTL: 1}
+	core.SetExpiry(&pending, now.Add(time.Hour))
+	expiredID := server.Add(expired)
+	pendingID := server.Add(pending)
+	permanentID := server.Add(core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "192.0.2.3", TTL: 1})
+
+	const (
+		success = `cfdc_reconcile_total{component="gc",result="success"}`
+		failure = `cfdc_reconcile_total{component="gc",result="failure"}`
+	)
+	successBefore, failureBefore := metricValue(t, success), metricValue(t, failure)
+
+	report, err := core.CollectExpired(context.Background(), client, now, 2, true)
+	if err != nil {
+		t.Fatalf("Error inesperado: %v", err)
+	}
+	if report.Checked != 3 || len(report.Collected) != 1 || len(server.Records()) != 3 {
+		t.Errorf("La simulación no debe eliminar nada: %+v", report)
+	}
+
+	report, err = core.CollectExpired(context.Background(), client, now, 2, false)
+	if err != nil {
+		t.Fatalf("Error inesperado: %v", err)
+	}
+	if len(report.Collected) != 1 || report.Collected[0].ID != expiredID || len(report.Failed) != 0 {
+		t.Errorf("Informe incorrecto: %+v", report)
+	}
+	if server.Record(expiredID) != nil {
+		t.Error("El registro caducado no se eliminó")
+	}
+	for _, id := range []string{pendingID, permanentID} {
+		if server.Record(id) == nil {
+			t.Errorf("Se eliminó un registro no caducado: %s", id)
+		}
+	}
+
+	// Cada pasada cuenta una reconciliación, también cuando falla la API
+	server.FailRequests(1, http.StatusForbidden)
+	if _, err := core.CollectExpired(context.Background(), client, now, 2, false); err == nil {
+		t.Error("Se esperaba un error de la API")
+	}
+	if got := metricValue(t, success) - successBefore; got != 2 {
+		t.Errorf("Reconciliaciones correctas contadas: %v", got)
+	}
+	if got := metricValue(t, failure) - failureBefore; got != 1 {
+		t.Errorf("Reconciliaciones fallidas contadas: %v", got)
+	}
+}
@@ -0,0 +1,371 @@
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tipos de comprobación de salud de los orígenes
+const (
+	CheckHTTP  = "http"
+	CheckHTTPS = "https"
+	CheckTCP   = "tcp"
+)
+
+// Valores por defecto de la conmutación por error
+const (
+	DefaultFailoverInterval = 10 * time.Second
+	DefaultCheckTimeout     = 3 * time.Second
+	DefaultFailoverRise     = 2
+	DefaultFailoverFall     = 3
+)
+
+// HealthCheck describe cómo se comprueba la salud de un origen. Las comprobaciones HTTP y HTTPS
+// se consideran correctas con cualquier respuesta menor que 400; las TCP, si se puede abrir la conexión.
+type HealthCheck struct {
+	Kind string
+	// Port es el puerto de la comprobación; 0 usa el del protocolo (80, 443)
+	Port int
+	// Path es la ruta de las comprobaciones HTTP
+	Path string
+	// Host es la cabecera Host y el nombre TLS de las comprobaciones HTTP
+	Host    string
+	Timeout time.Duration
+
+	// client es el cliente HTTP compartido por las comprobaciones de un Failover; sin él
+	// cada comprobación usa un cliente propio
+	client *http.Client
+}
+
+// Check comprueba la salud del origen en la dirección indicada (host o host:puerto)
+func (hc HealthCheck) Check(ctx context.Context, address string) error {
+	timeout := hc.Timeout
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	switch hc.Kind {
+	case CheckTCP:
+		if hc.Port == 0 && !hasPort(address) {
+			return fmt.Errorf("la comprobación TCP necesita un puerto")
+		}
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", hc.target(address, 0))
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+
+	case CheckHTTP, CheckHTTPS:
+		defaultPort := 80
+		if hc.Kind == CheckHTTPS {
+			defaultPort = 443
+		}
+		path := hc.Path
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, hc.Kind+"://"+hc.target(address, defaultPort)+path, nil)
+		if err != nil {
+			return err
+		}
+		if hc.Host != "" {
+			req.Host = hc.Host
+		}
+		client := hc.client
+		if client == nil {
+			client = hc.newHTTPClient()
+			defer client.CloseIdleConnections()
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("respuesta %s", resp.Status)
+		}
+		return nil
+	}
+	return fmt.Errorf("tipo de comprobación desconocido: %s", hc.Kind)
+}
+
+// newHTTPClient crea el cliente de las comprobaciones HTTP y HTTPS
+func (hc HealthCheck) newHTTPClient() *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{ServerName: hc.Host}},
+		// Una redirección ya indica que el origen responde
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+}
+
+// target devuelve la dirección host:puerto que se comprueba
+func (hc HealthCheck) target(address string, defaultPort int) string {
+	if hasPort(address) {
+		return address
+	}
+	port := hc.Port
+	if port == 0 {
+		port = defaultPort
+	}
+	return net.JoinHostPort(strings.Trim(address, "[]"), strconv.Itoa(port))
+}
+
+// hasPort indica si la dirección incluye un puerto
+func hasPort(address string) bool {
+	_, _, err := net.SplitHostPort(address)
+	return err == nil
+}
+
+// FailoverOrigin es un origen candidato. Content es el contenido que se escribe en el registro
+// y Address la dirección que se comprueba (por defecto el propio contenido).
+type FailoverOrigin struct {
+	Content string `json:"content"`
+	Address string `json:"address"`
+}
+
+// ParseFailoverOrigin interpreta un origen con el formato "contenido" o "contenido@dirección",
+// por ejemplo "203.0.113.4@10.0.0.4:8080" para comprobarlo por la red interna
+func ParseFailoverOrigin(value string) (FailoverOrigin, error) {
+	content, address, _ := strings.Cut(strings.TrimSpace(value), "@")
+	if content == "" {
+		return FailoverOrigin{}, fmt.Errorf("origen vacío")
+	}
+	if address == "" {
+		address = content
+	}
+	return FailoverOrigin{Content: content, Address: address}, nil
+}
+
+// OriginStatus es el estado de salud de un origen
+type OriginStatus struct {
+	FailoverOrigin
+	Priority  int       `json:"priority"`
+	Healthy   bool      `json:"healthy"`
+	Checked   bool      `json:"checked"`
+	Successes int       `json:"successes"`
+	Failures  int       `json:"failures"`
+	LastCheck time.Time `json:"last_check,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// FailoverStatus es el estado que publica el endpoint de estado del servicio de conmutación
+type FailoverStatus struct {
+	Name       string         `json:"name"`
+	Type       string         `json:"type"`
+	Active     string         `json:"active"`
+	Healthy    bool           `json:"healthy"`
+	LastChange time.Time      `json:"last_change,omitempty"`
+	LastError  string         `json:"last_error,omitempty"`
+	Origins    []OriginStatus `json:"origins"`
+}
+
+// Failover mantiene un registro apuntando al origen sano de mayor prioridad. Para evitar
+// cambios continuos, un origen solo pasa a estar sano tras Rise comprobaciones correctas
+// seguidas y a estar caído tras Fall fallos seguidos; la primera comprobación fija el estado inicial.
+type Failover struct {
+	Rise int
+	Fall int
+
+	provider DNSProvider
+	name     string
+	rtype    string
+	check    HealthCheck
+
+	mu         sync.Mutex
+	origins    []OriginStatus
+	active     string
+	lastChange time.Time
+	lastErr    string
+}
+
+// NewFailover crea el servicio de conmutación para el registro indicado. Los orígenes se
+// indican por orden de prioridad y su contenido debe ser válido para el tipo del registro.
+func NewFailover(provider DNSProvider, name, recordType string, origins []FailoverOrigin, check HealthCheck) (*Failover, error) {
+	recordType = strings.ToUpper(recordType)
+	switch recordType {
+	case "A", "AAAA", "CNAME":
+	default:
+		return nil, fmt.Errorf("la conmutación solo admite registros A, AAAA y CNAME")
+	}
+	if len(origins) == 0 {
+		return nil, fmt.Errorf("hay que indicar al menos un origen")
+	}
+
+	// Las comprobaciones periódicas reutilizan el cliente y sus conexiones con los orígenes
+	if check.Kind == CheckHTTP || check.Kind == CheckHTTPS {
+		check.client = check.newHTTPClient()
+	}
+	f := &Failover{Rise: DefaultFailoverRise, Fall: DefaultFailoverFall, provider: provider, name: name, rtype: recordType, check: check}
+	for i, origin := range origins {
+		if err := ValidateRecord(&DNSRecord{Name: name, Type: recordType, Content: origin.Content, TTL: 1}); err != nil {
+			return nil, fmt.Errorf("origen %s: %w", origin.Content, err)
+		}
+		f.origins = append(f.origins, OriginStatus{FailoverOrigin: origin, Priority: i + 1})
+	}
+	return f, nil
+}
+
+// Close cierra las conexiones abiertas por las comprobaciones de salud
+func (f *Failover) Close() {
+	if f.check.client != nil {
+		f.check.client.CloseIdleConnections()
+	}
+}
+
+// Probe comprueba todos los orígenes en paralelo y actualiza su estado
+func (f *Failover) Probe(ctx context.Context) {
+	errs := make([]error, len(f.origins))
+	var wg sync.WaitGroup
+	for i, origin := range f.origins {
+		wg.Add(1)
+		go func(i int, address string) {
+			defer wg.Done()
+			errs[i] = f.check.Check(ctx, address)
+		}(i, origin.Address)
+	}
+	wg.Wait()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now().UTC()
+	for i, err := range errs {
+		origin := &f.origins[i]
+		origin.LastCheck = now
+		origin.LastError = ""
+		if err != nil {
+			origin.LastError = err.Error()
+			origin.Successes = 0
+			origin.Failures++
+		} else {
+			origin.Failures = 0
+			origin.Successes++
+		}
+
+		switch {
+		case !origin.Checked:
+			origin.Healthy = err == nil
+			origin.Checked = true
+			logger.Info("estado inicial del origen", "origin", origin.Content, "healthy", origin.Healthy, "error", err)
+		case origin.Healthy && origin.Failures >= f.Fall:
+			origin.Healthy = false
+			logger.Warn("origen caído", "origin", origin.Content, "failures", origin.Failures, "error", err)
+		case !origin.Healthy && origin.Successes >= f.Rise:
+			origin.Healthy = true
+			logger.Info("origen recuperado", "origin", origin.Content, "successes", origin.Successes)
+		default:
+			logger.Debug("comprobación de origen", "origin", origin.Content, "healthy", origin.Healthy, "error", err)
+		}
+	}
+}
+
+// Reconcile actualiza el registro para que apunte al origen sano de mayor prioridad.
+// Si ningún origen está sano el registro no se modifica. Devuelve el cambio aplicado o nil.
+func (f *Failover) Reconcile(ctx context.Context) (*RecordChange, error) {
+	f.mu.Lock()
+	best := ""
+	for _, origin := range f.origins {
+		if origin.Healthy {
+			best = origin.Content
+			break
+		}
+	}
+	f.mu.Unlock()
+
+	change, err := f.apply(ctx, best)
+	RecordReconcile("failover", err)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lastErr = ""
+	if err != nil {
+		f.lastErr = err.Error()
+	}
+	if change != nil {
+		f.active = change.Desired.Content
+		f.lastChange = time.Now().UTC()
+	}
+	return change, err
+}
+
+// apply lleva el registro al contenido indicado
+func (f *Failover) apply(ctx context.Context, content string) (*RecordChange, error) {
+	provider := ProviderWithContext(f.provider, ctx)
+	records, err := provider.FindDNSRecords(f.name, f.rtype)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) != 1 {
+		return nil, fmt.Errorf("se esperaba un registro %s %s y hay %d", f.name, f.rtype, len(records))
+	}
+	current := records[0]
+
+	f.mu.Lock()
+	f.active = current.Content
+	f.mu.Unlock()
+
+	if content == "" {
+		return nil, fmt.Errorf("ningún origen está sano; se mantiene %s", current.Content)
+	}
+	if sameContent(f.rtype, current.Content, content) {
+		return nil, nil
+	}
+
+	desired := copyRecord(current)
+	desired.Content = content
+	if err := provider.UpdateDNSRecord(desired.ID, desired); err != nil {
+		return nil, err
+	}
+	logger.Info("registro conmutado", "name", f.name, "type", f.rtype, "from", current.Content, "to", content)
+	return &RecordChange{Action: AuditUpdate, Current: current, Desired: desired}, nil
+}
+
+// Step realiza una comprobación de los orígenes seguida de la actualización del registro
+func (f *Failover) Step(ctx context.Context) (*RecordChange, error) {
+	f.Probe(ctx)
+	return f.Reconcile(ctx)
+}
+
+// Status devuelve una copia del estado actual
+func (f *Failover) Status() FailoverStatus {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	status := FailoverStatus{
+		Name:       f.name,
+		Type:       f.rtype,
+		Active:     f.active,
+		LastChange: f.lastChange,
+		LastError:  f.lastErr,
+		Origins:    append([]OriginStatus(nil), f.origins...),
+	}
+	for _, origin := range f.origins {
+		if origin.Healthy && sameContent(f.rtype, origin.Content, f.active) {
+			status.Healthy = true
+		}
+	}
+	return status
+}
+
+// ServeHTTP publica el estado en JSON. Responde 503 si el origen activo no está sano, para
+// que el endpoint se pueda usar directamente en una monitorización externa.
+func (f *Failover) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	status := f.Status()
+	w.Header().Set("Content-Type", "application/json")
+	if !status.Healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(status)
+}
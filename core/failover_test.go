@@ -0,0 +1,197 @@
+package core_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"cloudflare-domain-controller/core"
+)
+
+// newOrigin arranca un origen HTTP de prueba cuya salud se controla con el valor devuelto
+func newOrigin(t *testing.T) (*httptest.Server, *atomic.Bool) {
+	t.Helper()
+	healthy := &atomic.Bool{}
+	healthy.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" || !healthy.Load() {
+			http.Error(w, "caído", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+	return server, healthy
+}
+
+func TestHealthCheck(t *testing.T) {
+	origin, healthy := newOrigin(t)
+	address := strings.TrimPrefix(origin.URL, "http://")
+	ctx := context.Background()
+
+	if err := (core.HealthCheck{Kind: core.CheckHTTP, Path: "/health"}).Check(ctx, address); err != nil {
+		t.Errorf("El origen debía estar sano: %v", err)
+	}
+	healthy.Store(false)
+	if err := (core.HealthCheck{Kind: core.CheckHTTP, Path: "/health"}).Check(ctx, address); err == nil {
+		t.Error("Se esperaba un error con una respuesta 503")
+	}
+
+	host, port, _ := net.SplitHostPort(address)
+	check := core.HealthCheck{Kind: core.CheckTCP}
+	check.Port, _ = net.LookupPort("tcp", port)
+	if err := check.Check(ctx, host); err != nil {
+		t.Errorf("La conexión TCP debía funcionar: %v", err)
+	}
+	origin.Close()
+	if err := check.Check(ctx, host); err == nil {
+		t.Error("Se esperaba un error con el origen cerrado")
+	}
+	if err := (core.HealthCheck{Kind: core.CheckTCP}).Check(ctx, host); err == nil {
+		t.Error("La comprobación TCP sin puerto debe fallar")
+	}
+}
+
+func TestParseFailoverOrigin(t *testing.T) {
+	origin, err := core.ParseFailoverOrigin("203.0.113.4@10.0.0.4:8080")
+	if err != nil || origin.Content != "203.0.113.4" || origin.Address != "10.0.0.4:8080" {
+		t.Errorf("Origen incorrecto: %+v, %v", origin, err)
+	}
+	if origin, _ := core.ParseFailoverOrigin("203.0.113.4"); origin.Address != "203.0.113.4" {
+		t.Errorf("La dirección debe ser el contenido: %+v", origin)
+	}
+	if _, err := core.ParseFailoverOrigin("@10.0.0.4"); err == nil {
+		t.Error("Se esperaba un error con un origen vacío")
+	}
+}
+
+func TestFailover(t *testing.T) {
+	server, client := newTestServer(t)
+	id := server.Add(core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 60})
+
+	primary, primaryHealthy := newOrigin(t)
+	backup, _ := newOrigin(t)
+	origins := []core.FailoverOrigin{
+		{Content: "192.0.2.1", Address: strings.TrimPrefix(primary.URL, "http://")},
+		{Content: "192.0.2.2", Address: strings.TrimPrefix(backup.URL, "http://")},
+	}
+	failover, err := core.NewFailover(client, "web.test-domain.com", "A", origins, core.HealthCheck{Kind: core.CheckHTTP, Path: "/health"})
+	if err != nil {
+		t.Fatalf("Error al crear la conmutación: %v", err)
+	}
+	failover.Rise, failover.Fall = 2, 2
+	ctx := context.Background()
+
+	step := func() *core.RecordChange {
+		t.Helper()
+		change, err := failover.Step(ctx)
+		if err != nil {
+			t.Fatalf("Error en la comprobación: %v", err)
+		}
+		return change
+	}
+	content := func() string {
+		return server.Record(id).Content
+	}
+
+	if change := step(); change != nil {
+		t.Errorf("No debía haber cambios con el origen principal sano: %+v", change)
+	}
+
+	// Un solo fallo no basta para cambiar de origen
+	primaryHealthy.Store(false)
+	step()
+	if content() != "192.0.2.1" {
+		t.Errorf("Se cambió de origen con un solo fallo: %s", content())
+	}
+	if change := step(); change == nil || content() != "192.0.2.2" {
+		t.Errorf("Se esperaba el cambio al origen de respaldo: %+v, %s", change, content())
+	}
+
+	// El origen principal vuelve tras dos comprobaciones correctas
+	primaryHealthy.Store(true)
+	step()
+	if content() != "192.0.2.2" {
+		t.Errorf("Se volvió al origen principal demasiado pronto: %s", content())
+	}
+	step()
+	if content() != "192.0.2.1" {
+		t.Errorf("Se esperaba la vuelta al origen principal: %s", content())
+	}
+
+	status := failover.Status()
+	if !status.Healthy || status.Active != "192.0.2.1" || len(status.Origins) != 2 || status.LastChange.IsZero() {
+		t.Errorf("Estado incorrecto: %+v", status)
+	}
+
+	// Sin orígenes sanos el registro no se modifica y el estado responde 503
+	primaryHealthy.Store(false)
+	backup.Close()
+	failover.Step(ctx)
+	if _, err := failover.Step(ctx); err == nil {
+		t.Error("Se esperaba un error sin orígenes sanos")
+	}
+	if content() != "192.0.2.1" {
+		t.Errorf("El registro no debía cambiar: %s", content())
+	}
+
+	recorder := httptest.NewRecorder()
+	failover.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/status", nil))
+	var published core.FailoverStatus
+	if err := json.NewDecoder(recorder.Body).Decode(&published); err != nil {
+		t.Fatalf("Estado JSON inválido: %v", err)
+	}
+	if recorder.Code != http.StatusServiceUnavailable || published.Healthy || published.LastError == "" {
+		t.Errorf("Estado publicado incorrecto: %d %+v", recorder.Code, published)
+	}
+}
+
+func TestFailoverReusesConnections(t *testing.T) {
+	server, client := newTestServer(t)
+	server.Add(core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 60})
+
+	var connections atomic.Int32
+	origin := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	origin.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	origin.Start()
+	t.Cleanup(origin.Close)
+
+	origins := []core.FailoverOrigin{{Content: "192.0.2.1", Address: strings.TrimPrefix(origin.URL, "http://")}}
+	failover, err := core.NewFailover(client, "web.test-domain.com", "A", origins, core.HealthCheck{Kind: core.CheckHTTP, Path: "/health"})
+	if err != nil {
+		t.Fatalf("Error al crear la conmutación: %v", err)
+	}
+	defer failover.Close()
+	for i := 0; i < 3; i++ {
+		if _, err := failover.Step(context.Background()); err != nil {
+			t.Fatalf("Error en la comprobación: %v", err)
+		}
+	}
+	if got := connections.Load(); got != 1 {
+		t.Errorf("Las comprobaciones deben reutilizar la conexión: %d conexiones", got)
+	}
+}
+
+func TestNewFailoverValidation(t *testing.T) {
+	_, client := newTestServer(t)
+	if _, err := core.NewFailover(client, "web.test-domain.com", "TXT", []core.FailoverOrigin{{Content: "x"}}, core.HealthCheck{}); err == nil {
+		t.Error("Se esperaba un error con un tipo no admitido")
+	}
+	if _, err := core.NewFailover(client, "web.test-domain.com", "A", []core.FailoverOrigin{{Content: "web.example.net"}}, core.HealthCheck{}); err == nil {
+		t.Error("Se esperaba un error con un contenido inválido para A")
+	}
+	if _, err := core.NewFailover(client, "web.test-domain.com", "A", nil, core.HealthCheck{}); err == nil {
+		t.Error("Se esperaba un error sin orígenes")
+	}
+}
@@ -0,0 +1,24 @@
+package core
+
+import (
+	"fmt"
+	"os"
+)
+
+// lockFile toma un bloqueo exclusivo sobre el archivo auxiliar path+".lock", esperando si lo
+// tiene otro proceso, y devuelve la función que lo libera. Protege la lectura, modificación y
+// escritura de los archivos de estado que comparten varios procesos (los comandos y los servicios).
+func lockFile(path string) (unlock func(), err error) {
+	file, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockExclusive(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("no se pudo bloquear %s: %w", path, err)
+	}
+	return func() {
+		unlockExclusive(file)
+		file.Close()
+	}, nil
+}
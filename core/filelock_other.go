@@ -0,0 +1,16 @@
+//go:build !unix && !windows
+
+package core
+
+import "os"
+
+// lockExclusive no está disponible en esta plataforma: los archivos de estado solo se
+// protegen dentro del proceso
+func lockExclusive(file *os.File) error {
+	return nil
+}
+
+// unlockExclusive no hace nada en esta plataforma
+func unlockExclusive(file *os.File) error {
+	return nil
+}
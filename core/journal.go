@@ -0,0 +1,204 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultJournalSize es el número de operaciones que se conservan para deshacer
+const DefaultJournalSize = 20
+
+// journalFileName es el archivo del directorio de estado donde se guarda el diario
+const journalFileName = "journal.json"
+
+// ErrRecordDiverged indica que el registro cambió después de la operación que se quiere deshacer
+var ErrRecordDiverged = errors.New("el registro cambió desde la operación")
+
+// JournalEntry representa una operación realizada con el estado del registro antes y después
+type JournalEntry struct {
+	Timestamp time.Time  `json:"timestamp"`
+	Action    string     `json:"action"`
+	Before    *DNSRecord `json:"before,omitempty"`
+	After     *DNSRecord `json:"after,omitempty"`
+}
+
+// Name devuelve el nombre del registro afectado por la operación
+func (e JournalEntry) Name() string {
+	if e.After != nil {
+		return e.After.Name
+	}
+	if e.Before != nil {
+		return e.Before.Name
+	}
+	return ""
+}
+
+// Journal guarda las últimas operaciones realizadas para poder deshacerlas. Las modificaciones
+// se hacen con un bloqueo del archivo, como en Schedule, para que varios comandos a la vez no
+// pierdan operaciones.
+type Journal struct {
+	path string
+	size int
+}
+
+// NewJournal crea un diario en la ruta indicada que conserva como máximo size operaciones
+func NewJournal(path string, size int) *Journal {
+	if size <= 0 {
+		size = DefaultJournalSize
+	}
+	return &Journal{path: path, size: size}
+}
+
+// OpenJournal abre el diario del directorio de estado configurado
+func OpenJournal(config *Config) (*Journal, error) {
+	path, err := config.StatePath(journalFileName)
+	if err != nil {
+		return nil, err
+	}
+	return NewJournal(path, config.JournalSize), nil
+}
+
+// Entries devuelve las operaciones guardadas, de la más antigua a la más reciente
+func (j *Journal) Entries() ([]JournalEntry, error) {
+	data, err := os.ReadFile(j.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []JournalEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("diario de operaciones inválido: %v", err)
+	}
+	return entries, nil
+}
+
+// Append agrega una operación y descarta las más antiguas si se supera el tamaño máximo
+func (j *Journal) Append(action string, before, after *DNSRecord) error {
+	unlock, err := lockFile(j.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := j.Entries()
+	if err != nil {
+		return err
+	}
+
+	entries = append(entries, JournalEntry{
+		Timestamp: time.Now().UTC(),
+		Action:    action,
+		Before:    copyRecord(before),
+		After:     copyRecord(after),
+	})
+	if len(entries) > j.size {
+		entries = entries[len(entries)-j.size:]
+	}
+	return j.save(entries)
+}
+
+// RemoveLast elimina la operación más reciente del diario
+func (j *Journal) RemoveLast() error {
+	unlock, err := lockFile(j.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := j.Entries()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	return j.save(entries[:len(entries)-1])
+}
+
+// save escribe el diario de forma atómica
+func (j *Journal) save(entries []JournalEntry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), ".journal-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), j.path)
+}
+
+// Undo revierte una operación del diario si el registro no cambió desde entonces:
+// elimina un registro creado, restaura el estado anterior de uno actualizado o
+// vuelve a crear uno eliminado.
+func Undo(provider DNSProvider, entry JournalEntry) error {
+	switch entry.Action {
+	case AuditCreate:
+		live, err := provider.GetDNSRecord(entry.After.ID)
+		if err != nil {
+			return fmt.Errorf("no se pudo obtener el registro creado: %w", err)
+		}
+		if !sameRecordData(live, entry.After) {
+			return fmt.Errorf("%s: %w", live.Name, ErrRecordDiverged)
+		}
+		return provider.DeleteDNSRecord(live.ID)
+
+	case AuditUpdate:
+		live, err := provider.GetDNSRecord(entry.After.ID)
+		if err != nil {
+			return fmt.Errorf("no se pudo obtener el registro actualizado: %w", err)
+		}
+		if !sameRecordData(live, entry.After) {
+			return fmt.Errorf("%s: %w", live.Name, ErrRecordDiverged)
+		}
+		record := copyRecord(entry.Before)
+		record.ID = live.ID
+		return provider.UpdateDNSRecord(record.ID, record)
+
+	case AuditDelete:
+		existing, err := provider.FindDNSRecords(entry.Before.Name, entry.Before.Type)
+		if err != nil {
+			return err
+		}
+		for _, live := range existing {
+			if sameRecordData(live, entry.Before) {
+				return fmt.Errorf("%s ya fue creado de nuevo: %w", live.Name, ErrRecordDiverged)
+			}
+		}
+		record := copyRecord(entry.Before)
+		record.ID = ""
+		return provider.CreateDNSRecord(record)
+	}
+	return fmt.Errorf("acción desconocida: %s", entry.Action)
+}
+
+// copyRecord devuelve una copia del registro, o nil si el registro es nil
+func copyRecord(record *DNSRecord) *DNSRecord {
+	if record == nil {
+		return nil
+	}
+	copied := *record
+	if record.Priority != nil {
+		priority := *record.Priority
+		copied.Priority = &priority
+	}
+	copied.Tags = append([]string(nil), record.Tags...)
+	return &copied
+}
@@ -0,0 +1,144 @@
+package core_test
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"cloudflare-domain-controller/core"
+)
+
+func TestJournalKeepsLastEntries(t *testing.T) {
+	journal := core.NewJournal(filepath.Join(t.TempDir(), "journal.json"), 2)
+
+	for _, content := range []string{"192.0.2.1", "192.0.2.2", "192.0.2.3"} {
+		record := &core.DNSRecord{ID: "1", Name: "a.test-domain.com", Type: "A", Content: content}
+		if err := journal.Append(core.AuditCreate, nil, record); err != nil {
+			t.Fatalf("Error al agregar la operación: %v", err)
+		}
+	}
+
+	entries, err := journal.Entries()
+	if err != nil {
+		t.Fatalf("Error al leer el diario: %v", err)
+	}
+	if len(entries) != 2 || entries[0].After.Content != "192.0.2.2" || entries[1].After.Content != "192.0.2.3" {
+		t.Fatalf("El diario no conservó las últimas operaciones: %+v", entries)
+	}
+
+	if err := journal.RemoveLast(); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ = journal.Entries()
+	if len(entries) != 1 || entries[0].After.Content != "192.0.2.2" {
+		t.Errorf("RemoveLast no eliminó la operación más reciente: %+v", entries)
+	}
+}
+
+func TestJournalConcurrentAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.json")
+
+	// Cada comando abre su propio Journal: el bloqueo del archivo evita perder operaciones
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			record := &core.DNSRecord{ID: "1", Name: "a.test-domain.com", Type: "A", Content: "192.0.2.1"}
+			if err := core.NewJournal(path, 50).Append(core.AuditCreate, nil, record); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if entries, err := core.NewJournal(path, 50).Entries(); err != nil || len(entries) != 20 {
+		t.Errorf("Se esperaban 20 operaciones: %d, %v", len(entries), err)
+	}
+}
+
+func TestUndo(t *testing.T) {
+	server, client := newTestServer(t)
+
+	t.Run("create", func(t *testing.T) {
+		record := &core.DNSRecord{Name: "nuevo.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1}
+		if err := client.CreateDNSRecord(record); err != nil {
+			t.Fatal(err)
+		}
+		created := *record
+		if err := core.Undo(client, core.JournalEntry{Action: core.AuditCreate, After: &created}); err != nil {
+			t.Fatalf("Error al deshacer la creación: %v", err)
+		}
+		if server.Record(record.ID) != nil {
+			t.Error("El registro creado no se eliminó")
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		id := server.Add(core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+		before := server.Record(id)
+		after := *before
+		after.Content = "192.0.2.2"
+		after.TTL = 300
+		if err := client.UpdateDNSRecord(id, &after); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := core.Undo(client, core.JournalEntry{Action: core.AuditUpdate, Before: before, After: &after}); err != nil {
+			t.Fatalf("Error al deshacer la actualización: %v", err)
+		}
+		live := server.Record(id)
+		if live.Content != "192.0.2.1" || live.TTL != 1 {
+			t.Errorf("No se restauró el estado anterior: %+v", live)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		id := server.Add(core.DNSRecord{Name: "borrado.test-domain.com", Type: "TXT", Content: "hola", TTL: 1})
+		before := server.Record(id)
+		if err := client.DeleteDNSRecord(id); err != nil {
+			t.Fatal(err)
+		}
+
+		entry := core.JournalEntry{Action: core.AuditDelete, Before: before}
+		if err := core.Undo(client, entry); err != nil {
+			t.Fatalf("Error al deshacer la eliminación: %v", err)
+		}
+		records, _ := client.FindDNSRecords("borrado.test-domain.com", "TXT")
+		if len(records) != 1 || records[0].Content != "hola" {
+			t.Fatalf("El registro eliminado no se volvió a crear: %+v", records)
+		}
+
+		// Deshacer dos veces no debe duplicar el registro
+		if err := core.Undo(client, entry); !errors.Is(err, core.ErrRecordDiverged) {
+			t.Errorf("Se esperaba ErrRecordDiverged, obtenido %v", err)
+		}
+	})
+
+	t.Run("registro modificado", func(t *testing.T) {
+		id := server.Add(core.DNSRecord{Name: "cambiado.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+		before := server.Record(id)
+		after := *before
+		after.Content = "192.0.2.2"
+		if err := client.UpdateDNSRecord(id, &after); err != nil {
+			t.Fatal(err)
+		}
+
+		// Otro operador cambia el registro después de la actualización
+		other := after
+		other.Content = "192.0.2.3"
+		if err := client.UpdateDNSRecord(id, &other); err != nil {
+			t.Fatal(err)
+		}
+
+		err := core.Undo(client, core.JournalEntry{Action: core.AuditUpdate, Before: before, After: &after})
+		if !errors.Is(err, core.ErrRecordDiverged) {
+			t.Errorf("Se esperaba ErrRecordDiverged, obtenido %v", err)
+		}
+		live := server.Record(id)
+		if live.Content != "192.0.2.3" {
+			t.Errorf("El registro modificado no debería haberse tocado: %+v", live)
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package core
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// LevelTrace es el nivel de las trazas HTTP, más detallado que slog.LevelDebug
+const LevelTrace = slog.LevelDebug - 4
+
+// maxLoggedBody es el tamaño máximo de los cuerpos HTTP que se muestran en las trazas
+const maxLoggedBody = 4096
+
+// redacted sustituye a los valores sensibles en los registros
+const redacted = "[REDACTED]"
+
+// sensitiveKeys son fragmentos de nombres de campos y cabeceras cuyo valor nunca se registra
+var sensitiveKeys = []string{"authorization", "token", "password", "secret", "cookie"}
+
+// logger es el registro de la biblioteca; por defecto no escribe nada
+var logger = slog.New(slog.DiscardHandler)
+
+// SetLogger configura el registro que usa el paquete. Con nil se desactiva.
+func SetLogger(l *slog.Logger) {
+	if l == nil {
+		l = slog.New(slog.DiscardHandler)
+	}
+	logger = l
+}
+
+// Logger devuelve el registro que usa el paquete
+func Logger() *slog.Logger {
+	return logger
+}
+
+// NewLogger crea un registro estructurado en texto o JSON con el nivel mínimo indicado.
+// Los atributos con nombres sensibles se ocultan siempre.
+func NewLogger(w io.Writer, level slog.Level, jsonFormat bool) *slog.Logger {
+	options := &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.LevelKey && attr.Value.Any() == LevelTrace {
+				return slog.String(slog.LevelKey, "TRACE")
+			}
+			if isSensitive(attr.Key) {
+				return slog.String(attr.Key, redacted)
+			}
+			return attr
+		},
+	}
+	if jsonFormat {
+		return slog.New(slog.NewJSONHandler(w, options))
+	}
+	return slog.New(slog.NewTextHandler(w, options))
+}
+
+// isSensitive indica si el nombre de un campo o cabecera corresponde a un valor sensible
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactHeaders devuelve las cabeceras como atributos con los valores sensibles ocultos
+func redactHeaders(header http.Header) slog.Value {
+	attrs := make([]slog.Attr, 0, len(header))
+	for key, values := range header {
+		value := strings.Join(values, ", ")
+		if isSensitive(key) {
+			value = redacted
+		}
+		attrs = append(attrs, slog.String(key, value))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// redactBody devuelve el cuerpo de una solicitud o respuesta para las trazas: en los cuerpos
+// JSON se ocultan los campos sensibles y los cuerpos largos se recortan
+func redactBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err == nil {
+		if redactedBody, err := json.Marshal(redactJSON(value)); err == nil {
+			body = redactedBody
+		}
+	}
+	if len(body) > maxLoggedBody {
+		return string(body[:maxLoggedBody]) + "…"
+	}
+	return string(body)
+}
+
+// redactJSON oculta los valores de los campos sensibles de un documento JSON
+func redactJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if isSensitive(key) {
+				v[key] = redacted
+			} else {
+				v[key] = redactJSON(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactJSON(item)
+		}
+	}
+	return value
+}
@@ -0,0 +1,69 @@
+package core_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"cloudflare-domain-controller/core"
+	"cloudflare-domain-controller/core/coretest"
+)
+
+func TestHTTPTrace(t *testing.T) {
+	server, client := newTestServer(t)
+	id := server.Add(core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+
+	var buf bytes.Buffer
+	core.SetLogger(core.NewLogger(&buf, core.LevelTrace, true))
+	t.Cleanup(func() { core.SetLogger(nil) })
+
+	record, _ := client.GetDNSRecord(id)
+	record.Content = "192.0.2.2"
+	if err := client.UpdateDNSRecord(id, record); err != nil {
+		t.Fatalf("Error al actualizar: %v", err)
+	}
+
+	out := buf.String()
+	if strings.Contains(out, coretest.Token) {
+		t.Fatalf("La traza contiene el token de la API: %s", out)
+	}
+
+	var traced bool
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Línea de registro inválida %q: %v", line, err)
+		}
+		if entry["msg"] != "traza HTTP" || entry["method"] != "PATCH" {
+			continue
+		}
+		traced = true
+		headers, _ := entry["request_headers"].(map[string]interface{})
+		if headers["Authorization"] != "[REDACTED]" {
+			t.Errorf("La cabecera Authorization debe ocultarse: %v", headers)
+		}
+		if entry["ray_id"] != "coretest" || entry["status"] != float64(200) || entry["latency"] == nil {
+			t.Errorf("Traza incompleta: %v", entry)
+		}
+		responseHeaders, _ := entry["response_headers"].(map[string]interface{})
+		if responseHeaders["Cf-Ray"] != "coretest" {
+			t.Errorf("La traza debe incluir las cabeceras de la respuesta: %v", entry)
+		}
+		if !strings.Contains(entry["request_body"].(string), "192.0.2.2") || !strings.Contains(entry["response_body"].(string), "192.0.2.2") {
+			t.Errorf("La traza debe incluir los cuerpos: %v", entry)
+		}
+	}
+	if !traced {
+		t.Errorf("No se registró la traza de la actualización: %s", out)
+	}
+
+	// Con el nivel de depuración no se registran los cuerpos
+	buf.Reset()
+	core.SetLogger(core.NewLogger(&buf, slog.LevelDebug, false))
+	client.GetDNSRecord(id)
+	if !strings.Contains(buf.String(), "ray_id=coretest") || strings.Contains(buf.String(), "response_body") {
+		t.Errorf("Registro de depuración incorrecto: %s", buf.String())
+	}
+}
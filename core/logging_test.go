@@ -0,0 +1,38 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRedactBody(t *testing.T) {
+	body := []byte(`{"name":"web","api_token":"secreto","nested":[{"Password":"1234","content":"192.0.2.1"}]}`)
+	got := redactBody(body)
+	if strings.Contains(got, "secreto") || strings.Contains(got, "1234") {
+		t.Errorf("El cuerpo contiene valores sensibles: %s", got)
+	}
+	if !strings.Contains(got, "192.0.2.1") || !strings.Contains(got, redacted) {
+		t.Errorf("Cuerpo incorrecto: %s", got)
+	}
+
+	long := bytes.Repeat([]byte("x"), maxLoggedBody+10)
+	if got := redactBody(long); len(got) > maxLoggedBody+len("…") {
+		t.Errorf("El cuerpo no se recortó: %d", len(got))
+	}
+	if redactBody(nil) != "" {
+		t.Error("Un cuerpo vacío debe mostrarse vacío")
+	}
+}
+
+func TestNewLoggerRedacts(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewLogger(&buf, LevelTrace, false)
+	log.Log(context.Background(), LevelTrace, "prueba", "Authorization", "Bearer secreto", "name", "web")
+
+	out := buf.String()
+	if strings.Contains(out, "secreto") || !strings.Contains(out, "level=TRACE") || !strings.Contains(out, "name=web") {
+		t.Errorf("Salida incorrecta: %s", out)
+	}
+}
@@ -0,0 +1,260 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Resultados de las métricas de reconciliación
+const (
+	ReconcileSuccess = "success"
+	ReconcileFailure = "failure"
+)
+
+// defaultBuckets son los límites de los histogramas de latencia, en segundos
+var defaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Métricas del cliente y de los servicios de larga duración
+var (
+	apiRequests = newCounterVec("cfdc_api_requests_total",
+		"Solicitudes a la API de Cloudflare por método, endpoint y código de estado.", "method", "endpoint", "status")
+	apiRequestDuration = newHistogramVec("cfdc_api_request_duration_seconds",
+		"Latencia de las solicitudes a la API de Cloudflare.", defaultBuckets, "method", "endpoint")
+	apiRateLimited = newCounterVec("cfdc_api_rate_limited_total",
+		"Respuestas 429 recibidas de la API de Cloudflare.")
+	apiRetries = newCounterVec("cfdc_api_retries_total",
+		"Solicitudes repetidas tras una respuesta 429.")
+	limiterWaits = newCounterVec("cfdc_rate_limiter_waits_total",
+		"Solicitudes retrasadas por el limitador local.")
+	limiterWaitSeconds = newCounterVec("cfdc_rate_limiter_wait_seconds_total",
+		"Tiempo total de espera en el limitador local.")
+	reconciles = newCounterVec("cfdc_reconcile_total",
+		"Reconciliaciones de los servicios por componente y resultado.", "component", "result")
+)
+
+// allMetrics son las métricas que publica MetricsHandler, en orden
+var allMetrics = []metric{apiRequests, apiRequestDuration, apiRateLimited, apiRetries, limiterWaits, limiterWaitSeconds, reconciles}
+
+// metric es una familia de métricas que se puede escribir en el formato de texto de Prometheus
+type metric interface {
+	write(w io.Writer)
+}
+
+// counterVec es un contador con etiquetas
+type counterVec struct {
+	mu     sync.Mutex
+	name   string
+	help   string
+	labels []string
+	values map[string]float64
+}
+
+func newCounterVec(name, help string, labels ...string) *counterVec {
+	return &counterVec{name: name, help: help, labels: labels, values: make(map[string]float64)}
+}
+
+// add suma value a la serie con los valores de etiqueta indicados
+func (c *counterVec) add(value float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+	c.mu.Lock()
+	c.values[key] += value
+	c.mu.Unlock()
+}
+
+// inc suma uno a la serie con los valores de etiqueta indicados
+func (c *counterVec) inc(labelValues ...string) {
+	c.add(1, labelValues...)
+}
+
+// value devuelve el valor de una serie
+func (c *counterVec) value(labelValues ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[strings.Join(labelValues, "\xff")]
+}
+
+func (c *counterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	if len(c.labels) == 0 && len(c.values) == 0 {
+		fmt.Fprintf(w, "%s 0\n", c.name)
+		return
+	}
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, key, ""), formatFloat(c.values[key]))
+	}
+}
+
+// histogram acumula observaciones en intervalos
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// histogramVec es un histograma con etiquetas
+type histogramVec struct {
+	mu      sync.Mutex
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+	series  map[string]*histogram
+}
+
+func newHistogramVec(name, help string, buckets []float64, labels ...string) *histogramVec {
+	return &histogramVec{name: name, help: help, labels: labels, buckets: buckets, series: make(map[string]*histogram)}
+}
+
+// observe registra una observación en la serie con los valores de etiqueta indicados
+func (h *histogramVec) observe(value float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	series, ok := h.series[key]
+	if !ok {
+		series = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = series
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			series.counts[i]++
+		}
+	}
+	series.sum += value
+	series.count++
+}
+
+func (h *histogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		series := h.series[key]
+		for i, bound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, key, formatFloat(bound)), series.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, key, "+Inf"), series.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, key, ""), formatFloat(series.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, key, ""), series.count)
+	}
+}
+
+// formatLabels escribe las etiquetas de una serie; le es el límite de un intervalo del histograma
+func formatLabels(names []string, key, le string) string {
+	var pairs []string
+	if len(names) > 0 {
+		values := strings.Split(key, "\xff")
+		for i, name := range names {
+			value := ""
+			if i < len(values) {
+				value = values[i]
+			}
+			pairs = append(pairs, name+"="+escapeLabel(value))
+		}
+	}
+	if le != "" {
+		pairs = append(pairs, "le="+escapeLabel(le))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// escapeLabel escribe el valor de una etiqueta entre comillas con los caracteres escapados
+func escapeLabel(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func sortedKeys(values map[string]float64) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// WriteMetrics escribe todas las métricas en el formato de texto de Prometheus
+func WriteMetrics(w io.Writer) {
+	for _, m := range allMetrics {
+		m.write(w)
+	}
+}
+
+// MetricsHandler devuelve un handler HTTP que publica las métricas para Prometheus
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WriteMetrics(w)
+	})
+}
+
+// RecordReconcile cuenta una reconciliación de un servicio con éxito o con error
+func RecordReconcile(component string, err error) {
+	result := ReconcileSuccess
+	if err != nil {
+		result = ReconcileFailure
+	}
+	reconciles.inc(component, result)
+}
+
+// observeRequest registra una solicitud a la API. status es 0 si no se recibió respuesta.
+func (c *CloudflareClient) observeRequest(method, rawURL string, status int, duration time.Duration) {
+	endpoint := c.endpointLabel(rawURL)
+	statusLabel := "error"
+	if status != 0 {
+		statusLabel = strconv.Itoa(status)
+	}
+	apiRequests.inc(method, endpoint, statusLabel)
+	apiRequestDuration.observe(duration.Seconds(), method, endpoint)
+}
+
+// endpointLabel convierte la URL de una solicitud en una plantilla sin identificadores, para que
+// el número de series no crezca con cada registro: /zones/:zone/dns_records/:id
+func (c *CloudflareClient) endpointLabel(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "unknown"
+	}
+	path := parsed.Path
+	if base, err := url.Parse(c.config.BaseURL); err == nil {
+		path = strings.TrimPrefix(path, strings.TrimSuffix(base.Path, "/"))
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 1; i < len(segments); i++ {
+		switch segments[i-1] {
+		case "zones":
+			segments[i] = ":zone"
+		case "dns_records":
+			if segments[i] != "batch" {
+				segments[i] = ":id"
+			}
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
@@ -0,0 +1,60 @@
+package core_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cloudflare-domain-controller/core"
+)
+
+func TestRequestMetrics(t *testing.T) {
+	server, client := newTestServer(t)
+	id := server.Add(core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+
+	const (
+		ok          = `cfdc_api_requests_total{method="GET",endpoint="/zones/:zone/dns_records/:id",status="200"}`
+		limited     = `cfdc_api_requests_total{method="GET",endpoint="/zones/:zone/dns_records/:id",status="429"}`
+		rateLimited = "cfdc_api_rate_limited_total"
+		retries     = "cfdc_api_retries_total"
+	)
+	okBefore := metricValue(t, ok)
+	limitedBefore := metricValue(t, limited)
+	rateLimitedBefore := metricValue(t, rateLimited)
+	retriesBefore := metricValue(t, retries)
+
+	server.FailRequests(1, http.StatusTooManyRequests)
+	if _, err := client.GetDNSRecord(id); err != nil {
+		t.Fatalf("Error inesperado: %v", err)
+	}
+	if got := metricValue(t, ok) - okBefore; got != 1 {
+		t.Errorf("Solicitudes correctas contadas: %v", got)
+	}
+	if got := metricValue(t, limited) - limitedBefore; got != 1 {
+		t.Errorf("Solicitudes 429 contadas: %v", got)
+	}
+	if limitedCount, retryCount := metricValue(t, rateLimited)-rateLimitedBefore, metricValue(t, retries)-retriesBefore; limitedCount != 1 || retryCount != 1 {
+		t.Errorf("Límite y reintentos mal contados: %v, %v", limitedCount, retryCount)
+	}
+
+	recorder := httptest.NewRecorder()
+	core.MetricsHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	out := recorder.Body.String()
+	for _, want := range []string{
+		"# TYPE cfdc_api_requests_total counter",
+		`cfdc_api_requests_total{method="GET",endpoint="/zones/:zone/dns_records/:id",status="200"}`,
+		"# TYPE cfdc_api_request_duration_seconds histogram",
+		`cfdc_api_request_duration_seconds_bucket{method="GET",endpoint="/zones/:zone/dns_records/:id",le="+Inf"}`,
+		`cfdc_api_request_duration_seconds_count{method="GET",endpoint="/zones/:zone/dns_records/:id"}`,
+		"cfdc_api_rate_limited_total ",
+		"cfdc_api_retries_total ",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Falta %q en las métricas:\n%s", want, out)
+		}
+	}
+	if !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("Content-Type incorrecto: %s", recorder.Header().Get("Content-Type"))
+	}
+}
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEndpointLabel(t *testing.T) {
+	client := NewCloudflareClient(&Config{BaseURL: "https://api.cloudflare.com/client/v4"})
+	tests := map[string]string{
+		"https://api.cloudflare.com/client/v4/zones/abc/dns_records?page=2":  "/zones/:zone/dns_records",
+		"https://api.cloudflare.com/client/v4/zones/abc/dns_records/rec-123": "/zones/:zone/dns_records/:id",
+		"https://api.cloudflare.com/client/v4/zones/abc/dns_records/batch":   "/zones/:zone/dns_records/batch",
+		"https://api.cloudflare.com/client/v4/zones/abc":                     "/zones/:zone",
+	}
+	for rawURL, want := range tests {
+		if got := client.endpointLabel(rawURL); got != want {
+			t.Errorf("endpointLabel(%s): esperado %s, obtenido %s", rawURL, want, got)
+		}
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	h := newHistogramVec("prueba_segundos", "Prueba.", []float64{0.1, 1}, "op")
+	h.observe(0.05, "a")
+	h.observe(0.5, "a")
+	h.observe(5, "a")
+
+	var buf strings.Builder
+	h.write(&buf)
+	for _, want := range []string{
+		`prueba_segundos_bucket{op="a",le="0.1"} 1`,
+		`prueba_segundos_bucket{op="a",le="1"} 2`,
+		`prueba_segundos_bucket{op="a",le="+Inf"} 3`,
+		`prueba_segundos_sum{op="a"} 5.55`,
+		`prueba_segundos_count{op="a"} 3`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Falta %q en el histograma:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestRecordReconcile(t *testing.T) {
+	before := reconciles.value("prueba", ReconcileFailure)
+	RecordReconcile("prueba", errors.New("fallo"))
+	RecordReconcile("prueba", nil)
+	if reconciles.value("prueba", ReconcileFailure)-before != 1 || reconciles.value("prueba", ReconcileSuccess) < 1 {
+		t.Error("Reconciliaciones mal contadas")
+	}
+	if got := escapeLabel("a\"b\\c\nd"); got != `"a\"b\\c\nd"` {
+		t.Errorf("Etiqueta mal escapada: %s", got)
+	}
+}
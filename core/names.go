@@ -0,0 +1,151 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// ApexName es el nombre con el que se indica el ápice de la zona
+const ApexName = "@"
+
+// idnaProfile convierte nombres internacionalizados a punycode. No aplica las reglas STD3
+// para admitir etiquetas con guion bajo como _dmarc o _acme-challenge.
+var idnaProfile = idna.New(idna.MapForLookup(), idna.Transitional(false), idna.StrictDomainName(false))
+
+// ResolveName convierte el nombre indicado por el usuario en el nombre completo del registro
+// dentro de zone. "@" es el ápice; un nombre terminado en punto es absoluto y debe pertenecer
+// a la zona; cualquier otro nombre es relativo a la zona, salvo que ya termine en ella.
+// El resultado está en minúsculas, sin punto final y con las etiquetas IDN en punycode.
+// Si zone está vacía los nombres se devuelven normalizados pero sin completar.
+func ResolveName(name, zone string) (string, error) {
+	normalizedZone, err := normalizeName(strings.TrimSuffix(zone, "."))
+	if err != nil {
+		return "", fmt.Errorf("dominio inválido %q: %w", zone, err)
+	}
+	zone = normalizedZone
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("el nombre está vacío")
+	}
+	if name == ApexName {
+		if zone == "" {
+			return "", fmt.Errorf("'@' requiere configurar CLOUDFLARE_DOMAIN_NAME")
+		}
+		return zone, nil
+	}
+
+	absolute := strings.HasSuffix(name, ".")
+	normalized, err := normalizeName(strings.TrimSuffix(name, "."))
+	if err != nil {
+		return "", fmt.Errorf("nombre inválido %q: %w", name, err)
+	}
+	if zone == "" || isSubdomain(normalized, zone) {
+		return normalized, nil
+	}
+	if absolute {
+		return "", fmt.Errorf("%s no pertenece a la zona %s", normalized, zone)
+	}
+	return normalized + "." + zone, nil
+}
+
+// RelativeName devuelve el nombre relativo a zone: "@" para el ápice, el subdominio para los
+// nombres de la zona y el nombre completo sin cambios si pertenece a otra zona
+func RelativeName(fqdn, zone string) string {
+	name := strings.ToLower(strings.TrimSuffix(fqdn, "."))
+	zone = strings.ToLower(strings.TrimSuffix(zone, "."))
+	switch {
+	case zone == "":
+		return fqdn
+	case name == zone:
+		return ApexName
+	case strings.HasSuffix(name, "."+zone):
+		return fqdn[:len(name)-len(zone)-1]
+	}
+	return fqdn
+}
+
+// ResolveName resuelve el nombre dentro del dominio configurado
+func (c *Config) ResolveName(name string) (string, error) {
+	return ResolveName(name, c.DomainName)
+}
+
+// RelativeName devuelve el nombre relativo al dominio configurado
+func (c *Config) RelativeName(fqdn string) string {
+	return RelativeName(fqdn, c.DomainName)
+}
+
+// ToUnicode convierte las etiquetas punycode de un nombre a Unicode para mostrarlo.
+// Si el nombre no es un IDN válido se devuelve sin cambios.
+func ToUnicode(name string) string {
+	if !strings.Contains(name, "xn--") && !strings.Contains(name, "XN--") {
+		return name
+	}
+	unicode, err := idnaProfile.ToUnicode(name)
+	if err != nil {
+		return name
+	}
+	return unicode
+}
+
+// HasHostnameContent indica si el contenido de los registros del tipo indicado es un nombre de host
+func HasHostnameContent(recordType string) bool {
+	switch strings.ToUpper(recordType) {
+	case "CNAME", "NS", "MX", "PTR":
+		return true
+	}
+	return false
+}
+
+// NormalizeContent convierte a punycode el contenido de los registros cuyo valor es un nombre
+// de host (CNAME, NS, MX y PTR), conservando el punto final. Los demás tipos no se modifican.
+func NormalizeContent(recordType, content string) (string, error) {
+	if !HasHostnameContent(recordType) || isASCII(content) {
+		return content, nil
+	}
+
+	absolute := strings.HasSuffix(content, ".")
+	normalized, err := normalizeName(strings.TrimSuffix(content, "."))
+	if err != nil {
+		return "", fmt.Errorf("contenido inválido %q: %w", content, err)
+	}
+	if absolute {
+		normalized += "."
+	}
+	return normalized, nil
+}
+
+// normalizeName pasa cada etiqueta a minúsculas y convierte a punycode las que no son ASCII
+func normalizeName(name string) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+	labels := strings.Split(name, ".")
+	for i, label := range labels {
+		if label == "" {
+			return "", fmt.Errorf("contiene una etiqueta vacía")
+		}
+		if isASCII(label) {
+			labels[i] = strings.ToLower(label)
+			continue
+		}
+		ascii, err := idnaProfile.ToASCII(label)
+		if err != nil {
+			return "", err
+		}
+		labels[i] = ascii
+	}
+	return strings.Join(labels, "."), nil
+}
+
+// isASCII indica si la cadena solo contiene caracteres ASCII
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
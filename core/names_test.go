@@ -0,0 +1,146 @@
+package core_test
+
+import (
+	"testing"
+
+	"cloudflare-domain-controller/core"
+)
+
+func TestResolveName(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		zone    string
+		want    string
+		wantErr bool
+	}{
+		{"subdominio", "www", "example.com", "www.example.com", false},
+		{"varias etiquetas", "a.b", "example.com", "a.b.example.com", false},
+		{"ápice con @", "@", "example.com", "example.com", false},
+		{"ápice por nombre", "example.com", "example.com", "example.com", false},
+		{"ápice absoluto", "example.com.", "example.com", "example.com", false},
+		{"nombre completo", "www.example.com", "example.com", "www.example.com", false},
+		{"nombre absoluto", "www.example.com.", "example.com", "www.example.com", false},
+		{"sufijo sin punto", "notexample.com", "example.com", "notexample.com.example.com", false},
+		{"otra zona relativa", "www.otra.org", "example.com", "www.otra.org.example.com", false},
+		{"otra zona absoluta", "www.otra.org.", "example.com", "", true},
+		{"sufijo absoluto sin punto", "notexample.com.", "example.com", "", true},
+		{"mayúsculas", "WWW.Example.COM", "example.com", "www.example.com", false},
+		{"zona en mayúsculas", "www", "Example.COM.", "www.example.com", false},
+		{"comodín", "*", "example.com", "*.example.com", false},
+		{"comodín de subdominio", "*.dev", "example.com", "*.dev.example.com", false},
+		{"comodín completo", "*.example.com", "example.com", "*.example.com", false},
+		{"guion bajo", "_dmarc", "example.com", "_dmarc.example.com", false},
+		{"IDN", "música", "example.com", "xn--msica-7ua.example.com", false},
+		{"IDN en mayúsculas", "MÚSICA", "example.com", "xn--msica-7ua.example.com", false},
+		{"punycode", "xn--msica-7ua", "example.com", "xn--msica-7ua.example.com", false},
+		{"zona IDN", "www", "españa.com", "www.xn--espaa-rta.com", false},
+		{"nombre en zona IDN", "www.españa.com", "xn--espaa-rta.com", "www.xn--espaa-rta.com", false},
+		{"espacios", "  www  ", "example.com", "www.example.com", false},
+		{"sin zona", "www.example.com", "", "www.example.com", false},
+		{"sin zona con punto", "WWW.example.com.", "", "www.example.com", false},
+		{"@ sin zona", "@", "", "", true},
+		{"vacío", "", "example.com", "", true},
+		{"etiqueta vacía", "a..b", "example.com", "", true},
+		{"solo un punto", ".", "example.com", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := core.ResolveName(tt.input, tt.zone)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Se esperaba un error, obtenido %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error inesperado: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveName(%q, %q): esperado %q, obtenido %q", tt.input, tt.zone, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRelativeName(t *testing.T) {
+	tests := []struct {
+		fqdn string
+		zone string
+		want string
+	}{
+		{"www.example.com", "example.com", "www"},
+		{"a.b.example.com", "example.com", "a.b"},
+		{"example.com", "example.com", "@"},
+		{"Example.COM.", "example.com", "@"},
+		{"*.example.com", "example.com", "*"},
+		{"notexample.com", "example.com", "notexample.com"},
+		{"www.otra.org", "example.com", "www.otra.org"},
+		{"www.example.com", "", "www.example.com"},
+	}
+
+	for _, tt := range tests {
+		if got := core.RelativeName(tt.fqdn, tt.zone); got != tt.want {
+			t.Errorf("RelativeName(%q, %q): esperado %q, obtenido %q", tt.fqdn, tt.zone, tt.want, got)
+		}
+	}
+}
+
+func TestToUnicode(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"xn--msica-7ua.example.com", "música.example.com"},
+		{"xn--msica-7ua", "música"},
+		{"*.xn--espaa-rta.com", "*.españa.com"},
+		{"_dmarc.example.com", "_dmarc.example.com"},
+		{"@", "@"},
+	}
+	for _, tt := range tests {
+		if got := core.ToUnicode(tt.name); got != tt.want {
+			t.Errorf("ToUnicode(%q): esperado %q, obtenido %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestNormalizeContent(t *testing.T) {
+	tests := []struct {
+		recordType string
+		content    string
+		want       string
+	}{
+		{"CNAME", "música.example.net", "xn--msica-7ua.example.net"},
+		{"CNAME", "Música.example.net.", "xn--msica-7ua.example.net."},
+		{"MX", "correo.españa.com", "correo.xn--espaa-rta.com"},
+		{"CNAME", "Ghs.Example.net", "Ghs.Example.net"},
+		{"TXT", "música", "música"},
+	}
+	for _, tt := range tests {
+		got, err := core.NormalizeContent(tt.recordType, tt.content)
+		if err != nil {
+			t.Errorf("NormalizeContent(%q, %q): error inesperado %v", tt.recordType, tt.content, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NormalizeContent(%q, %q): esperado %q, obtenido %q", tt.recordType, tt.content, tt.want, got)
+		}
+	}
+	if _, err := core.NormalizeContent("CNAME", "música..example.net"); err == nil {
+		t.Error("Se esperaba un error con una etiqueta vacía")
+	}
+}
+
+func TestGetDNSRecordByNameEscapesQuery(t *testing.T) {
+	server, client := newTestServer(t)
+	id := server.Add(core.DNSRecord{Name: "xn--msica-7ua.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+
+	record, err := client.GetDNSRecordByName("música")
+	if err != nil {
+		t.Fatalf("Error al obtener el registro IDN: %v", err)
+	}
+	if record.ID != id {
+		t.Errorf("Registro incorrecto: %+v", record)
+	}
+}
@@ -0,0 +1,374 @@
+package core
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Valores por defecto de las consultas DNS a los servidores autoritativos
+const (
+	dnsPort             = "53"
+	dnsQueryTimeout     = 5 * time.Second
+	dnsMaxMessageSize   = 4096
+	dnsEDNSBufferSize   = 1232
+	DefaultWaitInterval = 5 * time.Second
+	DefaultWaitTimeout  = 5 * time.Minute
+)
+
+// ErrPropagationTimeout indica que el registro no apareció en todos los servidores a tiempo
+var ErrPropagationTimeout = errors.New("el registro no se propagó a todos los servidores antes del tiempo límite")
+
+// dnsTypes relaciona los tipos de registro con los tipos de consulta DNS
+var dnsTypes = map[string]dnsmessage.Type{
+	"A":     dnsmessage.TypeA,
+	"AAAA":  dnsmessage.TypeAAAA,
+	"CNAME": dnsmessage.TypeCNAME,
+	"NS":    dnsmessage.TypeNS,
+	"MX":    dnsmessage.TypeMX,
+	"TXT":   dnsmessage.TypeTXT,
+	"PTR":   dnsmessage.TypePTR,
+	"SRV":   dnsmessage.TypeSRV,
+	"CAA":   dnsmessage.Type(257),
+	"HTTPS": dnsmessage.Type(65),
+	"SVCB":  dnsmessage.Type(64),
+}
+
+// PropagationStatus es el resultado de consultar un registro en un servidor de nombres
+type PropagationStatus struct {
+	Server  string
+	Answers []string
+	Matched bool
+	Err     error
+}
+
+// GetZoneNameservers obtiene los servidores de nombres autoritativos asignados a la zona
+func (c *CloudflareClient) GetZoneNameservers() (nameservers []string, err error) {
+	c, span := c.startSpan("GetZoneNameservers")
+	defer func() { endSpan(span, err) }()
+
+	// Validar configuración
+	if err := c.config.Validate(); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/zones/%s", c.config.BaseURL, c.config.ZoneID)
+	respBody, err := c.makeRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Result struct {
+			NameServers []string `json:"name_servers"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Result.NameServers) == 0 {
+		return nil, fmt.Errorf("la zona no tiene servidores de nombres asignados")
+	}
+	return result.Result.NameServers, nil
+}
+
+// Nameservers devuelve los servidores a consultar: los configurados en CLOUDFLARE_NAMESERVERS
+// o, si no hay ninguno, los servidores autoritativos de la zona
+func Nameservers(config *Config, provider DNSProvider) ([]string, error) {
+	if len(config.Nameservers) > 0 {
+		return config.Nameservers, nil
+	}
+	return provider.GetZoneNameservers()
+}
+
+// QueryDNS consulta al servidor indicado los registros del nombre y tipo dados. Puede ser un
+// servidor autoritativo o un resolvedor recursivo.
+// Las respuestas se devuelven con el mismo formato que el contenido de Cloudflare.
+// Un nombre inexistente no es un error: devuelve una lista vacía.
+func QueryDNS(ctx context.Context, server, name, recordType string) ([]string, error) {
+	qtype, ok := dnsTypes[strings.ToUpper(recordType)]
+	if !ok {
+		return nil, fmt.Errorf("tipo de registro no admitido en las consultas DNS: %s", recordType)
+	}
+	qname, err := dnsmessage.NewName(strings.TrimSuffix(name, ".") + ".")
+	if err != nil {
+		return nil, fmt.Errorf("nombre inválido %q: %w", name, err)
+	}
+
+	// EDNS0 permite respuestas UDP de hasta 1232 bytes sin fragmentar
+	var opt dnsmessage.ResourceHeader
+	if err := opt.SetEDNS0(dnsEDNSBufferSize, dnsmessage.RCodeSuccess, false); err != nil {
+		return nil, err
+	}
+	id := uint16(rand.Uint32())
+	query := dnsmessage.Message{
+		Header:      dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions:   []dnsmessage.Question{{Name: qname, Type: qtype, Class: dnsmessage.ClassINET}},
+		Additionals: []dnsmessage.Resource{{Header: opt, Body: &dnsmessage.OPTResource{}}},
+	}
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok || time.Until(deadline) > dnsQueryTimeout {
+		deadline = time.Now().Add(dnsQueryTimeout)
+	}
+
+	address := nameserverAddress(server)
+	response, err := exchangeDNS(ctx, "udp", address, packed, id, deadline)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	// La respuesta no cabe en UDP: repetir la consulta por TCP
+	if response.Truncated {
+		logger.Debug("respuesta DNS truncada, se repite por TCP", "server", server, "name", name, "type", recordType)
+		if response, err = exchangeDNS(ctx, "tcp", address, packed, id, deadline); err != nil {
+			return nil, err
+		}
+	}
+
+	logger.Debug("consulta DNS", "server", server, "name", name, "type", recordType, "rcode", response.RCode.String(), "answers", len(response.Answers))
+	switch response.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return []string{}, nil
+	default:
+		return nil, fmt.Errorf("el servidor respondió %s", response.RCode)
+	}
+
+	answers := []string{}
+	for _, answer := range response.Answers {
+		if answer.Header.Type != qtype {
+			continue
+		}
+		answers = append(answers, formatAnswer(answer.Body))
+	}
+
+	// Un servidor autoritativo responde a una delegación con los NS en la sección de autoridad
+	if qtype == dnsmessage.TypeNS && len(answers) == 0 {
+		for _, authority := range response.Authorities {
+			if authority.Header.Type == dnsmessage.TypeNS && strings.EqualFold(authority.Header.Name.String(), qname.String()) {
+				answers = append(answers, formatAnswer(authority.Body))
+			}
+		}
+	}
+	return answers, nil
+}
+
+// exchangeDNS envía la consulta por UDP o TCP y espera la respuesta con el mismo ID
+func exchangeDNS(ctx context.Context, network, address string, query []byte, id uint16, deadline time.Time) (*dnsmessage.Message, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(deadline)
+	// Cancelar ctx interrumpe la espera de la respuesta
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	var response dnsmessage.Message
+	if network == "tcp" {
+		// Por TCP cada mensaje va precedido de su longitud en dos bytes
+		msg := make([]byte, 2+len(query))
+		binary.BigEndian.PutUint16(msg, uint16(len(query)))
+		copy(msg[2:], query)
+		if _, err := conn.Write(msg); err != nil {
+			return nil, err
+		}
+		var length [2]byte
+		if _, err := io.ReadFull(conn, length[:]); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, binary.BigEndian.Uint16(length[:]))
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return nil, err
+		}
+		if err := response.Unpack(buf); err != nil {
+			return nil, err
+		}
+		if response.ID != id || !response.Response {
+			return nil, fmt.Errorf("respuesta DNS inesperada de %s", address)
+		}
+		return &response, nil
+	}
+
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, dnsMaxMessageSize)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		// Ignorar las respuestas que no corresponden a la consulta
+		if err := response.Unpack(buf[:n]); err == nil && response.ID == id && response.Response {
+			return &response, nil
+		}
+	}
+}
+
+// CheckPropagation consulta el registro en todos los servidores en paralelo
+func CheckPropagation(ctx context.Context, servers []string, record *DNSRecord) []PropagationStatus {
+	statuses := make([]PropagationStatus, len(servers))
+	var wg sync.WaitGroup
+	for i, server := range servers {
+		wg.Add(1)
+		go func(i int, server string) {
+			defer wg.Done()
+			answers, err := QueryDNS(ctx, server, record.Name, queryType(record))
+			statuses[i] = PropagationStatus{
+				Server:  server,
+				Answers: answers,
+				Err:     err,
+				Matched: err == nil && answerMatches(record, answers),
+			}
+		}(i, server)
+	}
+	wg.Wait()
+	return statuses
+}
+
+// WaitForPropagation repite las consultas cada interval hasta que todos los servidores
+// devuelven el registro esperado o se cancela ctx. Devuelve el último estado de cada servidor.
+func WaitForPropagation(ctx context.Context, servers []string, record *DNSRecord, interval time.Duration) ([]PropagationStatus, error) {
+	if interval <= 0 {
+		interval = DefaultWaitInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		statuses := CheckPropagation(ctx, servers, record)
+		if Propagated(statuses) {
+			return statuses, nil
+		}
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return statuses, ErrPropagationTimeout
+			}
+			return statuses, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Propagated indica si todos los servidores devuelven el registro esperado
+func Propagated(statuses []PropagationStatus) bool {
+	for _, status := range statuses {
+		if !status.Matched {
+			return false
+		}
+	}
+	return len(statuses) > 0
+}
+
+// answerMatches indica si alguna respuesta corresponde al registro. Los registros con proxy
+// se sirven con direcciones de Cloudflare y de los tipos sin formato conocido solo se comprueba que existan.
+func answerMatches(record *DNSRecord, answers []string) bool {
+	if len(answers) == 0 {
+		return false
+	}
+	recordType := strings.ToUpper(record.Type)
+	if record.Proxied {
+		for _, answer := range answers {
+			if IsCloudflareIP(answer) {
+				return true
+			}
+		}
+		return false
+	}
+	if record.Content == "" {
+		return true
+	}
+
+	for _, answer := range answers {
+		switch recordType {
+		case "A", "AAAA":
+			expected, err1 := netip.ParseAddr(record.Content)
+			got, err2 := netip.ParseAddr(answer)
+			if err1 == nil && err2 == nil && expected == got {
+				return true
+			}
+		case "CNAME", "NS", "MX", "PTR":
+			if sameHostname(answer, record.Content) {
+				return true
+			}
+		case "TXT":
+			if answer == txtValue(record.Content) {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// queryType devuelve el tipo que hay que consultar para un registro: los CNAME con proxy
+// se aplanan y se sirven como direcciones de Cloudflare
+func queryType(record *DNSRecord) string {
+	recordType := strings.ToUpper(record.Type)
+	if record.Proxied && recordType == "CNAME" {
+		return "A"
+	}
+	return recordType
+}
+
+// txtValue devuelve el texto de un contenido TXT, uniendo las cadenas entre comillas
+func txtValue(content string) string {
+	if strings.HasPrefix(content, `"`) {
+		return strings.Join(splitQuotedStrings(content), "")
+	}
+	return content
+}
+
+// formatAnswer convierte una respuesta DNS al formato de contenido de Cloudflare
+func formatAnswer(body dnsmessage.ResourceBody) string {
+	switch b := body.(type) {
+	case *dnsmessage.AResource:
+		return netip.AddrFrom4(b.A).String()
+	case *dnsmessage.AAAAResource:
+		return netip.AddrFrom16(b.AAAA).String()
+	case *dnsmessage.CNAMEResource:
+		return strings.TrimSuffix(b.CNAME.String(), ".")
+	case *dnsmessage.NSResource:
+		return strings.TrimSuffix(b.NS.String(), ".")
+	case *dnsmessage.MXResource:
+		return strings.TrimSuffix(b.MX.String(), ".")
+	case *dnsmessage.PTRResource:
+		return strings.TrimSuffix(b.PTR.String(), ".")
+	case *dnsmessage.TXTResource:
+		return strings.Join(b.TXT, "")
+	case *dnsmessage.SRVResource:
+		return fmt.Sprintf("%d %d %s", b.Weight, b.Port, strings.TrimSuffix(b.Target.String(), "."))
+	}
+	return body.GoString()
+}
+
+// nameserverAddress añade el puerto 53 si la dirección del servidor no lo indica
+func nameserverAddress(server string) string {
+	if _, _, err := net.SplitHostPort(server); err == nil {
+		return server
+	}
+	return net.JoinHostPort(strings.Trim(server, "[]"), dnsPort)
+}
@@ -0,0 +1,27 @@
+package core_test
+
+import (
+	"strings"
+	"testing"
+
+	"cloudflare-domain-controller/core"
+)
+
+func TestNameservers(t *testing.T) {
+	server, client := newTestServer(t)
+	server.SetNameservers("ada.ns.cloudflare.com", "bob.ns.cloudflare.com")
+
+	config := server.Config()
+	servers, err := core.Nameservers(config, client)
+	if err != nil {
+		t.Fatalf("Error al obtener los servidores de nombres: %v", err)
+	}
+	if strings.Join(servers, ",") != "ada.ns.cloudflare.com,bob.ns.cloudflare.com" {
+		t.Errorf("Servidores incorrectos: %v", servers)
+	}
+
+	config.Nameservers = []string{"127.0.0.1:5353"}
+	if servers, _ := core.Nameservers(config, client); len(servers) != 1 || servers[0] != "127.0.0.1:5353" {
+		t.Errorf("Se deben usar los servidores configurados: %v", servers)
+	}
+}
@@ -0,0 +1,338 @@
+package core
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// stubDNS es un servidor DNS autoritativo mínimo que responde desde memoria
+type stubDNS struct {
+	mu       sync.Mutex
+	conn     net.PacketConn
+	listener net.Listener
+	answers  map[string][]dnsmessage.Resource
+	queries  int
+	tcp      int
+	truncate bool
+	ednsSize int
+}
+
+// newStubDNS arranca un servidor DNS en un puerto local, por UDP y por TCP
+func newStubDNS(t *testing.T) *stubDNS {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("No se pudo abrir el servidor DNS de prueba: %v", err)
+	}
+	conn, err := net.ListenPacket("udp", listener.Addr().String())
+	if err != nil {
+		listener.Close()
+		t.Fatalf("No se pudo abrir el servidor DNS de prueba: %v", err)
+	}
+	stub := &stubDNS{conn: conn, listener: listener, answers: make(map[string][]dnsmessage.Resource)}
+	t.Cleanup(func() {
+		conn.Close()
+		listener.Close()
+	})
+	go stub.serve()
+	go stub.serveTCP()
+	return stub
+}
+
+// addr devuelve la dirección del servidor
+func (s *stubDNS) addr() string {
+	return s.conn.LocalAddr().String()
+}
+
+// set reemplaza las respuestas para el nombre y tipo indicados
+func (s *stubDNS) set(name string, qtype dnsmessage.Type, bodies ...dnsmessage.ResourceBody) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := stubKey(name+".", qtype)
+	s.answers[key] = nil
+	for _, body := range bodies {
+		s.answers[key] = append(s.answers[key], dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name + "."), Type: qtype, Class: dnsmessage.ClassINET, TTL: 300},
+			Body:   body,
+		})
+	}
+}
+
+// setTruncate hace que las respuestas por UDP lleguen truncadas y sin registros
+func (s *stubDNS) setTruncate(truncate bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.truncate = truncate
+}
+
+// queryCount devuelve el número de consultas recibidas
+func (s *stubDNS) queryCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queries
+}
+
+// tcpCount devuelve el número de consultas recibidas por TCP
+func (s *stubDNS) tcpCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tcp
+}
+
+// lastEDNSSize devuelve el tamaño UDP anunciado por EDNS0 en la última consulta
+func (s *stubDNS) lastEDNSSize() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ednsSize
+}
+
+func (s *stubDNS) serve() {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if packed := s.respond(buf[:n], false); packed != nil {
+			s.conn.WriteTo(packed, addr)
+		}
+	}
+}
+
+func (s *stubDNS) serveTCP() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			var length [2]byte
+			if _, err := io.ReadFull(conn, length[:]); err != nil {
+				return
+			}
+			buf := make([]byte, binary.BigEndian.Uint16(length[:]))
+			if _, err := io.ReadFull(conn, buf); err != nil {
+				return
+			}
+			packed := s.respond(buf, true)
+			if packed == nil {
+				return
+			}
+			msg := binary.BigEndian.AppendUint16(nil, uint16(len(packed)))
+			conn.Write(append(msg, packed...))
+		}()
+	}
+}
+
+// respond construye la respuesta empaquetada a una consulta
+func (s *stubDNS) respond(packed []byte, tcp bool) []byte {
+	var query dnsmessage.Message
+	if err := query.Unpack(packed); err != nil || len(query.Questions) != 1 {
+		return nil
+	}
+	question := query.Questions[0]
+
+	s.mu.Lock()
+	s.queries++
+	if tcp {
+		s.tcp++
+	}
+	s.ednsSize = 0
+	for _, additional := range query.Additionals {
+		if additional.Header.Type == dnsmessage.TypeOPT {
+			s.ednsSize = int(additional.Header.Class)
+		}
+	}
+	answers, ok := s.answers[stubKey(question.Name.String(), question.Type)]
+	truncate := s.truncate && !tcp
+	s.mu.Unlock()
+
+	response := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: query.ID, Response: true, Authoritative: true},
+		Questions: query.Questions,
+		Answers:   answers,
+	}
+	if truncate {
+		response.Truncated = true
+		response.Answers = nil
+	}
+	if !ok {
+		response.RCode = dnsmessage.RCodeNameError
+	}
+	packed, err := response.Pack()
+	if err != nil {
+		return nil
+	}
+	return packed
+}
+
+func stubKey(name string, qtype dnsmessage.Type) string {
+	return strings.ToLower(name) + "/" + qtype.String()
+}
+
+func TestQueryDNS(t *testing.T) {
+	stub := newStubDNS(t)
+	stub.set("web.test-domain.com", dnsmessage.TypeA, &dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}})
+	stub.set("blog.test-domain.com", dnsmessage.TypeCNAME, &dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName("ghs.example.net.")})
+	stub.set("txt.test-domain.com", dnsmessage.TypeTXT, &dnsmessage.TXTResource{TXT: []string{"v=spf1 ", "-all"}})
+
+	tests := []struct {
+		name       string
+		recordType string
+		want       string
+	}{
+		{"web.test-domain.com", "A", "192.0.2.1"},
+		{"blog.test-domain.com.", "CNAME", "ghs.example.net"},
+		{"txt.test-domain.com", "TXT", "v=spf1 -all"},
+		{"falta.test-domain.com", "A", ""},
+	}
+	for _, tt := range tests {
+		answers, err := QueryDNS(context.Background(), stub.addr(), tt.name, tt.recordType)
+		if err != nil {
+			t.Errorf("Error al consultar %s %s: %v", tt.name, tt.recordType, err)
+			continue
+		}
+		if got := strings.Join(answers, ","); got != tt.want {
+			t.Errorf("Respuesta incorrecta para %s %s: esperado %q, obtenido %q", tt.name, tt.recordType, tt.want, got)
+		}
+	}
+
+	if _, err := QueryDNS(context.Background(), stub.addr(), "web.test-domain.com", "XYZ"); err == nil {
+		t.Error("Se esperaba un error para un tipo no admitido")
+	}
+}
+
+func TestQueryDNSCancelled(t *testing.T) {
+	// Un servidor que nunca responde: la consulta termina al cancelar el contexto
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	if _, err := QueryDNS(ctx, conn.LocalAddr().String(), "web.test-domain.com", "A"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Se esperaba context.Canceled, obtenido %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= dnsQueryTimeout {
+		t.Errorf("La consulta no se interrumpió al cancelar: %s", elapsed)
+	}
+}
+
+func TestQueryDNSTruncated(t *testing.T) {
+	stub := newStubDNS(t)
+	stub.set("txt.test-domain.com", dnsmessage.TypeTXT, &dnsmessage.TXTResource{TXT: []string{strings.Repeat("a", 255), strings.Repeat("b", 255)}})
+
+	answers, err := QueryDNS(context.Background(), stub.addr(), "txt.test-domain.com", "TXT")
+	if err != nil {
+		t.Fatalf("Error inesperado: %v", err)
+	}
+	if size := stub.lastEDNSSize(); size != dnsEDNSBufferSize {
+		t.Errorf("La consulta debe anunciar EDNS0 con %d bytes: %d", dnsEDNSBufferSize, size)
+	}
+	if stub.tcpCount() != 0 {
+		t.Error("Una respuesta completa por UDP no debe repetirse por TCP")
+	}
+
+	stub.setTruncate(true)
+	truncated, err := QueryDNS(context.Background(), stub.addr(), "txt.test-domain.com", "TXT")
+	if err != nil {
+		t.Fatalf("Error al repetir la consulta por TCP: %v", err)
+	}
+	if stub.tcpCount() != 1 {
+		t.Errorf("Se esperaba una consulta por TCP: %d", stub.tcpCount())
+	}
+	if strings.Join(truncated, ",") != strings.Join(answers, ",") || len(truncated) != 1 {
+		t.Errorf("Respuesta incorrecta por TCP: %v", truncated)
+	}
+}
+
+func TestAnswerMatches(t *testing.T) {
+	tests := []struct {
+		record  DNSRecord
+		answers []string
+		want    bool
+	}{
+		{DNSRecord{Type: "A", Content: "192.0.2.1"}, []string{"192.0.2.1"}, true},
+		{DNSRecord{Type: "A", Content: "192.0.2.1"}, []string{"192.0.2.2"}, false},
+		{DNSRecord{Type: "A", Content: "192.0.2.1", Proxied: true}, []string{"104.16.0.1"}, true},
+		{DNSRecord{Type: "A", Content: "192.0.2.1", Proxied: true}, nil, false},
+		{DNSRecord{Type: "A", Content: "192.0.2.1", Proxied: true}, []string{"192.0.2.1"}, false},
+		{DNSRecord{Type: "AAAA", Content: "2001:DB8::1"}, []string{"2001:db8::1"}, true},
+		{DNSRecord{Type: "CNAME", Content: "GHS.example.net."}, []string{"ghs.example.net"}, true},
+		{DNSRecord{Type: "TXT", Content: `"v=spf1 " "-all"`}, []string{"v=spf1 -all"}, true},
+		{DNSRecord{Type: "TXT", Content: "hola"}, []string{"adiós"}, false},
+	}
+	for _, tt := range tests {
+		if got := answerMatches(&tt.record, tt.answers); got != tt.want {
+			t.Errorf("answerMatches(%+v, %v): esperado %v, obtenido %v", tt.record, tt.answers, tt.want, got)
+		}
+	}
+}
+
+func TestWaitForPropagation(t *testing.T) {
+	propagated := newStubDNS(t)
+	pending := newStubDNS(t)
+	propagated.set("web.test-domain.com", dnsmessage.TypeA, &dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}})
+	pending.set("web.test-domain.com", dnsmessage.TypeA, &dnsmessage.AResource{A: [4]byte{192, 0, 2, 9}})
+
+	record := &DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "192.0.2.1"}
+	servers := []string{propagated.addr(), pending.addr()}
+
+	// El segundo servidor recibe el registro mientras se espera
+	go func() {
+		for pending.queryCount() < 2 {
+			time.Sleep(5 * time.Millisecond)
+		}
+		pending.set("web.test-domain.com", dnsmessage.TypeA, &dnsmessage.AResource{A: netip.MustParseAddr("192.0.2.1").As4()})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	statuses, err := WaitForPropagation(ctx, servers, record, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Error al esperar la propagación: %v", err)
+	}
+	if !Propagated(statuses) {
+		t.Errorf("Estado incorrecto: %+v", statuses)
+	}
+}
+
+func TestWaitForPropagationTimeout(t *testing.T) {
+	stub := newStubDNS(t)
+	record := &DNSRecord{Name: "falta.test-domain.com", Type: "A", Content: "192.0.2.1"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	statuses, err := WaitForPropagation(ctx, []string{stub.addr()}, record, 10*time.Millisecond)
+	if !errors.Is(err, ErrPropagationTimeout) {
+		t.Fatalf("Se esperaba ErrPropagationTimeout, obtenido %v", err)
+	}
+	if len(statuses) != 1 || statuses[0].Matched || len(statuses[0].Answers) != 0 {
+		t.Errorf("Estado incorrecto: %+v", statuses)
+	}
+}
+
+func TestNameserverAddress(t *testing.T) {
+	if got := nameserverAddress("ada.ns.cloudflare.com"); got != "ada.ns.cloudflare.com:53" {
+		t.Errorf("Dirección incorrecta: %s", got)
+	}
+	if got := nameserverAddress("2001:db8::53"); got != "[2001:db8::53]:53" {
+		t.Errorf("Dirección IPv6 incorrecta: %s", got)
+	}
+}
@@ -0,0 +1,41 @@
+package core
+
+import "context"
+
+// DNSProvider son las operaciones básicas sobre los registros DNS de una zona. La implementa
+// CloudflareClient y también el backend en memoria del paquete coretest, de modo que el
+// código que solo necesita estas operaciones se puede probar sin la API de Cloudflare.
+type DNSProvider interface {
+	// CreateDNSRecord crea el registro y actualiza record con el estado guardado por el
+	// proveedor, incluido el ID asignado
+	CreateDNSRecord(record *DNSRecord) error
+	// UpdateDNSRecord modifica los campos indicados de un registro existente (PATCH) y
+	// actualiza record con el estado guardado por el proveedor
+	UpdateDNSRecord(recordID string, record *DNSRecord) error
+	// ReplaceDNSRecord sobrescribe por completo un registro existente (PUT) y actualiza
+	// record con el estado guardado por el proveedor
+	ReplaceDNSRecord(recordID string, record *DNSRecord) error
+	DeleteDNSRecord(recordID string) error
+	GetDNSRecord(recordID string) (*DNSRecord, error)
+	// GetDNSRecordByName devuelve el primer registro del nombre indicado, completo o relativo a la zona
+	GetDNSRecordByName(name string) (*DNSRecord, error)
+	// FindDNSRecords devuelve los registros del nombre completo indicado y, si no está vacío, del tipo
+	FindDNSRecords(name, recordType string) ([]*DNSRecord, error)
+	// ListDNSRecords devuelve todos los registros de la zona
+	ListDNSRecords() ([]*DNSRecord, error)
+	// BatchDNSRecords aplica las operaciones de forma atómica
+	BatchDNSRecords(ops *BatchOperations) (*BatchResult, error)
+	// GetZoneNameservers devuelve los servidores de nombres autoritativos de la zona
+	GetZoneNameservers() ([]string, error)
+}
+
+var _ DNSProvider = (*CloudflareClient)(nil)
+
+// ProviderWithContext devuelve un proveedor cuyas solicitudes se cancelan con ctx y quedan
+// dentro de su traza. Los proveedores que no hacen solicitudes se devuelven sin cambios.
+func ProviderWithContext(provider DNSProvider, ctx context.Context) DNSProvider {
+	if client, ok := provider.(*CloudflareClient); ok {
+		return client.WithContext(ctx)
+	}
+	return provider
+}
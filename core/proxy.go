@@ -0,0 +1,30 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PlanProxyChanges calcula los cambios necesarios para activar o desactivar el proxy en los
+// registros indicados. Los registros que ya están en el estado pedido se omiten. Si se activa
+// el proxy y algún registro no lo admite, devuelve un error sin planificar ningún cambio.
+func PlanProxyChanges(records []*DNSRecord, proxied bool) ([]RecordChange, error) {
+	var changes []RecordChange
+	var unproxiable []string
+	for _, record := range records {
+		if record.Proxied == proxied {
+			continue
+		}
+		if proxied && !IsProxiable(record.Type) {
+			unproxiable = append(unproxiable, record.Name+" "+record.Type)
+			continue
+		}
+		desired := copyRecord(record)
+		desired.Proxied = proxied
+		changes = append(changes, RecordChange{Action: AuditUpdate, Current: record, Desired: desired})
+	}
+	if len(unproxiable) > 0 {
+		return nil, fmt.Errorf("los siguientes registros no admiten proxy (solo A, AAAA y CNAME): %s", strings.Join(unproxiable, ", "))
+	}
+	return changes, nil
+}
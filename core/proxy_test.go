@@ -0,0 +1,48 @@
+package core_test
+
+import (
+	"strings"
+	"testing"
+
+	"cloudflare-domain-controller/core"
+)
+
+func TestPlanProxyChanges(t *testing.T) {
+	records := []*core.DNSRecord{
+		{ID: "1", Name: "web.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1},
+		{ID: "2", Name: "cdn.test-domain.com", Type: "CNAME", Content: "origen.example.net", TTL: 1, Proxied: true},
+		{ID: "3", Name: "txt.test-domain.com", Type: "TXT", Content: "hola", TTL: 1},
+	}
+
+	changes, err := core.PlanProxyChanges(records[:2], true)
+	if err != nil {
+		t.Fatalf("Error al planificar: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Desired.ID != "1" || !changes[0].Desired.Proxied || changes[0].Current.Proxied {
+		t.Errorf("Cambios incorrectos: %+v", changes)
+	}
+
+	if _, err := core.PlanProxyChanges(records, true); err == nil || !strings.Contains(err.Error(), "txt.test-domain.com TXT") {
+		t.Errorf("Se esperaba un error por el registro TXT, obtenido %v", err)
+	}
+
+	changes, err = core.PlanProxyChanges(records, false)
+	if err != nil || len(changes) != 1 || changes[0].Desired.ID != "2" {
+		t.Errorf("Desactivar el proxy debe cambiar solo el registro con proxy: %+v, %v", changes, err)
+	}
+}
+
+func TestApplyProxyChanges(t *testing.T) {
+	server, client := newTestServer(t)
+	id := server.Add(core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+	changes, err := core.PlanProxyChanges([]*core.DNSRecord{server.Record(id)}, true)
+	if err != nil {
+		t.Fatalf("Error al planificar: %v", err)
+	}
+	if err := core.ApplyBulkChanges(client, changes); err != nil {
+		t.Fatalf("Error al aplicar: %v", err)
+	}
+	if after := server.Record(id); after == nil || !after.Proxied {
+		t.Error("El proxy no se activó")
+	}
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultRateLimit es el número de solicitudes por segundo permitidas por defecto.
+// Cloudflare admite 1200 solicitudes cada 5 minutos por usuario.
+const DefaultRateLimit = 4
+
+// rateLimitBurst es el número de solicitudes que se pueden hacer seguidas sin esperar
+const rateLimitBurst = 20
+
+// Reintentos de las solicitudes rechazadas por exceso de peticiones (429)
+const (
+	maxRetries     = 3
+	baseRetryDelay = time.Second
+)
+
+// RateLimiter limita las solicitudes con un cubo de fichas: permite ráfagas de hasta burst
+// solicitudes y después perSecond solicitudes por segundo. Es seguro usarlo desde varias goroutines.
+type RateLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	tokens  float64
+	updated time.Time
+}
+
+// NewRateLimiter crea un limitador de perSecond solicitudes por segundo con ráfagas de burst solicitudes
+func NewRateLimiter(perSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{rate: perSecond, burst: float64(burst), tokens: float64(burst), updated: time.Now()}
+}
+
+// Wait bloquea hasta que se puede realizar la siguiente solicitud o se cancela ctx
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.updated).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.updated = now
+
+	// Reservar una ficha; si no hay, esperar a que se genere
+	l.tokens--
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if wait > 0 {
+		limiterWaits.inc()
+		limiterWaitSeconds.add(wait.Seconds())
+		logger.Debug("esperando por el límite de solicitudes", "wait", wait)
+	}
+	return sleepContext(ctx, wait)
+}
+
+// sleepContext espera el tiempo indicado o hasta que se cancela ctx
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryDelay calcula la espera antes de reintentar una solicitud rechazada con 429
+func retryDelay(retryAfter string, attempt int) time.Duration {
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return baseRetryDelay << attempt
+}
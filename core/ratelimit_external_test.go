@@ -0,0 +1,31 @@
+package core_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"cloudflare-domain-controller/core"
+)
+
+func TestRetryTooManyRequests(t *testing.T) {
+	server, client := newTestServer(t)
+	id := server.Add(core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+
+	server.FailRequests(2, http.StatusTooManyRequests)
+	record, err := client.GetDNSRecord(id)
+	if err != nil {
+		t.Fatalf("La solicitud se debía reintentar: %v", err)
+	}
+	if record.Content != "192.0.2.1" {
+		t.Errorf("Registro incorrecto: %+v", record)
+	}
+
+	// Todos los intentos reciben 429
+	server.FailRequests(100, http.StatusTooManyRequests)
+	_, err = client.GetDNSRecord(id)
+	var apiErr *core.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 429 {
+		t.Errorf("Se esperaba un error 429 tras agotar los reintentos, obtenido %v", err)
+	}
+}
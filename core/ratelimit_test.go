@@ -0,0 +1,41 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(50, 2)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := limiter.Wait(ctx); err != nil {
+			t.Fatalf("Error inesperado: %v", err)
+		}
+	}
+	// Las dos primeras solicitudes son inmediatas y las otras dos esperan 20ms cada una
+	if elapsed := time.Since(start); elapsed < 35*time.Millisecond {
+		t.Errorf("El limitador no esperó lo suficiente: %v", elapsed)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	slow := NewRateLimiter(0.1, 1)
+	slow.Wait(ctx)
+	if err := slow.Wait(cancelled); !errors.Is(err, context.Canceled) {
+		t.Errorf("Se esperaba context.Canceled, obtenido %v", err)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	if got := retryDelay("3", 0); got != 3*time.Second {
+		t.Errorf("Se debe respetar Retry-After: %v", got)
+	}
+	if got := retryDelay("", 2); got != 4*baseRetryDelay {
+		t.Errorf("Espera incorrecta sin Retry-After: %v", got)
+	}
+}
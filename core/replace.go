@@ -0,0 +1,159 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// ReplaceFailure describe un cambio que no se pudo aplicar durante un reemplazo de contenido
+type ReplaceFailure struct {
+	Change RecordChange
+	Err    error
+}
+
+// ReplaceSummary resume el resultado de aplicar un reemplazo de contenido
+type ReplaceSummary struct {
+	Applied []RecordChange
+	Failed  []ReplaceFailure
+}
+
+// PlanContentReplacement busca los registros que apuntan a from y calcula los cambios para
+// que apunten a to: direcciones de registros A y AAAA, destinos de CNAME y MX y el destino de
+// los SRV. Con includeSPF también se reemplazan los mecanismos ip4, ip6, a, mx e include de los
+// registros TXT de SPF. Todos los registros resultantes se validan antes de devolver el plan.
+func PlanContentReplacement(records []*DNSRecord, from, to string, includeSPF bool) ([]RecordChange, error) {
+	if from == "" || to == "" {
+		return nil, fmt.Errorf("hay que indicar el valor anterior y el nuevo")
+	}
+
+	var changes []RecordChange
+	for _, record := range records {
+		content, ok := replaceContent(record, from, to, includeSPF)
+		if !ok || content == record.Content {
+			continue
+		}
+		desired := copyRecord(record)
+		desired.Content = content
+		if err := ValidateRecord(desired); err != nil {
+			return nil, fmt.Errorf("%s %s: %w", record.Name, record.Type, err)
+		}
+		changes = append(changes, RecordChange{Action: AuditUpdate, Current: record, Desired: desired})
+	}
+	return changes, nil
+}
+
+// ApplyContentReplacement aplica los cambios con UpdateDNSRecord, como máximo concurrency a la
+// vez. A diferencia de una operación batch, un error no detiene el resto: los fallos se recogen
+// en el resumen. Si progress no es nil se llama al terminar cada cambio con su posición en
+// changes y su error; las llamadas nunca se solapan.
+func ApplyContentReplacement(ctx context.Context, provider DNSProvider, changes []RecordChange, concurrency int, progress func(i int, change RecordChange, err error)) *ReplaceSummary {
+	ops := OperationsFromChanges(changes)
+	positions := make(map[string]int, len(ops))
+	for i, op := range ops {
+		positions[op.ID] = i
+	}
+
+	executor := NewExecutor(provider, concurrency)
+	if progress != nil {
+		executor.OnProgress(func(result OperationResult) {
+			progress(positions[result.Operation.ID], result.Operation.Change, result.Err)
+		})
+	}
+	// Las operaciones de OperationsFromChanges no tienen dependencias inválidas
+	results, _ := executor.Run(ctx, ops)
+
+	summary := &ReplaceSummary{}
+	for _, result := range results {
+		if result.Err != nil {
+			summary.Failed = append(summary.Failed, ReplaceFailure{Change: result.Operation.Change, Err: result.Err})
+		} else {
+			summary.Applied = append(summary.Applied, result.Operation.Change)
+		}
+	}
+	return summary
+}
+
+// replaceContent devuelve el contenido del registro con from reemplazado por to y si hubo coincidencia
+func replaceContent(record *DNSRecord, from, to string, includeSPF bool) (string, bool) {
+	switch strings.ToUpper(record.Type) {
+	case "A", "AAAA":
+		if sameAddress(record.Content, from) {
+			return to, true
+		}
+	case "CNAME", "MX":
+		if sameHostname(record.Content, from) {
+			return to, true
+		}
+	case "SRV":
+		// El contenido de un SRV es "peso puerto destino"
+		fields := strings.Fields(record.Content)
+		if len(fields) == 3 && sameHostname(fields[2], from) {
+			fields[2] = to
+			return strings.Join(fields, " "), true
+		}
+	case "TXT":
+		if includeSPF {
+			return replaceSPF(record.Content, from, to)
+		}
+	}
+	return "", false
+}
+
+// replaceSPF reemplaza from por to en los mecanismos de un registro SPF, conservando los
+// calificadores (+, -, ~, ?) y las longitudes de prefijo CIDR
+func replaceSPF(content, from, to string) (string, bool) {
+	quoted := strings.HasPrefix(content, `"`)
+	value := txtValue(content)
+	if !strings.HasPrefix(strings.ToLower(value), "v=spf1") {
+		return "", false
+	}
+
+	terms := strings.Fields(value)
+	replaced := false
+	for i, term := range terms {
+		qualifier := ""
+		if strings.ContainsAny(term[:1], "+-~?") {
+			qualifier, term = term[:1], term[1:]
+		}
+		mechanism, target, ok := strings.Cut(term, ":")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(mechanism) {
+		case "ip4", "ip6", "a", "mx", "include":
+		default:
+			continue
+		}
+		host, cidr, _ := strings.Cut(target, "/")
+		if !sameAddress(host, from) && !sameHostname(host, from) {
+			continue
+		}
+		term = mechanism + ":" + to
+		if cidr != "" {
+			term += "/" + cidr
+		}
+		terms[i] = qualifier + term
+		replaced = true
+	}
+	if !replaced {
+		return "", false
+	}
+
+	result := strings.Join(terms, " ")
+	if len(result) > maxTXTString {
+		return ChunkTXT(result), true
+	}
+	if quoted {
+		return `"` + result + `"`, true
+	}
+	return result, true
+}
+
+// sameAddress compara dos direcciones IP sin tener en cuenta su representación
+func sameAddress(a, b string) bool {
+	addrA, errA := netip.ParseAddr(a)
+	addrB, errB := netip.ParseAddr(b)
+	return errA == nil && errB == nil && addrA == addrB
+}
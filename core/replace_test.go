@@ -0,0 +1,95 @@
+package core_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"cloudflare-domain-controller/core"
+)
+
+func TestPlanContentReplacement(t *testing.T) {
+	records := []*core.DNSRecord{
+		{ID: "1", Name: "web.test-domain.com", Type: "A", Content: "203.0.113.4", TTL: 1},
+		{ID: "2", Name: "otro.test-domain.com", Type: "A", Content: "203.0.113.5", TTL: 1},
+		{ID: "3", Name: "www.test-domain.com", Type: "CNAME", Content: "viejo.example.net", TTL: 1},
+		{ID: "4", Name: "test-domain.com", Type: "MX", Content: "Viejo.example.net.", TTL: 1},
+		{ID: "5", Name: "_sip._tcp.test-domain.com", Type: "SRV", Content: "5 5060 viejo.example.net", TTL: 1},
+		{ID: "6", Name: "test-domain.com", Type: "TXT", Content: `"v=spf1 ip4:203.0.113.4/32 -a:viejo.example.net include:_spf.example.com ~all"`, TTL: 1},
+		{ID: "7", Name: "txt.test-domain.com", Type: "TXT", Content: "servidor 203.0.113.4", TTL: 1},
+	}
+
+	changes, err := core.PlanContentReplacement(records, "203.0.113.4", "198.51.100.7", true)
+	if err != nil {
+		t.Fatalf("Error al planificar: %v", err)
+	}
+	got := map[string]string{}
+	for _, change := range changes {
+		got[change.Desired.ID] = change.Desired.Content
+	}
+	want := map[string]string{
+		"1": "198.51.100.7",
+		"6": `"v=spf1 ip4:198.51.100.7/32 -a:viejo.example.net include:_spf.example.com ~all"`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Cambios incorrectos: %v", got)
+	}
+	for id, content := range want {
+		if got[id] != content {
+			t.Errorf("Registro %s: esperado %q, obtenido %q", id, content, got[id])
+		}
+	}
+
+	changes, err = core.PlanContentReplacement(records, "viejo.example.net", "nuevo.example.net", false)
+	if err != nil {
+		t.Fatalf("Error al planificar: %v", err)
+	}
+	var ids []string
+	for _, change := range changes {
+		ids = append(ids, change.Desired.ID+"="+change.Desired.Content)
+	}
+	if strings.Join(ids, ",") != "3=nuevo.example.net,4=nuevo.example.net,5=5 5060 nuevo.example.net" {
+		t.Errorf("Cambios incorrectos sin SPF: %v", ids)
+	}
+	if records[2].Content != "viejo.example.net" {
+		t.Error("El plan no debe modificar los registros originales")
+	}
+
+	if _, err := core.PlanContentReplacement(records, "203.0.113.4", "2001:db8::7", false); err == nil {
+		t.Error("Se esperaba un error al poner una IPv6 en un registro A")
+	}
+}
+
+func TestApplyContentReplacement(t *testing.T) {
+	server, client := newTestServer(t)
+	webID := server.Add(core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "203.0.113.4", TTL: 1})
+	apiID := server.Add(core.DNSRecord{Name: "api.test-domain.com", Type: "A", Content: "203.0.113.4", TTL: 1})
+
+	records, err := client.ListDNSRecords()
+	if err != nil {
+		t.Fatalf("Error al listar: %v", err)
+	}
+	changes, err := core.PlanContentReplacement(records, "203.0.113.4", "198.51.100.7", false)
+	if err != nil || len(changes) != 2 {
+		t.Fatalf("Plan incorrecto: %+v, %v", changes, err)
+	}
+
+	// Simular que el segundo registro se eliminó entre el plan y la aplicación
+	if err := server.DeleteDNSRecord(apiID); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls []int
+	summary := core.ApplyContentReplacement(context.Background(), client, changes, 2, func(i int, change core.RecordChange, err error) {
+		calls = append(calls, i)
+	})
+	if len(summary.Applied) != 1 || len(summary.Failed) != 1 || summary.Failed[0].Change.Desired.ID != apiID {
+		t.Errorf("Resumen incorrecto: %+v", summary)
+	}
+	if len(calls) != 2 {
+		t.Errorf("Se esperaba progreso para cada cambio: %v", calls)
+	}
+	if web := server.Record(webID); web == nil || web.Content != "198.51.100.7" {
+		t.Errorf("El registro no se actualizó: %+v", web)
+	}
+}
@@ -0,0 +1,113 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NewRRSet construye los registros deseados de un conjunto: todos los registros del mismo
+// nombre y tipo, uno por contenido, con el mismo TTL y estado del proxy. Los contenidos se
+// normalizan y validan, y los repetidos se descartan.
+func NewRRSet(name, recordType string, contents []string, ttl int, proxied bool) ([]*DNSRecord, error) {
+	recordType = strings.ToUpper(recordType)
+	var records []*DNSRecord
+	for _, content := range contents {
+		normalized, err := NormalizeContent(recordType, content)
+		if err != nil {
+			return nil, err
+		}
+		if recordType == "TXT" {
+			normalized = ChunkTXT(normalized)
+		}
+		if containsContent(records, normalized) {
+			continue
+		}
+		record := &DNSRecord{Name: name, Type: recordType, Content: normalized, TTL: ttl, Proxied: proxied}
+		if err := ValidateRecord(record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	if recordType == "CNAME" && len(records) > 1 {
+		return nil, fmt.Errorf("un nombre solo puede tener un registro CNAME")
+	}
+	return records, nil
+}
+
+// PlanRRSet calcula los cambios para que el conjunto current pase a tener exactamente los
+// registros de desired: los miembros con el mismo contenido se conservan (actualizando el TTL
+// o el proxy si cambian), los que sobran se eliminan y los que faltan se crean. Si varios
+// miembros tienen el mismo contenido se conserva el primero y se eliminan los demás.
+func PlanRRSet(current, desired []*DNSRecord) []RecordChange {
+	var deletes, updates, creates []RecordChange
+	for i, record := range current {
+		if !containsContent(desired, record.Content) || containsContent(current[:i], record.Content) {
+			deletes = append(deletes, RecordChange{Action: AuditDelete, Current: record})
+		}
+	}
+	for _, want := range desired {
+		var have *DNSRecord
+		for _, record := range current {
+			if sameContent(record.Type, record.Content, want.Content) {
+				have = record
+				break
+			}
+		}
+		if have == nil {
+			creates = append(creates, RecordChange{Action: AuditCreate, Desired: copyRecord(want)})
+			continue
+		}
+		if have.TTL != want.TTL || have.Proxied != want.Proxied {
+			updated := copyRecord(have)
+			updated.TTL = want.TTL
+			updated.Proxied = want.Proxied
+			updates = append(updates, RecordChange{Action: AuditUpdate, Current: have, Desired: updated})
+		}
+	}
+
+	changes := append(deletes, updates...)
+	return append(changes, creates...)
+}
+
+// RRSetContents devuelve los contenidos de los registros del conjunto
+func RRSetContents(records []*DNSRecord) []string {
+	contents := make([]string, len(records))
+	for i, record := range records {
+		contents[i] = record.Content
+	}
+	return contents
+}
+
+// RemoveRRSetContents devuelve los contenidos del conjunto sin los indicados. Devuelve un error
+// si alguno de los contenidos a quitar no pertenece al conjunto.
+func RemoveRRSetContents(records []*DNSRecord, remove []string) ([]string, error) {
+	for _, content := range remove {
+		if !containsContent(records, content) {
+			return nil, fmt.Errorf("%s no pertenece al conjunto", content)
+		}
+	}
+	var kept []string
+	for _, record := range records {
+		removed := false
+		for _, content := range remove {
+			if sameContent(record.Type, record.Content, content) {
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			kept = append(kept, record.Content)
+		}
+	}
+	return kept, nil
+}
+
+// containsContent indica si algún registro tiene el contenido indicado
+func containsContent(records []*DNSRecord, content string) bool {
+	for _, record := range records {
+		if sameContent(record.Type, record.Content, content) {
+			return true
+		}
+	}
+	return false
+}
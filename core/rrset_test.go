@@ -0,0 +1,106 @@
+package core_test
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"cloudflare-domain-controller/core"
+)
+
+func TestPlanRRSet(t *testing.T) {
+	current := []*core.DNSRecord{
+		{ID: "1", Name: "web.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 300},
+		{ID: "2", Name: "web.test-domain.com", Type: "A", Content: "192.0.2.2", TTL: 300},
+	}
+
+	desired, err := core.NewRRSet("web.test-domain.com", "A", []string{"192.0.2.2", "192.0.2.3", "192.0.2.3"}, 300, false)
+	if err != nil {
+		t.Fatalf("Error inesperado: %v", err)
+	}
+	if len(desired) != 2 {
+		t.Fatalf("Los contenidos repetidos se deben descartar: %+v", desired)
+	}
+
+	changes := core.PlanRRSet(current, desired)
+	if len(changes) != 2 ||
+		changes[0].Action != core.AuditDelete || changes[0].Current.ID != "1" ||
+		changes[1].Action != core.AuditCreate || changes[1].Desired.Content != "192.0.2.3" {
+		t.Errorf("Cambios incorrectos: %+v", changes)
+	}
+
+	// Cambiar el TTL del conjunto actualiza los miembros que se conservan
+	desired, _ = core.NewRRSet("web.test-domain.com", "A", []string{"192.0.2.1", "192.0.2.2"}, 60, false)
+	changes = core.PlanRRSet(current, desired)
+	if len(changes) != 2 || changes[0].Action != core.AuditUpdate || changes[0].Desired.TTL != 60 || changes[0].Current.TTL != 300 {
+		t.Errorf("Se esperaban actualizaciones del TTL: %+v", changes)
+	}
+
+	if changes := core.PlanRRSet(current, nil); len(changes) != 2 || changes[1].Action != core.AuditDelete {
+		t.Errorf("Un conjunto vacío elimina todos los registros: %+v", changes)
+	}
+
+	// De los miembros repetidos solo se conserva el primero
+	duplicated := append(current,
+		&core.DNSRecord{ID: "3", Name: "web.test-domain.com", Type: "A", Content: "192.0.2.2", TTL: 300},
+		&core.DNSRecord{ID: "4", Name: "web.test-domain.com", Type: "A", Content: "192.0.2.2", TTL: 60},
+	)
+	desired, _ = core.NewRRSet("web.test-domain.com", "A", []string{"192.0.2.1", "192.0.2.2"}, 300, false)
+	changes = core.PlanRRSet(duplicated, desired)
+	if len(changes) != 2 ||
+		changes[0].Action != core.AuditDelete || changes[0].Current.ID != "3" ||
+		changes[1].Action != core.AuditDelete || changes[1].Current.ID != "4" {
+		t.Errorf("Se esperaba la eliminación de los duplicados: %+v", changes)
+	}
+}
+
+func TestNewRRSetValidation(t *testing.T) {
+	if _, err := core.NewRRSet("web.test-domain.com", "A", []string{"192.0.2.1", "no-es-ip"}, 1, false); err == nil {
+		t.Error("Se esperaba un error con un contenido inválido")
+	}
+	if _, err := core.NewRRSet("web.test-domain.com", "CNAME", []string{"a.example.net", "b.example.net"}, 1, false); err == nil {
+		t.Error("Se esperaba un error con varios CNAME")
+	}
+}
+
+func TestRemoveRRSetContents(t *testing.T) {
+	records := []*core.DNSRecord{{Type: "A", Content: "192.0.2.1"}, {Type: "A", Content: "192.0.2.2"}, {Type: "AAAA", Content: "2001:db8::1"}}
+	kept, err := core.RemoveRRSetContents(records, []string{"192.0.2.1", "2001:DB8:0::1"})
+	if err != nil || strings.Join(kept, ",") != "192.0.2.2" {
+		t.Errorf("Contenidos incorrectos: %v, %v", kept, err)
+	}
+	if _, err := core.RemoveRRSetContents(records, []string{"192.0.2.9"}); err == nil {
+		t.Error("Se esperaba un error con un contenido que no pertenece al conjunto")
+	}
+}
+
+func TestApplyRRSet(t *testing.T) {
+	server, client := newTestServer(t)
+	server.Add(core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+	server.Add(core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "192.0.2.2", TTL: 1})
+
+	current, err := client.FindDNSRecords("web.test-domain.com", "A")
+	if err != nil {
+		t.Fatalf("Error al buscar: %v", err)
+	}
+	desired, _ := core.NewRRSet("web.test-domain.com", "A", []string{"192.0.2.2", "192.0.2.3", "192.0.2.4"}, 1, false)
+	changes := core.PlanRRSet(current, desired)
+	if err := core.ApplyBulkChanges(client, changes); err != nil {
+		t.Fatalf("Error al aplicar: %v", err)
+	}
+	if calls := batchRequests(server); calls != 1 {
+		t.Errorf("Se esperaba una única operación batch: %d", calls)
+	}
+	for _, change := range changes {
+		if change.Action == core.AuditCreate && change.Desired.ID == "" {
+			t.Errorf("Los registros creados deben recibir su ID: %+v", change.Desired)
+		}
+	}
+
+	records, _ := client.FindDNSRecords("web.test-domain.com", "A")
+	contents := core.RRSetContents(records)
+	sort.Strings(contents)
+	if strings.Join(contents, ",") != "192.0.2.2,192.0.2.3,192.0.2.4" {
+		t.Errorf("Conjunto incorrecto: %v", contents)
+	}
+}
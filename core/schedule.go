@@ -0,0 +1,395 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// scheduleFileName es el archivo del directorio de estado donde se guardan los cambios programados
+const scheduleFileName = "schedule.json"
+
+// DefaultSchedulerInterval es el intervalo por defecto entre revisiones del programador
+const DefaultSchedulerInterval = 30 * time.Second
+
+// DefaultScheduleMaxDelay es el retraso máximo con el que se ejecuta un cambio programado.
+// Un cambio que no se ejecutó a tiempo (por ejemplo porque el programador estaba detenido)
+// se descarta en lugar de aplicarse horas después.
+const DefaultScheduleMaxDelay = time.Hour
+
+// Estados de un cambio programado
+const (
+	SchedulePending   = "pending"
+	ScheduleRunning   = "running"
+	ScheduleDone      = "done"
+	ScheduleFailed    = "failed"
+	ScheduleSkipped   = "skipped"
+	ScheduleCancelled = "cancelled"
+)
+
+// ScheduledChange es un cambio sobre un registro que se aplicará en el momento indicado.
+// Before es el registro tal como estaba al programar el cambio (nil al crear) y After el
+// estado deseado (nil al eliminar).
+type ScheduledChange struct {
+	ID         string     `json:"id"`
+	At         time.Time  `json:"at"`
+	Action     string     `json:"action"`
+	Before     *DNSRecord `json:"before,omitempty"`
+	After      *DNSRecord `json:"after,omitempty"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExecutedAt *time.Time `json:"executed_at,omitempty"`
+	Result     *DNSRecord `json:"result,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// Record devuelve el registro afectado por el cambio
+func (sc ScheduledChange) Record() *DNSRecord {
+	if sc.After != nil {
+		return sc.After
+	}
+	return sc.Before
+}
+
+// Schedule guarda los cambios programados en un archivo local. Cada lectura y modificación
+// se hace con un bloqueo del archivo, de modo que el programador y los comandos schedule
+// pueden usarlo a la vez desde procesos distintos.
+type Schedule struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewSchedule crea una programación guardada en la ruta indicada
+func NewSchedule(path string) *Schedule {
+	return &Schedule{path: path}
+}
+
+// OpenSchedule abre la programación del directorio de estado configurado
+func OpenSchedule(config *Config) (*Schedule, error) {
+	path, err := config.StatePath(scheduleFileName)
+	if err != nil {
+		return nil, err
+	}
+	return NewSchedule(path), nil
+}
+
+// Entries devuelve todos los cambios programados ordenados por fecha de ejecución
+func (s *Schedule) Entries() (entries []ScheduledChange, err error) {
+	err = s.locked(func() error {
+		entries, err = s.load()
+		return err
+	})
+	return entries, err
+}
+
+// Add valida y guarda un cambio pendiente y devuelve el cambio con su identificador
+func (s *Schedule) Add(change ScheduledChange) (ScheduledChange, error) {
+	switch change.Action {
+	case AuditCreate, AuditUpdate, AuditDelete:
+	default:
+		return change, fmt.Errorf("acción desconocida: %s", change.Action)
+	}
+	if change.Action != AuditCreate && (change.Before == nil || change.Before.ID == "") {
+		return change, fmt.Errorf("falta el registro existente")
+	}
+	if change.Action != AuditDelete {
+		if change.After == nil {
+			return change, fmt.Errorf("falta el estado deseado del registro")
+		}
+		if err := ValidateRecord(change.After); err != nil {
+			return change, err
+		}
+	}
+
+	err := s.locked(func() error {
+		entries, err := s.load()
+		if err != nil {
+			return err
+		}
+		next := 1
+		for _, entry := range entries {
+			if id, err := strconv.Atoi(entry.ID); err == nil && id >= next {
+				next = id + 1
+			}
+		}
+		change.ID = strconv.Itoa(next)
+		change.Status = SchedulePending
+		change.CreatedAt = time.Now().UTC()
+		change.Before = copyRecord(change.Before)
+		change.After = copyRecord(change.After)
+
+		return s.save(append(entries, change))
+	})
+	return change, err
+}
+
+// Cancel marca como cancelado un cambio pendiente
+func (s *Schedule) Cancel(id string) error {
+	return s.update(id, func(entry *ScheduledChange) error {
+		if entry.Status != SchedulePending {
+			return fmt.Errorf("el cambio %s no está pendiente (%s)", id, entry.Status)
+		}
+		entry.Status = ScheduleCancelled
+		return nil
+	})
+}
+
+// Due devuelve los cambios pendientes cuya hora de ejecución es anterior o igual a now
+func (s *Schedule) Due(now time.Time) ([]ScheduledChange, error) {
+	entries, err := s.Entries()
+	if err != nil {
+		return nil, err
+	}
+	var due []ScheduledChange
+	for _, entry := range entries {
+		if entry.Status == SchedulePending && !entry.At.After(now) {
+			due = append(due, entry)
+		}
+	}
+	return due, nil
+}
+
+// Claim marca como en curso un cambio pendiente antes de ejecutarlo y lo devuelve tal como
+// está guardado. Devuelve false si ya no está pendiente, por ejemplo porque se canceló
+// después de Due.
+func (s *Schedule) Claim(id string) (claimed ScheduledChange, ok bool, err error) {
+	err = s.update(id, func(entry *ScheduledChange) error {
+		if entry.Status != SchedulePending {
+			return nil
+		}
+		entry.Status = ScheduleRunning
+		claimed, ok = *entry, true
+		return nil
+	})
+	return claimed, ok, err
+}
+
+// Complete guarda el resultado de la ejecución de un cambio reclamado con Claim
+func (s *Schedule) Complete(id, status string, result *DNSRecord, execErr error) error {
+	return s.update(id, func(entry *ScheduledChange) error {
+		if entry.Status != ScheduleRunning {
+			return fmt.Errorf("el cambio %s no está en curso (%s)", id, entry.Status)
+		}
+		now := time.Now().UTC()
+		entry.Status = status
+		entry.ExecutedAt = &now
+		entry.Result = copyRecord(result)
+		entry.Error = ""
+		if execErr != nil {
+			entry.Error = execErr.Error()
+		}
+		return nil
+	})
+}
+
+// update modifica un cambio guardado
+func (s *Schedule) update(id string, modify func(entry *ScheduledChange) error) error {
+	return s.locked(func() error {
+		entries, err := s.load()
+		if err != nil {
+			return err
+		}
+		for i := range entries {
+			if entries[i].ID == id {
+				if err := modify(&entries[i]); err != nil {
+					return err
+				}
+				return s.save(entries)
+			}
+		}
+		return fmt.Errorf("no existe el cambio programado %s", id)
+	})
+}
+
+// locked ejecuta fn con el bloqueo del proceso y el del archivo
+func (s *Schedule) locked(fn func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := lockFile(s.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return fn()
+}
+
+// load lee los cambios del archivo
+func (s *Schedule) load() ([]ScheduledChange, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []ScheduledChange
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("archivo de cambios programados inválido: %v", err)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].At.Before(entries[j].At) })
+	return entries, nil
+}
+
+// save escribe los cambios de forma atómica
+func (s *Schedule) save(entries []ScheduledChange) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".schedule-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// ExecuteScheduled aplica un cambio programado después de comprobar que el registro no cambió
+// desde que se programó. Si cambió devuelve ErrRecordDiverged sin aplicar nada. Devuelve el
+// registro resultante (nil al eliminar).
+func ExecuteScheduled(provider DNSProvider, change ScheduledChange, zone string) (*DNSRecord, error) {
+	switch change.Action {
+	case AuditCreate:
+		// Al crear, la zona no debe tener ya un registro en conflicto con el nuevo
+		conflicts, err := CheckConflicts(provider, change.After, zone)
+		if err != nil {
+			return nil, err
+		}
+		if len(conflicts) > 0 {
+			return nil, fmt.Errorf("%s: %s: %w", conflicts[0].Record.Name, conflicts[0].Description(), ErrRecordDiverged)
+		}
+		record := copyRecord(change.After)
+		record.ID = ""
+		if err := provider.CreateDNSRecord(record); err != nil {
+			return nil, err
+		}
+		return record, nil
+
+	case AuditUpdate, AuditDelete:
+		live, err := provider.GetDNSRecord(change.Before.ID)
+		if err != nil {
+			return nil, fmt.Errorf("no se pudo obtener el registro: %w", err)
+		}
+		if !sameRecordData(live, change.Before) {
+			return nil, fmt.Errorf("%s: %w", live.Name, ErrRecordDiverged)
+		}
+		if change.Action == AuditDelete {
+			return nil, provider.DeleteDNSRecord(live.ID)
+		}
+		record := copyRecord(change.After)
+		record.ID = live.ID
+		if err := provider.UpdateDNSRecord(record.ID, record); err != nil {
+			return nil, err
+		}
+		return record, nil
+	}
+	return nil, fmt.Errorf("acción desconocida: %s", change.Action)
+}
+
+// RunScheduled ejecuta en orden los cambios pendientes cuya hora ha llegado y guarda su
+// resultado: done si se aplicó, skipped si el registro cambió desde que se programó o se
+// superó maxDelay, y failed si la API devolvió un error. Cada cambio se reclama con Claim
+// antes de ejecutarlo, así que los cancelados mientras tanto no se aplican. Devuelve los
+// cambios procesados.
+func RunScheduled(provider DNSProvider, schedule *Schedule, zone string, now time.Time, maxDelay time.Duration) ([]ScheduledChange, error) {
+	due, err := schedule.Due(now)
+	if err != nil {
+		return nil, err
+	}
+
+	var processed []ScheduledChange
+	for _, change := range due {
+		change, ok, err := schedule.Claim(change.ID)
+		if err != nil {
+			return processed, err
+		}
+		if !ok {
+			continue
+		}
+
+		var result *DNSRecord
+		status := ScheduleDone
+		if maxDelay > 0 && now.Sub(change.At) > maxDelay {
+			status = ScheduleSkipped
+			err = fmt.Errorf("no se ejecutó a tiempo (retraso máximo %s)", maxDelay)
+		} else {
+			result, err = ExecuteScheduled(provider, change, zone)
+			switch {
+			case errors.Is(err, ErrRecordDiverged):
+				status = ScheduleSkipped
+			case err != nil:
+				status = ScheduleFailed
+			}
+		}
+
+		RecordReconcile("scheduler", err)
+		record := change.Record()
+		logger.Info("cambio programado procesado", "id", change.ID, "action", change.Action, "name", record.Name, "type", record.Type, "status", status, "error", err)
+		if saveErr := schedule.Complete(change.ID, status, result, err); saveErr != nil {
+			return processed, saveErr
+		}
+		change.Status = status
+		change.Result = result
+		change.Error = ""
+		if err != nil {
+			change.Error = err.Error()
+		}
+		processed = append(processed, change)
+	}
+	return processed, nil
+}
+
+// scheduleLayouts son los formatos de fecha admitidos por ParseScheduleTime
+var scheduleLayouts = []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04:05", "2006-01-02T15:04"}
+
+// ParseScheduleTime interpreta la hora de un cambio programado en la zona horaria local:
+// una fecha RFC 3339, "2006-01-02 15:04", una hora "02:00" (la próxima vez que llegue esa
+// hora) o una duración desde ahora como "+90m".
+func ParseScheduleTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if duration, ok := strings.CutPrefix(value, "+"); ok {
+		d, err := time.ParseDuration(duration)
+		if err != nil || d <= 0 {
+			return time.Time{}, fmt.Errorf("duración inválida %q", value)
+		}
+		return now.Add(d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range scheduleLayouts {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		clock, err := time.ParseInLocation(layout, value, now.Location())
+		if err != nil {
+			continue
+		}
+		t := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, now.Location())
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("hora inválida %q: usa \"2006-01-02 15:04\", \"15:04\" o \"+90m\"", value)
+}
@@ -0,0 +1,229 @@
+package core_test
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"cloudflare-domain-controller/core"
+)
+
+func TestScheduleAddCancel(t *testing.T) {
+	schedule := core.NewSchedule(filepath.Join(t.TempDir(), "schedule.json"))
+	at := time.Date(2026, 10, 19, 2, 0, 0, 0, time.UTC)
+	record := &core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1}
+
+	first, err := schedule.Add(core.ScheduledChange{At: at.Add(time.Hour), Action: core.AuditCreate, After: record})
+	if err != nil {
+		t.Fatalf("Error al programar: %v", err)
+	}
+	second, err := schedule.Add(core.ScheduledChange{At: at, Action: core.AuditCreate, After: record})
+	if err != nil {
+		t.Fatalf("Error al programar: %v", err)
+	}
+	if first.ID != "1" || second.ID != "2" || first.Status != core.SchedulePending {
+		t.Errorf("Identificadores o estado incorrectos: %+v, %+v", first, second)
+	}
+
+	// Los cambios se devuelven por orden de ejecución
+	entries, err := schedule.Entries()
+	if err != nil || len(entries) != 2 || entries[0].ID != "2" {
+		t.Fatalf("Cambios incorrectos: %+v, %v", entries, err)
+	}
+
+	if err := schedule.Cancel("2"); err != nil {
+		t.Fatalf("Error al cancelar: %v", err)
+	}
+	if err := schedule.Cancel("2"); err == nil {
+		t.Error("Solo se pueden cancelar cambios pendientes")
+	}
+	if due, _ := schedule.Due(at.Add(2 * time.Hour)); len(due) != 1 || due[0].ID != "1" {
+		t.Errorf("Cambios pendientes incorrectos: %+v", due)
+	}
+
+	invalid := []core.ScheduledChange{
+		{At: at, Action: core.AuditCreate, After: &core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "no-es-ip", TTL: 1}},
+		{At: at, Action: core.AuditUpdate, After: record},
+		{At: at, Action: core.AuditDelete},
+		{At: at, Action: "rename", After: record},
+	}
+	for _, change := range invalid {
+		if _, err := schedule.Add(change); err == nil {
+			t.Errorf("Se esperaba un error para %+v", change)
+		}
+	}
+}
+
+func TestScheduleClaim(t *testing.T) {
+	schedule := core.NewSchedule(filepath.Join(t.TempDir(), "schedule.json"))
+	at := time.Date(2026, 10, 19, 2, 0, 0, 0, time.UTC)
+	record := &core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1}
+	for i := 0; i < 2; i++ {
+		if _, err := schedule.Add(core.ScheduledChange{At: at, Action: core.AuditCreate, After: record}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Un cambio cancelado después de Due no se puede reclamar ni completar
+	if err := schedule.Cancel("1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := schedule.Claim("1"); ok || err != nil {
+		t.Errorf("No se debía reclamar un cambio cancelado: %v, %v", ok, err)
+	}
+	if err := schedule.Complete("1", core.ScheduleDone, nil, nil); err == nil {
+		t.Error("Complete no debe sobrescribir un cambio cancelado")
+	}
+
+	claimed, ok, err := schedule.Claim("2")
+	if !ok || err != nil || claimed.Status != core.ScheduleRunning {
+		t.Fatalf("Error al reclamar: %+v, %v, %v", claimed, ok, err)
+	}
+	if _, ok, _ := schedule.Claim("2"); ok {
+		t.Error("Un cambio en curso no se puede reclamar dos veces")
+	}
+	if err := schedule.Cancel("2"); err == nil {
+		t.Error("Un cambio en curso no se puede cancelar")
+	}
+	if err := schedule.Complete("2", core.ScheduleDone, nil, nil); err != nil {
+		t.Errorf("Error al completar: %v", err)
+	}
+	entries, _ := schedule.Entries()
+	if entries[0].Status != core.ScheduleCancelled || entries[1].Status != core.ScheduleDone {
+		t.Errorf("Estados incorrectos: %+v", entries)
+	}
+}
+
+func TestScheduleConcurrentAdd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedule.json")
+	record := &core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1}
+
+	// Cada Schedule tiene su propio mutex, como los procesos distintos: solo el bloqueo
+	// del archivo evita que se pierdan cambios
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := core.NewSchedule(path).Add(core.ScheduledChange{At: time.Now(), Action: core.AuditCreate, After: record}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	entries, err := core.NewSchedule(path).Entries()
+	ids := map[string]bool{}
+	for _, entry := range entries {
+		ids[entry.ID] = true
+	}
+	if err != nil || len(entries) != 20 || len(ids) != 20 {
+		t.Errorf("Se esperaban 20 cambios con identificadores distintos: %d, %d, %v", len(entries), len(ids), err)
+	}
+}
+
+func TestRunScheduled(t *testing.T) {
+	server, client := newTestServer(t)
+	schedule := core.NewSchedule(filepath.Join(t.TempDir(), "schedule.json"))
+	now := time.Date(2026, 10, 19, 2, 0, 30, 0, time.UTC)
+
+	web := &core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "203.0.113.4", TTL: 1}
+	web.ID = server.Add(*web)
+	changed := &core.DNSRecord{Name: "api.test-domain.com", Type: "A", Content: "203.0.113.4", TTL: 1}
+	changed.ID = server.Add(*changed)
+
+	updated := *web
+	updated.Content = "198.51.100.7"
+	apiUpdated := *changed
+	apiUpdated.Content = "198.51.100.7"
+	changes := []core.ScheduledChange{
+		{At: now.Add(-30 * time.Second), Action: core.AuditUpdate, Before: web, After: &updated},
+		{At: now.Add(-20 * time.Second), Action: core.AuditUpdate, Before: changed, After: &apiUpdated},
+		{At: now.Add(-10 * time.Second), Action: core.AuditCreate, After: &core.DNSRecord{Name: "nuevo.test-domain.com", Type: "A", Content: "198.51.100.7", TTL: 1}},
+		{At: now.Add(-2 * time.Hour), Action: core.AuditDelete, Before: web},
+		{At: now.Add(time.Hour), Action: core.AuditDelete, Before: web},
+	}
+	for _, change := range changes {
+		if _, err := schedule.Add(change); err != nil {
+			t.Fatalf("Error al programar: %v", err)
+		}
+	}
+
+	// El registro de api cambia después de programar su actualización
+	modified := *changed
+	modified.Content = "203.0.113.9"
+	if err := server.UpdateDNSRecord(changed.ID, &modified); err != nil {
+		t.Fatal(err)
+	}
+
+	processed, err := core.RunScheduled(client, schedule, "test-domain.com", now, time.Hour)
+	if err != nil {
+		t.Fatalf("Error al ejecutar: %v", err)
+	}
+	statuses := map[string]string{}
+	for _, change := range processed {
+		statuses[change.ID] = change.Status
+	}
+	want := map[string]string{"1": core.ScheduleDone, "2": core.ScheduleSkipped, "3": core.ScheduleDone, "4": core.ScheduleSkipped}
+	if len(statuses) != len(want) {
+		t.Fatalf("Cambios procesados incorrectos: %+v", processed)
+	}
+	for id, status := range want {
+		if statuses[id] != status {
+			t.Errorf("Estado del cambio %s: esperado %s, obtenido %s", id, status, statuses[id])
+		}
+	}
+
+	if got := server.Record(web.ID); got == nil || got.Content != "198.51.100.7" {
+		t.Errorf("El registro no se actualizó: %+v", got)
+	}
+	if got := server.Record(changed.ID); got == nil || got.Content != "203.0.113.9" {
+		t.Errorf("No se debía modificar un registro que cambió: %+v", got)
+	}
+	if count := len(server.Records()); count != 3 {
+		t.Errorf("Se esperaba el registro creado: %d registros", count)
+	}
+
+	// Los resultados se guardan y el cambio futuro sigue pendiente
+	entries, _ := schedule.Entries()
+	for _, entry := range entries {
+		if entry.ID == "5" && entry.Status != core.SchedulePending {
+			t.Errorf("El cambio futuro debía seguir pendiente: %+v", entry)
+		}
+		if entry.ID == "2" && entry.ExecutedAt == nil {
+			t.Errorf("No se guardó el resultado: %+v", entry)
+		}
+	}
+	if _, err := core.ExecuteScheduled(client, changes[1], "test-domain.com"); !errors.Is(err, core.ErrRecordDiverged) {
+		t.Errorf("Se esperaba ErrRecordDiverged, obtenido %v", err)
+	}
+}
+
+func TestParseScheduleTime(t *testing.T) {
+	loc := time.FixedZone("CEST", 2*60*60)
+	now := time.Date(2026, 10, 18, 23, 30, 0, 0, loc)
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"02:00", time.Date(2026, 10, 19, 2, 0, 0, 0, loc)},
+		{"23:45", time.Date(2026, 10, 18, 23, 45, 0, 0, loc)},
+		{"2026-10-20 02:00", time.Date(2026, 10, 20, 2, 0, 0, 0, loc)},
+		{"2026-10-20T02:00:00Z", time.Date(2026, 10, 20, 2, 0, 0, 0, time.UTC)},
+		{"+90m", now.Add(90 * time.Minute)},
+	}
+	for _, tt := range tests {
+		got, err := core.ParseScheduleTime(tt.value, now)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("ParseScheduleTime(%q): esperado %v, obtenido %v (%v)", tt.value, tt.want, got, err)
+		}
+	}
+	for _, value := range []string{"mañana", "+0s", "25:00"} {
+		if _, err := core.ParseScheduleTime(value, now); err == nil {
+			t.Errorf("Se esperaba un error para %q", value)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package core
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// RecordSelector selecciona registros por nombre (patrones glob o expresión regular),
+// tipo y contenido. Los criterios vacíos no filtran.
+type RecordSelector struct {
+	// Globs son patrones glob sobre el nombre completo, ya resueltos dentro de la zona
+	Globs []string
+	// Regex se aplica al nombre relativo a la zona, tal como lo muestra 'list' ("@" es el ápice)
+	Regex   *regexp.Regexp
+	Type    string
+	Content string
+
+	zone string
+}
+
+// NewRecordSelector crea un selector. Los patrones glob se resuelven como nombres de la zona:
+// "@" es el ápice, "preview-*" es preview-*.<dominio> y "*.dev" todos los nombres bajo dev.<dominio>.
+func (c *Config) NewRecordSelector(globs []string, regex, recordType, content string) (*RecordSelector, error) {
+	selector := &RecordSelector{Type: strings.ToUpper(recordType), Content: content, zone: c.DomainName}
+
+	for _, glob := range globs {
+		name, err := c.ResolveName(glob)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := path.Match(name, ""); err != nil {
+			return nil, fmt.Errorf("patrón inválido %q: %w", glob, err)
+		}
+		selector.Globs = append(selector.Globs, name)
+	}
+
+	if regex != "" {
+		re, err := regexp.Compile(regex)
+		if err != nil {
+			return nil, fmt.Errorf("expresión regular inválida %q: %w", regex, err)
+		}
+		selector.Regex = re
+	}
+	return selector, nil
+}
+
+// Empty indica si el selector no tiene ningún criterio y, por tanto, selecciona todos los registros
+func (s *RecordSelector) Empty() bool {
+	return len(s.Globs) == 0 && s.Regex == nil && s.Type == "" && s.Content == ""
+}
+
+// Matches indica si el registro cumple todos los criterios del selector
+func (s *RecordSelector) Matches(record *DNSRecord) bool {
+	if s.Type != "" && !strings.EqualFold(record.Type, s.Type) {
+		return false
+	}
+	if s.Content != "" && !sameContent(record.Type, record.Content, s.Content) {
+		return false
+	}
+
+	name := normalizeHostname(record.Name)
+	if len(s.Globs) > 0 {
+		matched := false
+		for _, glob := range s.Globs {
+			if ok, _ := path.Match(glob, name); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if s.Regex != nil && !s.Regex.MatchString(RelativeName(name, s.zone)) {
+		return false
+	}
+	return true
+}
+
+// Filter devuelve los registros que cumplen los criterios del selector
+func (s *RecordSelector) Filter(records []*DNSRecord) []*DNSRecord {
+	var matches []*DNSRecord
+	for _, record := range records {
+		if s.Matches(record) {
+			matches = append(matches, record)
+		}
+	}
+	return matches
+}
+
+// PlanBulkUpdate calcula la actualización del contenido de todos los registros seleccionados.
+// Los registros que ya tienen ese contenido se omiten.
+func PlanBulkUpdate(records []*DNSRecord, content string) ([]RecordChange, error) {
+	var changes []RecordChange
+	for _, record := range records {
+		normalized, err := NormalizeContent(record.Type, content)
+		if err != nil {
+			return nil, err
+		}
+		if record.Type == "TXT" {
+			normalized = ChunkTXT(normalized)
+		}
+		if record.Content == normalized {
+			continue
+		}
+		desired := copyRecord(record)
+		desired.Content = normalized
+		if err := ValidateRecord(desired); err != nil {
+			return nil, fmt.Errorf("%s %s: %w", record.Name, record.Type, err)
+		}
+		changes = append(changes, RecordChange{Action: AuditUpdate, Current: record, Desired: desired})
+	}
+	return changes, nil
+}
+
+// ApplyBulkChanges aplica en una única operación batch las eliminaciones, actualizaciones y
+// creaciones indicadas. Los registros deseados se sustituyen por los que devuelve el batch,
+// con el ID asignado y los datos tal como los guardó Cloudflare.
+func ApplyBulkChanges(provider DNSProvider, changes []RecordChange) error {
+	ops := &BatchOperations{}
+	for _, change := range changes {
+		switch change.Action {
+		case AuditDelete:
+			ops.Deletes = append(ops.Deletes, change.Current)
+		case AuditUpdate:
+			ops.Patches = append(ops.Patches, change.Desired)
+		case AuditCreate:
+			ops.Posts = append(ops.Posts, change.Desired)
+		}
+	}
+	result, err := provider.BatchDNSRecords(ops)
+	if err != nil {
+		return err
+	}
+	fillBatchRecords(ops.Patches, result.Patches)
+	fillBatchRecords(ops.Posts, result.Posts)
+	return nil
+}
+
+// fillBatchRecords copia en cada registro enviado el registro devuelto en la misma posición
+func fillBatchRecords(sent, returned []*DNSRecord) {
+	if len(sent) != len(returned) {
+		return
+	}
+	for i, record := range sent {
+		if returned[i] != nil {
+			*record = *returned[i]
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package core_test
+
+import (
+	"strings"
+	"testing"
+
+	"cloudflare-domain-controller/core"
+)
+
+func TestRecordSelector(t *testing.T) {
+	config := &core.Config{DomainName: "test-domain.com"}
+	records := []*core.DNSRecord{
+		{ID: "1", Name: "test-domain.com", Type: "A", Content: "10.0.0.5"},
+		{ID: "2", Name: "www.test-domain.com", Type: "CNAME", Content: "test-domain.com"},
+		{ID: "3", Name: "preview-101.test-domain.com", Type: "A", Content: "10.0.0.5"},
+		{ID: "4", Name: "preview-102.test-domain.com", Type: "AAAA", Content: "2001:db8::5"},
+		{ID: "5", Name: "preview-abc.test-domain.com", Type: "A", Content: "10.0.0.6"},
+		{ID: "6", Name: "api.dev.test-domain.com", Type: "A", Content: "10.0.0.5"},
+		{ID: "7", Name: "test-domain.com", Type: "MX", Content: "mx1.example.net"},
+	}
+
+	tests := []struct {
+		name       string
+		globs      []string
+		regex      string
+		recordType string
+		content    string
+		want       string
+	}{
+		{"sin criterios", nil, "", "", "", "1,2,3,4,5,6,7"},
+		{"glob", []string{"preview-*"}, "", "", "", "3,4,5"},
+		{"glob y tipo", []string{"preview-*"}, "", "a", "", "3,5"},
+		{"varios globs", []string{"www", "api.dev"}, "", "", "", "2,6"},
+		{"ápice", []string{"@"}, "", "", "", "1,7"},
+		{"comodín de subdominio", []string{"*.dev"}, "", "", "", "6"},
+		{"todos los subdominios", []string{"*"}, "", "", "", "2,3,4,5,6"},
+		{"regex", nil, `^preview-\d+$`, "", "", "3,4"},
+		{"regex del ápice", nil, `^@$`, "", "", "1,7"},
+		{"contenido", nil, "", "", "10.0.0.5", "1,3,6"},
+		{"contenido y regex", nil, `^preview-`, "", "10.0.0.5", "3"},
+		{"glob y regex", []string{"preview-*"}, `abc`, "", "", "5"},
+		{"sin coincidencias", []string{"falta"}, "", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector, err := config.NewRecordSelector(tt.globs, tt.regex, tt.recordType, tt.content)
+			if err != nil {
+				t.Fatalf("Error al crear el selector: %v", err)
+			}
+			var ids []string
+			for _, record := range selector.Filter(records) {
+				ids = append(ids, record.ID)
+			}
+			if got := strings.Join(ids, ","); got != tt.want {
+				t.Errorf("Selección incorrecta: esperado %q, obtenido %q", tt.want, got)
+			}
+			if empty := tt.globs == nil && tt.regex == "" && tt.recordType == "" && tt.content == ""; selector.Empty() != empty {
+				t.Errorf("Empty() = %v, esperado %v", selector.Empty(), empty)
+			}
+		})
+	}
+
+	if _, err := config.NewRecordSelector([]string{"[www"}, "", "", ""); err == nil {
+		t.Error("Se esperaba un error con un patrón glob inválido")
+	}
+	if _, err := config.NewRecordSelector(nil, "(", "", ""); err == nil {
+		t.Error("Se esperaba un error con una expresión regular inválida")
+	}
+}
+
+func TestPlanBulkUpdate(t *testing.T) {
+	records := []*core.DNSRecord{
+		{ID: "1", Name: "a.test-domain.com", Type: "A", Content: "10.0.0.5", TTL: 1},
+		{ID: "2", Name: "b.test-domain.com", Type: "A", Content: "10.0.0.6", TTL: 1},
+	}
+	changes, err := core.PlanBulkUpdate(records, "10.0.0.6")
+	if err != nil {
+		t.Fatalf("Error al planificar: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Desired.ID != "1" || changes[0].Desired.Content != "10.0.0.6" || records[0].Content != "10.0.0.5" {
+		t.Errorf("Cambios incorrectos: %+v", changes)
+	}
+
+	if _, err := core.PlanBulkUpdate(records, "no-es-una-ip"); err == nil {
+		t.Error("Se esperaba un error de validación")
+	}
+}
+
+func TestApplyBulkChanges(t *testing.T) {
+	server, client := newTestServer(t)
+	oldID := server.Add(core.DNSRecord{Name: "preview-1.test-domain.com", Type: "A", Content: "10.0.0.5", TTL: 1})
+	webID := server.Add(core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "10.0.0.5", TTL: 1})
+	old := server.Record(oldID)
+
+	updates, err := core.PlanBulkUpdate([]*core.DNSRecord{server.Record(webID)}, "10.0.0.9")
+	if err != nil {
+		t.Fatalf("Error al planificar: %v", err)
+	}
+	created := &core.DNSRecord{Name: "nuevo.test-domain.com", Type: "a", Content: "10.0.0.9", TTL: 1}
+	changes := append([]core.RecordChange{{Action: core.AuditDelete, Current: old}}, updates...)
+	changes = append(changes, core.RecordChange{Action: core.AuditCreate, Desired: created})
+	if err := core.ApplyBulkChanges(client, changes); err != nil {
+		t.Fatalf("Error al aplicar: %v", err)
+	}
+	// Los registros deseados pasan a ser los devueltos por el batch
+	if created.ID == "" || created.Type != "A" {
+		t.Errorf("El registro creado no se actualizó con la respuesta: %+v", created)
+	}
+	if server.Record(oldID) != nil {
+		t.Error("El registro no se eliminó")
+	}
+	if updated := server.Record(webID); updated == nil || updated.Content != "10.0.0.9" {
+		t.Errorf("El registro no se actualizó: %+v", updated)
+	}
+	if calls := batchRequests(server); calls != 1 {
+		t.Errorf("Se esperaba una única operación batch, obtenidas %d", calls)
+	}
+}
@@ -0,0 +1,51 @@
+package core_test
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"cloudflare-domain-controller/core"
+	"cloudflare-domain-controller/core/coretest"
+)
+
+// newTestServer publica una zona test-domain.com en memoria y crea un cliente real que la usa
+func newTestServer(t *testing.T) (*coretest.Server, *core.CloudflareClient) {
+	t.Helper()
+	server := coretest.NewServer(t, "test-domain.com")
+	return server, server.NewClient(server.Config())
+}
+
+// batchRequests cuenta las solicitudes recibidas por el endpoint batch
+func batchRequests(server *coretest.Server) int {
+	count := 0
+	for _, request := range server.Requests() {
+		if strings.HasSuffix(request.Path, "/dns_records/batch") {
+			count++
+		}
+	}
+	return count
+}
+
+// metricValue devuelve el valor de una serie publicada por core.MetricsHandler, o 0 si aún no existe
+func metricValue(t *testing.T, series string) float64 {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	core.MetricsHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	scanner := bufio.NewScanner(recorder.Body)
+	for scanner.Scan() {
+		value, found := strings.CutPrefix(scanner.Text(), series+" ")
+		if !found {
+			continue
+		}
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			t.Fatalf("Valor inválido para %s: %q", series, value)
+		}
+		return parsed
+	}
+	return 0
+}
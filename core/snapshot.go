@@ -0,0 +1,277 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// snapshotDirName es el subdirectorio del estado local donde se guardan las instantáneas
+const snapshotDirName = "snapshots"
+
+// Snapshot representa una copia completa de los registros de la zona en un momento dado
+type Snapshot struct {
+	Name       string       `json:"name"`
+	CreatedAt  time.Time    `json:"created_at"`
+	ZoneID     string       `json:"zone_id"`
+	DomainName string       `json:"domain_name"`
+	Records    []*DNSRecord `json:"records"`
+}
+
+// NewSnapshot crea una instantánea con los registros indicados.
+// Si no se proporciona un nombre se usa la fecha actual.
+func NewSnapshot(name string, config *Config, records []*DNSRecord) *Snapshot {
+	createdAt := time.Now().UTC()
+	if name == "" {
+		name = createdAt.Format("20060102-150405")
+	}
+	return &Snapshot{
+		Name:       name,
+		CreatedAt:  createdAt,
+		ZoneID:     config.ZoneID,
+		DomainName: config.DomainName,
+		Records:    records,
+	}
+}
+
+// SnapshotDir devuelve el directorio de instantáneas, creándolo si es necesario
+func (c *Config) SnapshotDir() (string, error) {
+	dir, err := c.StatePath(snapshotDirName)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// SaveSnapshot guarda la instantánea en el directorio sin sobrescribir una existente
+func SaveSnapshot(dir string, snapshot *Snapshot) (string, error) {
+	if strings.ContainsAny(snapshot.Name, `/\`) || strings.HasPrefix(snapshot.Name, ".") {
+		return "", fmt.Errorf("nombre de instantánea inválido: %s", snapshot.Name)
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, snapshot.Name+".json")
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if os.IsExist(err) {
+		return "", fmt.Errorf("ya existe una instantánea llamada %s", snapshot.Name)
+	}
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// LoadSnapshot carga una instantánea por su nombre dentro del directorio o por su ruta
+func LoadSnapshot(dir, name string) (*Snapshot, error) {
+	path := name
+	if !strings.ContainsAny(name, `/\`) {
+		path = filepath.Join(dir, strings.TrimSuffix(name, ".json")+".json")
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no se encontró la instantánea %s", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("instantánea %s inválida: %v", name, err)
+	}
+	return &snapshot, nil
+}
+
+// ListSnapshots devuelve las instantáneas del directorio ordenadas por fecha de creación
+func ListSnapshots(dir string) ([]*Snapshot, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]*Snapshot, 0, len(paths))
+	for _, path := range paths {
+		snapshot, err := LoadSnapshot(dir, path)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt)
+	})
+	return snapshots, nil
+}
+
+// RecordChange representa una operación necesaria para llevar un registro a su estado deseado.
+// Current es el registro existente (nil al crear) y Desired el estado final (nil al eliminar).
+type RecordChange struct {
+	Action  string
+	Current *DNSRecord
+	Desired *DNSRecord
+}
+
+// Record devuelve el registro que identifica el cambio
+func (rc RecordChange) Record() *DNSRecord {
+	if rc.Desired != nil {
+		return rc.Desired
+	}
+	return rc.Current
+}
+
+// DiffRecords calcula el conjunto mínimo de cambios para pasar de current a desired.
+// Los registros se emparejan primero por ID y después por nombre y tipo, de modo que
+// un registro modificado genera una actualización en lugar de una eliminación y una creación.
+// Los cambios se devuelven en orden de aplicación: eliminaciones, actualizaciones y creaciones.
+func DiffRecords(current, desired []*DNSRecord) []RecordChange {
+	var deletes, updates, creates []RecordChange
+
+	pendingCurrent := make(map[string]*DNSRecord)
+	var currentOrder []*DNSRecord
+	for _, record := range current {
+		currentOrder = append(currentOrder, record)
+		if record.ID != "" {
+			pendingCurrent[record.ID] = record
+		}
+	}
+	matched := make(map[*DNSRecord]bool)
+
+	// Emparejar por ID
+	var unmatchedDesired []*DNSRecord
+	for _, want := range desired {
+		have, ok := pendingCurrent[want.ID]
+		if want.ID == "" || !ok || matched[have] {
+			unmatchedDesired = append(unmatchedDesired, want)
+			continue
+		}
+		matched[have] = true
+		if !sameRecordData(have, want) {
+			updates = append(updates, RecordChange{Action: AuditUpdate, Current: have, Desired: want})
+		}
+	}
+
+	// Emparejar por nombre y tipo, dando prioridad a los registros idénticos
+	for _, exact := range []bool{true, false} {
+		var remaining []*DNSRecord
+		for _, want := range unmatchedDesired {
+			var found *DNSRecord
+			for _, have := range currentOrder {
+				if matched[have] || !sameRecordKey(have, want) {
+					continue
+				}
+				if !exact || sameRecordData(have, want) {
+					found = have
+					break
+				}
+			}
+			if found == nil {
+				remaining = append(remaining, want)
+				continue
+			}
+			matched[found] = true
+			if !exact {
+				updates = append(updates, RecordChange{Action: AuditUpdate, Current: found, Desired: want})
+			}
+		}
+		unmatchedDesired = remaining
+	}
+
+	for _, want := range unmatchedDesired {
+		creates = append(creates, RecordChange{Action: AuditCreate, Desired: want})
+	}
+	for _, have := range currentOrder {
+		if !matched[have] {
+			deletes = append(deletes, RecordChange{Action: AuditDelete, Current: have})
+		}
+	}
+
+	changes := append(deletes, updates...)
+	return append(changes, creates...)
+}
+
+// sameRecordKey indica si dos registros tienen el mismo nombre y tipo
+func sameRecordKey(a, b *DNSRecord) bool {
+	return strings.EqualFold(a.Name, b.Name) && strings.EqualFold(a.Type, b.Type)
+}
+
+// sameRecordData indica si dos registros tienen los mismos datos, sin tener en cuenta el ID
+func sameRecordData(a, b *DNSRecord) bool {
+	return sameRecordKey(a, b) &&
+		a.Content == b.Content &&
+		a.TTL == b.TTL &&
+		a.Proxied == b.Proxied &&
+		samePriority(a.Priority, b.Priority) &&
+		a.Comment == b.Comment &&
+		sameTags(a.Tags, b.Tags)
+}
+
+// samePriority compara dos prioridades opcionales
+func samePriority(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// sameTags compara dos listas de etiquetas sin tener en cuenta el orden
+func sameTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sortedA := append([]string(nil), a...)
+	sortedB := append([]string(nil), b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+	for i := range sortedA {
+		if sortedA[i] != sortedB[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ApplyRecordChanges aplica los cambios en el orden indicado y se detiene en el primer error.
+// Las actualizaciones sobrescriben el registro completo (PUT) para que también se quiten el
+// comentario, las etiquetas y la prioridad que no tiene el estado deseado.
+func ApplyRecordChanges(provider DNSProvider, changes []RecordChange) error {
+	for _, change := range changes {
+		var err error
+		switch change.Action {
+		case AuditCreate:
+			record := *change.Desired
+			record.ID = ""
+			err = provider.CreateDNSRecord(&record)
+		case AuditUpdate:
+			record := *change.Desired
+			record.ID = change.Current.ID
+			err = provider.ReplaceDNSRecord(record.ID, &record)
+		case AuditDelete:
+			err = provider.DeleteDNSRecord(change.Current.ID)
+		default:
+			err = fmt.Errorf("acción desconocida: %s", change.Action)
+		}
+		if err != nil {
+			record := change.Record()
+			return fmt.Errorf("error al aplicar %s de %s %s: %w", change.Action, record.Name, record.Type, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package core_test
+
+import (
+	"fmt"
+	"testing"
+
+	"cloudflare-domain-controller/core"
+	"cloudflare-domain-controller/core/coretest"
+)
+
+func TestDiffRecords(t *testing.T) {
+	current := []*core.DNSRecord{
+		{ID: "1", Name: "www.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1},
+		{ID: "2", Name: "api.test-domain.com", Type: "A", Content: "192.0.2.2", TTL: 1},
+		{ID: "3", Name: "old.test-domain.com", Type: "CNAME", Content: "www.test-domain.com", TTL: 1},
+		{ID: "4", Name: "rr.test-domain.com", Type: "A", Content: "192.0.2.10", TTL: 1},
+		{ID: "5", Name: "rr.test-domain.com", Type: "A", Content: "192.0.2.11", TTL: 1},
+	}
+	desired := []*core.DNSRecord{
+		// Sin cambios
+		{ID: "1", Name: "www.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1},
+		// Mismo ID con contenido distinto
+		{ID: "2", Name: "api.test-domain.com", Type: "A", Content: "192.0.2.20", TTL: 1},
+		// Recreado con otro ID: se empareja por nombre y tipo con el registro idéntico
+		{ID: "99", Name: "rr.test-domain.com", Type: "A", Content: "192.0.2.11", TTL: 1},
+		// Nuevo
+		{ID: "100", Name: "new.test-domain.com", Type: "TXT", Content: "hola", TTL: 1},
+	}
+
+	changes := core.DiffRecords(current, desired)
+
+	var got []string
+	for _, change := range changes {
+		record := change.Record()
+		got = append(got, fmt.Sprintf("%s %s %s", change.Action, record.Name, record.Content))
+	}
+	want := []string{
+		"delete old.test-domain.com www.test-domain.com",
+		"delete rr.test-domain.com 192.0.2.10",
+		"update api.test-domain.com 192.0.2.20",
+		"create new.test-domain.com hola",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Cambios incorrectos:\nesperado %v\nobtenido %v", want, got)
+	}
+
+	if changes := core.DiffRecords(current, current); len(changes) != 0 {
+		t.Errorf("No se esperaban cambios al comparar la zona consigo misma: %v", changes)
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	server, client := newTestServer(t)
+	dir := t.TempDir()
+
+	// Más registros que el tamaño de página para verificar que se listan todos
+	const total = coretest.DefaultPageSize + 5
+	for i := 0; i < total; i++ {
+		server.Add(core.DNSRecord{Name: fmt.Sprintf("host%d.test-domain.com", i), Type: "A", Content: "192.0.2.1", TTL: 1})
+	}
+
+	records, err := client.ListDNSRecords()
+	if err != nil {
+		t.Fatalf("Error al listar los registros DNS: %v", err)
+	}
+	if len(records) != total {
+		t.Fatalf("Número de registros incorrecto: esperado %d, obtenido %d", total, len(records))
+	}
+
+	if _, err := core.SaveSnapshot(dir, core.NewSnapshot("base", server.Config(), records)); err != nil {
+		t.Fatalf("Error al guardar la instantánea: %v", err)
+	}
+	if _, err := core.SaveSnapshot(dir, core.NewSnapshot("base", server.Config(), records)); err == nil {
+		t.Error("Se esperaba un error al sobrescribir una instantánea existente")
+	}
+
+	// Modificar la zona después de la instantánea
+	if err := client.DeleteDNSRecord(records[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	changed := *records[1]
+	changed.Content = "198.51.100.1"
+	if err := client.UpdateDNSRecord(changed.ID, &changed); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.CreateDNSRecord(&core.DNSRecord{Name: "extra.test-domain.com", Type: "A", Content: "192.0.2.9", TTL: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := core.LoadSnapshot(dir, "base")
+	if err != nil {
+		t.Fatalf("Error al cargar la instantánea: %v", err)
+	}
+	live, err := client.ListDNSRecords()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := core.DiffRecords(live, snapshot.Records)
+	if len(changes) != 3 {
+		t.Fatalf("Número de cambios incorrecto: esperado 3, obtenido %d", len(changes))
+	}
+	if err := core.ApplyRecordChanges(client, changes); err != nil {
+		t.Fatalf("Error al restaurar la instantánea: %v", err)
+	}
+
+	live, err = client.ListDNSRecords()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes := core.DiffRecords(live, snapshot.Records); len(changes) != 0 {
+		t.Errorf("La zona no quedó igual que la instantánea: %d cambios pendientes", len(changes))
+	}
+
+	snapshots, err := core.ListSnapshots(dir)
+	if err != nil || len(snapshots) != 1 || snapshots[0].Name != "base" {
+		t.Errorf("Listado de instantáneas incorrecto: %v, %v", snapshots, err)
+	}
+}
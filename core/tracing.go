@@ -0,0 +1,130 @@
+package core
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Nombres del servicio y del instrumentador en las trazas
+const (
+	serviceName = "cloudflare-domain-controller"
+	tracerName  = "cloudflare-domain-controller/core"
+)
+
+// exportTimeout limita cada envío al recolector. Un recolector inaccesible no debe retrasar
+// los comandos, así que se usa un tiempo corto salvo que OTEL_EXPORTER_OTLP_*TIMEOUT indique otro.
+const exportTimeout = 2 * time.Second
+
+// tracer crea los spans del paquete. Por defecto no registra nada, de modo que sin
+// configurar las trazas el comportamiento y el rendimiento no cambian.
+var tracer trace.Tracer = noop.NewTracerProvider().Tracer(tracerName)
+
+// SetTracerProvider configura el proveedor de trazas que usa el paquete
+func SetTracerProvider(provider trace.TracerProvider) {
+	tracer = provider.Tracer(tracerName)
+}
+
+// TracingEnabled indica si hay que exportar trazas: con un endpoint explícito o con las
+// variables estándar OTEL_EXPORTER_OTLP_ENDPOINT u OTEL_EXPORTER_OTLP_TRACES_ENDPOINT,
+// salvo que OTEL_SDK_DISABLED sea true
+func TracingEnabled(endpoint string) bool {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return false
+	}
+	return endpoint != "" || os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// NewTracerProvider crea un proveedor que exporta las trazas por OTLP/HTTP. Si endpoint está
+// vacío el exportador usa las variables OTEL_EXPORTER_OTLP_*. Los spans se exportan en
+// segundo plano por lotes, de modo que las llamadas a la API no esperan al recolector; quien
+// lo crea debe llamar a Shutdown antes de terminar el proceso para enviar los pendientes.
+// Los envíos fallidos no se reintentan: se pierde el lote en lugar de bloquear el comando.
+func NewTracerProvider(ctx context.Context, endpoint string) (*sdktrace.TracerProvider, error) {
+	options := []otlptracehttp.Option{otlptracehttp.WithRetry(otlptracehttp.RetryConfig{Enabled: false})}
+	if endpoint != "" {
+		options = append(options, otlptracehttp.WithEndpointURL(endpoint))
+	}
+	if os.Getenv("OTEL_EXPORTER_OTLP_TIMEOUT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_TIMEOUT") == "" {
+		options = append(options, otlptracehttp.WithTimeout(exportTimeout))
+	}
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	// OTEL_SERVICE_NAME y OTEL_RESOURCE_ATTRIBUTES tienen prioridad sobre el nombre por defecto
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", serviceName)),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Un recolector caído no debe llenar la salida del comando: los errores de exportación
+	// solo se muestran con --debug
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.Debug("error al exportar las trazas", "error", err)
+	}))
+	return sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res)), nil
+}
+
+// ContextFromEnvironment añade a ctx la traza indicada en las variables TRACEPARENT y
+// TRACESTATE, para que los spans cuelguen del paso del pipeline que ejecuta el comando
+func ContextFromEnvironment(ctx context.Context) context.Context {
+	carrier := propagation.MapCarrier{}
+	if value := os.Getenv("TRACEPARENT"); value != "" {
+		carrier.Set("traceparent", value)
+	}
+	if value := os.Getenv("TRACESTATE"); value != "" {
+		carrier.Set("tracestate", value)
+	}
+	return propagation.TraceContext{}.Extract(ctx, carrier)
+}
+
+// startSpan abre un span para una operación del cliente y devuelve una copia del cliente
+// cuyas solicitudes quedan dentro de ese span
+func (c *CloudflareClient) startSpan(operation string, attrs ...attribute.KeyValue) (*CloudflareClient, trace.Span) {
+	attrs = append(attrs, attribute.String("cloudflare.zone.id", c.config.ZoneID))
+	if c.config.DomainName != "" {
+		attrs = append(attrs, attribute.String("cloudflare.zone.name", c.config.DomainName))
+	}
+	ctx, span := tracer.Start(c.context(), "cloudflare."+operation, trace.WithAttributes(attrs...))
+	return c.WithContext(ctx), span
+}
+
+// recordAttributes describe un registro en los atributos de un span
+func recordAttributes(record *DNSRecord) []attribute.KeyValue {
+	if record == nil {
+		return nil
+	}
+	return []attribute.KeyValue{
+		attribute.String("dns.record.name", record.Name),
+		attribute.String("dns.record.type", record.Type),
+	}
+}
+
+// endSpan cierra el span con el resultado de la operación
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.SetAttributes(attribute.String("cfdc.result", ReconcileFailure))
+	} else {
+		span.SetAttributes(attribute.String("cfdc.result", ReconcileSuccess))
+	}
+	span.End()
+}
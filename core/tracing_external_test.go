@@ -0,0 +1,109 @@
+package core_test
+
+import (
+	"context"
+	"testing"
+
+	"cloudflare-domain-controller/core"
+	"cloudflare-domain-controller/core/coretest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// recordSpans configura un proveedor que guarda los spans en memoria durante el test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	core.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { core.SetTracerProvider(noop.NewTracerProvider()) })
+	return recorder
+}
+
+// findSpan busca un span terminado por su nombre
+func findSpan(t *testing.T, recorder *tracetest.SpanRecorder, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+	for _, span := range recorder.Ended() {
+		if span.Name() == name {
+			return span
+		}
+	}
+	t.Fatalf("No se encontró el span %s", name)
+	return nil
+}
+
+// spanAttribute devuelve el valor de un atributo de un span
+func spanAttribute(span sdktrace.ReadOnlySpan, key string) attribute.Value {
+	for _, attr := range span.Attributes() {
+		if string(attr.Key) == key {
+			return attr.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestClientSpans(t *testing.T) {
+	recorder := recordSpans(t)
+	server, client := newTestServer(t)
+	id := server.Add(core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+
+	// La traza del llamante llega por TRACEPARENT
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	t.Setenv("TRACEPARENT", "00-"+traceID+"-00f067aa0ba902b7-01")
+	client = client.WithContext(core.ContextFromEnvironment(context.Background()))
+
+	if _, err := client.FindDNSRecords("web.test-domain.com", "A"); err != nil {
+		t.Fatalf("Error inesperado: %v", err)
+	}
+	if err := client.DeleteDNSRecord("no-existe"); err == nil {
+		t.Fatal("Se esperaba un error al eliminar un registro inexistente")
+	}
+	if _, err := client.GetDNSRecord(id); err != nil {
+		t.Fatalf("Error inesperado: %v", err)
+	}
+
+	find := findSpan(t, recorder, "cloudflare.FindDNSRecords")
+	if got := find.SpanContext().TraceID().String(); got != traceID {
+		t.Errorf("El span no continúa la traza del llamante: %s", got)
+	}
+	if find.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("Padre incorrecto: %s", find.Parent().SpanID())
+	}
+	for key, want := range map[string]string{
+		"cloudflare.zone.id":   coretest.ZoneID,
+		"cloudflare.zone.name": "test-domain.com",
+		"dns.record.type":      "A",
+		"cfdc.result":          core.ReconcileSuccess,
+	} {
+		if got := spanAttribute(find, key).AsString(); got != want {
+			t.Errorf("Atributo %s: esperado %s, obtenido %s", key, want, got)
+		}
+	}
+
+	// La solicitud HTTP cuelga del span de la operación
+	var request sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "HTTP GET" && span.Parent().SpanID() == find.SpanContext().SpanID() {
+			request = span
+		}
+	}
+	if request == nil {
+		t.Fatal("No se encontró el span de la solicitud HTTP")
+	}
+	if got := spanAttribute(request, "http.response.status_code").AsInt64(); got != 200 {
+		t.Errorf("Código de estado en el span: %d", got)
+	}
+	if got := spanAttribute(request, "url.template").AsString(); got != "/zones/:zone/dns_records" {
+		t.Errorf("Plantilla de URL en el span: %s", got)
+	}
+
+	del := findSpan(t, recorder, "cloudflare.DeleteDNSRecord")
+	if del.Status().Code != codes.Error || spanAttribute(del, "cfdc.result").AsString() != core.ReconcileFailure {
+		t.Errorf("El span de un error debe marcarse como fallido: %v", del.Status())
+	}
+	if got := spanAttribute(del, "dns.record.id").AsString(); got != "no-existe" {
+		t.Errorf("ID del registro en el span: %s", got)
+	}
+}
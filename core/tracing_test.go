@@ -0,0 +1,70 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTracingDisabledByDefault(t *testing.T) {
+	client := NewCloudflareClient(&Config{APIToken: "test-token", ZoneID: "test-zone-id", DomainName: "test-domain.com"})
+	_, span := client.startSpan("ListDNSRecords")
+	defer span.End()
+	if span.SpanContext().IsValid() || span.IsRecording() {
+		t.Error("Sin configurar las trazas no se deben registrar spans")
+	}
+}
+
+func TestTracingEnabled(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	t.Setenv("OTEL_SDK_DISABLED", "")
+	if TracingEnabled("") {
+		t.Error("Sin endpoint las trazas deben estar desactivadas")
+	}
+	if !TracingEnabled("http://localhost:4318") {
+		t.Error("Con --otlp-endpoint las trazas deben estar activadas")
+	}
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318")
+	if !TracingEnabled("") {
+		t.Error("Con OTEL_EXPORTER_OTLP_ENDPOINT las trazas deben estar activadas")
+	}
+	t.Setenv("OTEL_SDK_DISABLED", "true")
+	if TracingEnabled("http://localhost:4318") {
+		t.Error("OTEL_SDK_DISABLED debe desactivar las trazas")
+	}
+}
+
+func TestTracerProviderDoesNotBlock(t *testing.T) {
+	// Un recolector que no responde no debe retrasar las operaciones
+	release := make(chan struct{})
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer collector.Close()
+	defer close(release)
+
+	provider, err := NewTracerProvider(context.Background(), collector.URL)
+	if err != nil {
+		t.Fatalf("Error al crear el proveedor: %v", err)
+	}
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		_, span := provider.Tracer(tracerName).Start(context.Background(), "prueba")
+		span.End()
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Terminar los spans tardó %v", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	provider.Shutdown(ctx)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Shutdown no respetó el plazo: %v", elapsed)
+	}
+}
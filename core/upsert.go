@@ -0,0 +1,76 @@
+package core
+
+import "fmt"
+
+// Resultados posibles de UpsertDNSRecord
+const (
+	UpsertCreated   = "created"
+	UpsertUpdated   = "updated"
+	UpsertUnchanged = "unchanged"
+)
+
+// UpsertResult describe lo que hizo UpsertDNSRecord. Before solo se rellena al actualizar.
+type UpsertResult struct {
+	Status string
+	Before *DNSRecord
+	Record *DNSRecord
+}
+
+// UpsertDNSRecord busca el registro por nombre y tipo: lo crea si no existe, lo actualiza si
+// es distinto y no hace nada si ya coincide. Si hay varios registros con el mismo nombre y
+// tipo solo se considera sin cambios el que tenga el mismo contenido; en otro caso es ambiguo.
+func UpsertDNSRecord(provider DNSProvider, record *DNSRecord) (*UpsertResult, error) {
+	existing, err := provider.FindDNSRecords(record.Name, record.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, current := range existing {
+		if upsertMatches(current, record) {
+			return &UpsertResult{Status: UpsertUnchanged, Record: current}, nil
+		}
+	}
+
+	switch len(existing) {
+	case 0:
+		if err := provider.CreateDNSRecord(record); err != nil {
+			return nil, err
+		}
+		return &UpsertResult{Status: UpsertCreated, Record: record}, nil
+	case 1:
+		before := existing[0]
+		updated := copyRecord(before)
+		applyUpsert(updated, record)
+		if err := provider.UpdateDNSRecord(updated.ID, updated); err != nil {
+			return nil, err
+		}
+		return &UpsertResult{Status: UpsertUpdated, Before: before, Record: updated}, nil
+	}
+	return nil, fmt.Errorf("hay %d registros %s para %s; no se puede decidir cuál actualizar", len(existing), record.Type, record.Name)
+}
+
+// upsertMatches indica si el registro existente ya tiene los valores deseados.
+// La prioridad y el comentario solo se comparan si se indicaron en desired.
+func upsertMatches(current, desired *DNSRecord) bool {
+	if !sameContent(desired.Type, current.Content, desired.Content) || current.TTL != desired.TTL || current.Proxied != desired.Proxied {
+		return false
+	}
+	if desired.Priority != nil && !samePriority(current.Priority, desired.Priority) {
+		return false
+	}
+	return desired.Comment == "" || current.Comment == desired.Comment
+}
+
+// applyUpsert copia en record los valores deseados que comprueba upsertMatches
+func applyUpsert(record, desired *DNSRecord) {
+	record.Content = desired.Content
+	record.TTL = desired.TTL
+	record.Proxied = desired.Proxied
+	if desired.Priority != nil {
+		priority := *desired.Priority
+		record.Priority = &priority
+	}
+	if desired.Comment != "" {
+		record.Comment = desired.Comment
+	}
+}
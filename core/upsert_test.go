@@ -0,0 +1,56 @@
+package core_test
+
+import (
+	"testing"
+
+	"cloudflare-domain-controller/core"
+)
+
+func TestUpsertDNSRecord(t *testing.T) {
+	server, client := newTestServer(t)
+
+	record := &core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1}
+	result, err := core.UpsertDNSRecord(client, record)
+	if err != nil {
+		t.Fatalf("Error al crear el registro: %v", err)
+	}
+	if result.Status != core.UpsertCreated || len(server.Records()) != 1 {
+		t.Fatalf("Se esperaba la creación del registro: %+v", result)
+	}
+
+	result, err = core.UpsertDNSRecord(client, &core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+	if err != nil {
+		t.Fatalf("Error en la segunda llamada: %v", err)
+	}
+	if result.Status != core.UpsertUnchanged {
+		t.Errorf("Un registro idéntico no debe modificarse: %+v", result)
+	}
+
+	result, err = core.UpsertDNSRecord(client, &core.DNSRecord{Name: "web.test-domain.com", Type: "A", Content: "192.0.2.2", TTL: 300})
+	if err != nil {
+		t.Fatalf("Error al actualizar el registro: %v", err)
+	}
+	if result.Status != core.UpsertUpdated || result.Before.Content != "192.0.2.1" {
+		t.Errorf("Se esperaba la actualización del registro: %+v", result)
+	}
+	if stored := server.Record(record.ID); stored == nil || stored.Content != "192.0.2.2" || stored.TTL != 300 {
+		t.Errorf("El registro no se actualizó: %+v", stored)
+	}
+	if count := len(server.Records()); count != 1 {
+		t.Errorf("No se deben crear registros adicionales: %d", count)
+	}
+}
+
+func TestUpsertDNSRecordAmbiguous(t *testing.T) {
+	server, client := newTestServer(t)
+	server.Add(core.DNSRecord{Name: "rr.test-domain.com", Type: "A", Content: "192.0.2.10", TTL: 1})
+	server.Add(core.DNSRecord{Name: "rr.test-domain.com", Type: "A", Content: "192.0.2.11", TTL: 1})
+
+	result, err := core.UpsertDNSRecord(client, &core.DNSRecord{Name: "rr.test-domain.com", Type: "A", Content: "192.0.2.11", TTL: 1})
+	if err != nil || result.Status != core.UpsertUnchanged {
+		t.Errorf("El registro con el mismo contenido debe quedar sin cambios: %+v, %v", result, err)
+	}
+	if _, err := core.UpsertDNSRecord(client, &core.DNSRecord{Name: "rr.test-domain.com", Type: "A", Content: "192.0.2.12", TTL: 1}); err == nil {
+		t.Error("Se esperaba un error con varios registros candidatos")
+	}
+}
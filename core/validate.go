@@ -0,0 +1,277 @@
+package core
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Límites de los registros DNS admitidos por Cloudflare
+const (
+	maxNameLength    = 253
+	maxLabelLength   = 63
+	maxTXTLength     = 2048
+	maxTXTString     = 255
+	minTTL           = 30
+	maxTTL           = 86400
+	automaticTTL     = 1
+	maxPriorityValue = 65535
+)
+
+// recordTypes son los tipos de registro aceptados por la API de Cloudflare
+var recordTypes = map[string]bool{
+	"A": true, "AAAA": true, "CAA": true, "CERT": true, "CNAME": true, "DNSKEY": true,
+	"DS": true, "HTTPS": true, "LOC": true, "MX": true, "NAPTR": true, "NS": true,
+	"PTR": true, "SMIMEA": true, "SRV": true, "SSHFP": true, "SVCB": true, "TLSA": true,
+	"TXT": true, "URI": true,
+}
+
+// caaTags son las etiquetas CAA admitidas
+var caaTags = map[string]bool{
+	"issue": true, "issuewild": true, "iodef": true, "issuemail": true, "issuevmc": true,
+}
+
+// ValidationError describe un problema en un campo de un registro
+type ValidationError struct {
+	Field   string
+	Value   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("%s: %s", e.Field, e.Message)
+	}
+	return fmt.Sprintf("%s %q: %s", e.Field, e.Value, e.Message)
+}
+
+// ValidationErrors agrupa todos los problemas encontrados en un registro
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return "registro inválido: " + strings.Join(messages, "; ")
+}
+
+// IsProxiable indica si los registros del tipo indicado pueden pasar por el proxy de Cloudflare
+func IsProxiable(recordType string) bool {
+	switch strings.ToUpper(recordType) {
+	case "A", "AAAA", "CNAME":
+		return true
+	}
+	return false
+}
+
+// ValidateRecord comprueba el nombre, el tipo, el contenido, el TTL y el proxy de un registro
+// antes de enviarlo a la API. Devuelve ValidationErrors si encuentra algún problema.
+func ValidateRecord(record *DNSRecord) error {
+	var errs ValidationErrors
+	add := func(field, value, message string) {
+		errs = append(errs, &ValidationError{Field: field, Value: value, Message: message})
+	}
+
+	if message := checkHostname(record.Name, true); message != "" {
+		add("name", record.Name, message)
+	}
+
+	recordType := strings.ToUpper(record.Type)
+	if !recordTypes[recordType] {
+		add("type", record.Type, "tipo de registro desconocido")
+	}
+
+	if record.Content == "" {
+		add("content", "", "el contenido es obligatorio")
+	} else if recordType == "MX" && record.Content == "." {
+		// MX nulo (RFC 7505): indica que el dominio no acepta correo
+		if record.Priority == nil || *record.Priority != 0 {
+			add("content", record.Content, "el MX nulo solo se admite con prioridad 0")
+		}
+	} else if message := checkContent(recordType, record.Content); message != "" {
+		add("content", record.Content, message)
+	}
+
+	if record.TTL != automaticTTL && (record.TTL < minTTL || record.TTL > maxTTL) {
+		add("ttl", strconv.Itoa(record.TTL), fmt.Sprintf("debe ser 1 (automático) o estar entre %d y %d", minTTL, maxTTL))
+	}
+
+	if record.Proxied && !IsProxiable(recordType) {
+		add("proxied", "", fmt.Sprintf("los registros %s no se pueden pasar por el proxy", recordType))
+	}
+
+	if record.Priority != nil && (*record.Priority < 0 || *record.Priority > maxPriorityValue) {
+		add("priority", strconv.Itoa(*record.Priority), fmt.Sprintf("debe estar entre 0 y %d", maxPriorityValue))
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// checkContent valida el contenido según el tipo de registro y devuelve un mensaje si es inválido
+func checkContent(recordType, content string) string {
+	switch recordType {
+	case "A":
+		if addr, err := netip.ParseAddr(content); err != nil || !addr.Is4() {
+			return "se esperaba una dirección IPv4"
+		}
+	case "AAAA":
+		if addr, err := netip.ParseAddr(content); err != nil || !addr.Is6() || addr.Is4In6() {
+			return "se esperaba una dirección IPv6"
+		}
+	case "CNAME", "NS", "MX", "PTR":
+		if message := checkHostname(content, false); message != "" {
+			return "se esperaba un nombre de host: " + message
+		}
+	case "TXT":
+		return checkTXT(content)
+	case "CAA":
+		return checkCAA(content)
+	}
+	return ""
+}
+
+// checkHostname valida la sintaxis de un nombre DNS. Los comodines solo se aceptan
+// en los nombres de registro y únicamente como la etiqueta más a la izquierda.
+func checkHostname(name string, allowWildcard bool) string {
+	name = strings.TrimSuffix(name, ".")
+	if name == "" {
+		return "el nombre está vacío"
+	}
+	if len(name) > maxNameLength {
+		return fmt.Sprintf("supera los %d caracteres", maxNameLength)
+	}
+
+	for i, label := range strings.Split(name, ".") {
+		if label == "*" {
+			if !allowWildcard || i != 0 {
+				return "el comodín '*' solo se permite como primera etiqueta"
+			}
+			continue
+		}
+		if label == "" {
+			return "contiene una etiqueta vacía"
+		}
+		if len(label) > maxLabelLength {
+			return fmt.Sprintf("la etiqueta %q supera los %d caracteres", label, maxLabelLength)
+		}
+		if strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return fmt.Sprintf("la etiqueta %q no puede empezar ni terminar con '-'", label)
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+				return fmt.Sprintf("la etiqueta %q contiene el carácter inválido %q", label, r)
+			}
+		}
+	}
+	return ""
+}
+
+// checkTXT valida la longitud total de un registro TXT y la de cada cadena entre comillas
+func checkTXT(content string) string {
+	if len(content) > maxTXTLength {
+		return fmt.Sprintf("supera los %d caracteres", maxTXTLength)
+	}
+	if !strings.HasPrefix(content, `"`) {
+		return ""
+	}
+	for _, chunk := range splitQuotedStrings(content) {
+		if len(chunk) > maxTXTString {
+			return fmt.Sprintf("cada cadena entre comillas debe tener como máximo %d caracteres; usa ChunkTXT para dividirla", maxTXTString)
+		}
+	}
+	return ""
+}
+
+// checkCAA valida el formato "flags etiqueta valor" de un registro CAA
+func checkCAA(content string) string {
+	fields := strings.SplitN(content, " ", 3)
+	if len(fields) != 3 {
+		return `se esperaba el formato 'flags etiqueta "valor"'`
+	}
+
+	flags, err := strconv.Atoi(fields[0])
+	if err != nil || flags < 0 || flags > 255 {
+		return "los flags deben ser un número entre 0 y 255"
+	}
+
+	tag := strings.ToLower(fields[1])
+	if !caaTags[tag] {
+		return fmt.Sprintf("etiqueta CAA desconocida %q", fields[1])
+	}
+
+	value := strings.Trim(fields[2], `"`)
+	switch tag {
+	case "iodef":
+		if !strings.HasPrefix(value, "mailto:") && !strings.HasPrefix(value, "http://") && !strings.HasPrefix(value, "https://") {
+			return "el valor de iodef debe ser una URL mailto:, http:// o https://"
+		}
+	case "issue", "issuewild":
+		// Un valor vacío o ";" prohíbe la emisión; si no, debe empezar por el dominio de la CA
+		issuer := strings.TrimSpace(strings.SplitN(value, ";", 2)[0])
+		if issuer != "" {
+			if message := checkHostname(issuer, false); message != "" {
+				return "el emisor debe ser un dominio: " + message
+			}
+		}
+	}
+	return ""
+}
+
+// splitQuotedStrings separa un contenido TXT formado por varias cadenas entre comillas
+func splitQuotedStrings(content string) []string {
+	var chunks []string
+	var current strings.Builder
+	inQuotes, escaped := false, false
+	for _, r := range content {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && inQuotes:
+			escaped = true
+		case r == '"':
+			if inQuotes {
+				chunks = append(chunks, current.String())
+				current.Reset()
+			}
+			inQuotes = !inQuotes
+		case inQuotes:
+			current.WriteRune(r)
+		}
+	}
+	if inQuotes {
+		chunks = append(chunks, current.String())
+	}
+	return chunks
+}
+
+// ChunkTXT divide un contenido TXT largo en cadenas entre comillas de como máximo 255 bytes,
+// sin partir los caracteres UTF-8
+func ChunkTXT(content string) string {
+	if len(content) <= maxTXTString || strings.HasPrefix(content, `"`) {
+		return content
+	}
+
+	quote := func(chunk string) string {
+		chunk = strings.ReplaceAll(chunk, `\`, `\\`)
+		return `"` + strings.ReplaceAll(chunk, `"`, `\"`) + `"`
+	}
+
+	var chunks []string
+	for len(content) > maxTXTString {
+		cut := maxTXTString
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		chunks = append(chunks, quote(content[:cut]))
+		content = content[cut:]
+	}
+	chunks = append(chunks, quote(content))
+	return strings.Join(chunks, " ")
+}
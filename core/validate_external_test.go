@@ -0,0 +1,21 @@
+package core_test
+
+import (
+	"errors"
+	"testing"
+
+	"cloudflare-domain-controller/core"
+)
+
+func TestCreateDNSRecordValidatesBeforeRequest(t *testing.T) {
+	server, client := newTestServer(t)
+
+	err := client.CreateDNSRecord(&core.DNSRecord{Name: "foo.test-domain.com", Type: "A", Content: "myhost.com", TTL: 1})
+	var errs core.ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Se esperaba un error de validación, obtenido %v", err)
+	}
+	if len(server.Records()) != 0 {
+		t.Error("No se debe enviar a la API un registro inválido")
+	}
+}
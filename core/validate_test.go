@@ -0,0 +1,110 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestValidateRecord(t *testing.T) {
+	priority := 10
+	badPriority := 70000
+	zeroPriority := 0
+
+	tests := []struct {
+		name   string
+		record DNSRecord
+		fields []string
+	}{
+		{"A válido", DNSRecord{Name: "www.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1}, nil},
+		{"A con nombre de host", DNSRecord{Name: "foo.test-domain.com", Type: "A", Content: "myhost.com", TTL: 1}, []string{"content"}},
+		{"A con IPv6", DNSRecord{Name: "foo.test-domain.com", Type: "A", Content: "2001:db8::1", TTL: 1}, []string{"content"}},
+		{"AAAA válido", DNSRecord{Name: "foo.test-domain.com", Type: "AAAA", Content: "2001:db8::1", TTL: 300}, nil},
+		{"AAAA con IPv4", DNSRecord{Name: "foo.test-domain.com", Type: "AAAA", Content: "192.0.2.1", TTL: 1}, []string{"content"}},
+		{"AAAA IPv4 mapeada", DNSRecord{Name: "foo.test-domain.com", Type: "AAAA", Content: "::ffff:192.0.2.1", TTL: 1}, []string{"content"}},
+		{"CNAME válido", DNSRecord{Name: "blog.test-domain.com", Type: "CNAME", Content: "ghs.example.net.", TTL: 1, Proxied: true}, nil},
+		{"CNAME con IP inválida", DNSRecord{Name: "blog.test-domain.com", Type: "CNAME", Content: "http://example.net", TTL: 1}, []string{"content"}},
+		{"MX válido", DNSRecord{Name: "test-domain.com", Type: "MX", Content: "mx1.example.net", TTL: 1, Priority: &priority}, nil},
+		{"MX nulo", DNSRecord{Name: "test-domain.com", Type: "MX", Content: ".", TTL: 1, Priority: &zeroPriority}, nil},
+		{"MX nulo con prioridad", DNSRecord{Name: "test-domain.com", Type: "MX", Content: ".", TTL: 1, Priority: &priority}, []string{"content"}},
+		{"MX nulo sin prioridad", DNSRecord{Name: "test-domain.com", Type: "MX", Content: ".", TTL: 1}, []string{"content"}},
+		{"MX con prioridad inválida", DNSRecord{Name: "test-domain.com", Type: "MX", Content: "mx1.example.net", TTL: 1, Priority: &badPriority}, []string{"priority"}},
+		{"NS con guion inicial", DNSRecord{Name: "sub.test-domain.com", Type: "NS", Content: "-ns.example.net", TTL: 1}, []string{"content"}},
+		{"TXT largo sin comillas", DNSRecord{Name: "txt.test-domain.com", Type: "TXT", Content: strings.Repeat("a", 300), TTL: 1}, nil},
+		{"TXT con cadena demasiado larga", DNSRecord{Name: "txt.test-domain.com", Type: "TXT", Content: `"` + strings.Repeat("a", 300) + `"`, TTL: 1}, []string{"content"}},
+		{"TXT demasiado largo", DNSRecord{Name: "txt.test-domain.com", Type: "TXT", Content: strings.Repeat("a", 2049), TTL: 1}, []string{"content"}},
+		{"TXT con guion bajo", DNSRecord{Name: "_dmarc.test-domain.com", Type: "TXT", Content: "v=DMARC1; p=none", TTL: 1}, nil},
+		{"CAA válido", DNSRecord{Name: "test-domain.com", Type: "CAA", Content: `0 issue "letsencrypt.org"`, TTL: 1}, nil},
+		{"CAA que prohíbe emisión", DNSRecord{Name: "test-domain.com", Type: "CAA", Content: `0 issuewild ";"`, TTL: 1}, nil},
+		{"CAA iodef", DNSRecord{Name: "test-domain.com", Type: "CAA", Content: `0 iodef "mailto:seguridad@test-domain.com"`, TTL: 1}, nil},
+		{"CAA etiqueta desconocida", DNSRecord{Name: "test-domain.com", Type: "CAA", Content: `0 issuer "letsencrypt.org"`, TTL: 1}, []string{"content"}},
+		{"CAA iodef inválido", DNSRecord{Name: "test-domain.com", Type: "CAA", Content: `0 iodef "seguridad@test-domain.com"`, TTL: 1}, []string{"content"}},
+		{"CAA flags inválidos", DNSRecord{Name: "test-domain.com", Type: "CAA", Content: `300 issue "letsencrypt.org"`, TTL: 1}, []string{"content"}},
+		{"comodín válido", DNSRecord{Name: "*.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1}, nil},
+		{"comodín en medio", DNSRecord{Name: "a.*.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1}, []string{"name"}},
+		{"etiqueta demasiado larga", DNSRecord{Name: strings.Repeat("a", 64) + ".test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1}, []string{"name"}},
+		{"nombre demasiado largo", DNSRecord{Name: strings.Repeat("abcdefghi.", 26) + "com", Type: "A", Content: "192.0.2.1", TTL: 1}, []string{"name"}},
+		{"etiqueta vacía", DNSRecord{Name: "a..test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1}, []string{"name"}},
+		{"carácter inválido", DNSRecord{Name: "mi página.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 1}, []string{"name"}},
+		{"TTL fuera de rango", DNSRecord{Name: "www.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 10}, []string{"ttl"}},
+		{"TTL cero", DNSRecord{Name: "www.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 0}, []string{"ttl"}},
+		{"TTL máximo", DNSRecord{Name: "www.test-domain.com", Type: "A", Content: "192.0.2.1", TTL: 86400}, nil},
+		{"TXT con proxy", DNSRecord{Name: "txt.test-domain.com", Type: "TXT", Content: "hola", TTL: 1, Proxied: true}, []string{"proxied"}},
+		{"tipo desconocido", DNSRecord{Name: "www.test-domain.com", Type: "XYZ", Content: "algo", TTL: 1}, []string{"type"}},
+		{"sin contenido", DNSRecord{Name: "www.test-domain.com", Type: "A", TTL: 1}, []string{"content"}},
+		{"varios errores", DNSRecord{Name: "", Type: "MX", Content: "192.0.2.1 extra", TTL: 5, Proxied: true}, []string{"name", "content", "ttl", "proxied"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRecord(&tt.record)
+			if tt.fields == nil {
+				if err != nil {
+					t.Errorf("No se esperaba un error: %v", err)
+				}
+				return
+			}
+
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("Se esperaba ValidationErrors, obtenido %v", err)
+			}
+			var fields []string
+			for _, e := range errs {
+				fields = append(fields, e.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.fields, ",") {
+				t.Errorf("Campos con error incorrectos: esperado %v, obtenido %v (%v)", tt.fields, fields, err)
+			}
+		})
+	}
+}
+
+func TestChunkTXT(t *testing.T) {
+	if got := ChunkTXT("corto"); got != "corto" {
+		t.Errorf("Un contenido corto no debe modificarse: %q", got)
+	}
+
+	long := strings.Repeat("a", 255) + strings.Repeat("b", 100)
+	chunked := ChunkTXT(long)
+	chunks := splitQuotedStrings(chunked)
+	if len(chunks) != 2 || chunks[0] != strings.Repeat("a", 255) || chunks[1] != strings.Repeat("b", 100) {
+		t.Errorf("División incorrecta: %q", chunked)
+	}
+	if err := ValidateRecord(&DNSRecord{Name: "txt.test-domain.com", Type: "TXT", Content: chunked, TTL: 1}); err != nil {
+		t.Errorf("El contenido dividido debería ser válido: %v", err)
+	}
+
+	// Los caracteres de varios bytes no se parten entre dos cadenas
+	accents := strings.Repeat("a", 254) + strings.Repeat("ñ", 10)
+	chunks = splitQuotedStrings(ChunkTXT(accents))
+	if len(chunks) != 2 || chunks[0] != strings.Repeat("a", 254) || chunks[1] != strings.Repeat("ñ", 10) {
+		t.Errorf("División incorrecta con caracteres UTF-8: %q", chunks)
+	}
+	for _, chunk := range chunks {
+		if !utf8.ValidString(chunk) || len(chunk) > maxTXTString {
+			t.Errorf("Cadena inválida: %q", chunk)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package core
+
+import (
+	"context"
+	"net/netip"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Estados de la verificación de un registro contra un servidor DNS
+const (
+	VerifyOK       = "ok"
+	VerifyMismatch = "mismatch"
+	VerifyMissing  = "missing"
+	VerifyError    = "error"
+	VerifySkipped  = "skipped"
+)
+
+// verifyConcurrency limita el número de consultas DNS simultáneas
+const verifyConcurrency = 16
+
+// cloudflareRanges son los rangos de direcciones que Cloudflare usa para los registros con proxy
+// (https://www.cloudflare.com/ips/)
+var cloudflareRanges = []netip.Prefix{
+	netip.MustParsePrefix("173.245.48.0/20"),
+	netip.MustParsePrefix("103.21.244.0/22"),
+	netip.MustParsePrefix("103.22.200.0/22"),
+	netip.MustParsePrefix("103.31.4.0/22"),
+	netip.MustParsePrefix("141.101.64.0/18"),
+	netip.MustParsePrefix("108.162.192.0/18"),
+	netip.MustParsePrefix("190.93.240.0/20"),
+	netip.MustParsePrefix("188.114.96.0/20"),
+	netip.MustParsePrefix("197.234.240.0/22"),
+	netip.MustParsePrefix("198.41.128.0/17"),
+	netip.MustParsePrefix("162.158.0.0/15"),
+	netip.MustParsePrefix("104.16.0.0/13"),
+	netip.MustParsePrefix("104.24.0.0/14"),
+	netip.MustParsePrefix("172.64.0.0/13"),
+	netip.MustParsePrefix("131.0.72.0/22"),
+	netip.MustParsePrefix("2400:cb00::/32"),
+	netip.MustParsePrefix("2606:4700::/32"),
+	netip.MustParsePrefix("2803:f800::/32"),
+	netip.MustParsePrefix("2405:b500::/32"),
+	netip.MustParsePrefix("2405:8100::/32"),
+	netip.MustParsePrefix("2a06:98c0::/29"),
+	netip.MustParsePrefix("2c0f:f248::/32"),
+}
+
+// IsCloudflareIP indica si la dirección pertenece a los rangos del proxy de Cloudflare
+func IsCloudflareIP(address string) bool {
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range cloudflareRanges {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyResult es el resultado de comparar un conjunto de registros con un mismo nombre y tipo
+// con la respuesta de un servidor DNS
+type VerifyResult struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Proxied    bool     `json:"proxied"`
+	Resolver   string   `json:"resolver"`
+	Status     string   `json:"status"`
+	Expected   []string `json:"expected"`
+	Answers    []string `json:"answers"`
+	Missing    []string `json:"missing,omitempty"`
+	Unexpected []string `json:"unexpected,omitempty"`
+	Message    string   `json:"message,omitempty"`
+}
+
+// verifyGroup agrupa los registros de la API con el mismo nombre y tipo
+type verifyGroup struct {
+	name       string
+	recordType string
+	proxied    bool
+	records    []*DNSRecord
+}
+
+// VerifyRecords resuelve los registros en cada servidor indicado y compara las respuestas con el
+// contenido de la API. Los registros con el mismo nombre y tipo se comparan como un conjunto.
+// Para los registros con proxy se espera que todas las respuestas sean direcciones de Cloudflare.
+func VerifyRecords(ctx context.Context, records []*DNSRecord, resolvers []string) []VerifyResult {
+	groups := groupForVerify(records)
+	results := make([]VerifyResult, len(groups)*len(resolvers))
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, verifyConcurrency)
+	for i, group := range groups {
+		for j, resolver := range resolvers {
+			wg.Add(1)
+			go func(index int, group *verifyGroup, resolver string) {
+				defer wg.Done()
+				semaphore <- struct{}{}
+				defer func() { <-semaphore }()
+				results[index] = verifyGroupAt(ctx, group, resolver)
+			}(i*len(resolvers)+j, group, resolver)
+		}
+	}
+	wg.Wait()
+	return results
+}
+
+// VerifyFailed indica si algún resultado muestra una diferencia o un error
+func VerifyFailed(results []VerifyResult) bool {
+	for _, result := range results {
+		if result.Status != VerifyOK && result.Status != VerifySkipped {
+			return true
+		}
+	}
+	return false
+}
+
+// groupForVerify agrupa los registros por nombre, tipo y proxy, en orden estable
+func groupForVerify(records []*DNSRecord) []*verifyGroup {
+	var groups []*verifyGroup
+	index := make(map[string]*verifyGroup)
+	for _, record := range records {
+		name := normalizeHostname(record.Name)
+		recordType := strings.ToUpper(record.Type)
+		key := name + "/" + recordType
+		if record.Proxied {
+			key += "/proxied"
+		}
+		group, ok := index[key]
+		if !ok {
+			group = &verifyGroup{name: name, recordType: recordType, proxied: record.Proxied}
+			index[key] = group
+			groups = append(groups, group)
+		}
+		group.records = append(group.records, record)
+	}
+	return groups
+}
+
+// verifyGroupAt consulta un grupo de registros en un servidor y clasifica el resultado
+func verifyGroupAt(ctx context.Context, group *verifyGroup, resolver string) VerifyResult {
+	result := VerifyResult{Name: group.name, Type: group.recordType, Proxied: group.proxied, Resolver: resolver, Answers: []string{}}
+	for _, record := range group.records {
+		result.Expected = append(result.Expected, record.Content)
+	}
+
+	qtype := queryType(group.records[0])
+	if _, ok := dnsTypes[qtype]; !ok {
+		result.Status = VerifySkipped
+		result.Message = "tipo de registro no admitido en las consultas DNS"
+		return result
+	}
+
+	answers, err := QueryDNS(ctx, resolver, group.name, qtype)
+	if err != nil {
+		result.Status = VerifyError
+		result.Message = err.Error()
+		return result
+	}
+	result.Answers = answers
+	sort.Strings(result.Answers)
+
+	if len(answers) == 0 {
+		result.Status = VerifyMissing
+		result.Missing = result.Expected
+		return result
+	}
+
+	if group.proxied {
+		for _, answer := range answers {
+			if !IsCloudflareIP(answer) {
+				result.Unexpected = append(result.Unexpected, answer)
+			}
+		}
+		if len(result.Unexpected) > 0 {
+			result.Status = VerifyMismatch
+			result.Message = "el registro tiene proxy pero se sirven direcciones fuera de Cloudflare"
+			return result
+		}
+		result.Status = VerifyOK
+		return result
+	}
+
+	if !comparableContent(group.recordType) {
+		result.Status = VerifyOK
+		result.Message = "solo se comprobó que el registro existe"
+		return result
+	}
+
+	for _, record := range group.records {
+		if !answerMatches(record, answers) {
+			result.Missing = append(result.Missing, record.Content)
+		}
+	}
+	for _, answer := range answers {
+		found := false
+		for _, record := range group.records {
+			if answerMatches(record, []string{answer}) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result.Unexpected = append(result.Unexpected, answer)
+		}
+	}
+	if len(result.Missing) > 0 || len(result.Unexpected) > 0 {
+		result.Status = VerifyMismatch
+	} else {
+		result.Status = VerifyOK
+	}
+	return result
+}
+
+// comparableContent indica si las respuestas DNS del tipo se pueden comparar con el contenido de la API
+func comparableContent(recordType string) bool {
+	switch recordType {
+	case "A", "AAAA", "CNAME", "NS", "MX", "PTR", "TXT":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestIsCloudflareIP(t *testing.T) {
+	tests := map[string]bool{
+		"104.16.132.229":        true,
+		"172.67.1.1":            true,
+		"2606:4700:3030::1":     true,
+		"::ffff:104.16.132.229": true,
+		"192.0.2.1":             false,
+		"2001:db8::1":           false,
+		"no es una ip":          false,
+	}
+	for address, want := range tests {
+		if got := IsCloudflareIP(address); got != want {
+			t.Errorf("IsCloudflareIP(%q): esperado %v, obtenido %v", address, want, got)
+		}
+	}
+}
+
+func TestVerifyRecords(t *testing.T) {
+	stub := newStubDNS(t)
+	stub.set("web.test-domain.com", dnsmessage.TypeA, &dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}})
+	stub.set("rr.test-domain.com", dnsmessage.TypeA,
+		&dnsmessage.AResource{A: [4]byte{192, 0, 2, 10}},
+		&dnsmessage.AResource{A: [4]byte{192, 0, 2, 99}})
+	stub.set("cdn.test-domain.com", dnsmessage.TypeA, &dnsmessage.AResource{A: [4]byte{104, 16, 0, 1}})
+	stub.set("fuga.test-domain.com", dnsmessage.TypeA, &dnsmessage.AResource{A: [4]byte{192, 0, 2, 5}})
+	stub.set("blog.test-domain.com", dnsmessage.TypeA, &dnsmessage.AResource{A: [4]byte{172, 67, 0, 1}})
+	stub.set("lab.test-domain.com", dnsmessage.TypeNS, &dnsmessage.NSResource{NS: dnsmessage.MustNewName("ns-viejo.example.net.")})
+
+	records := []*DNSRecord{
+		{Name: "web.test-domain.com", Type: "A", Content: "192.0.2.1"},
+		{Name: "rr.test-domain.com", Type: "A", Content: "192.0.2.10"},
+		{Name: "rr.test-domain.com", Type: "A", Content: "192.0.2.11"},
+		{Name: "cdn.test-domain.com", Type: "A", Content: "192.0.2.2", Proxied: true},
+		{Name: "fuga.test-domain.com", Type: "A", Content: "192.0.2.5", Proxied: true},
+		{Name: "blog.test-domain.com", Type: "CNAME", Content: "ghs.example.net", Proxied: true},
+		{Name: "falta.test-domain.com", Type: "AAAA", Content: "2001:db8::1"},
+		{Name: "lab.test-domain.com", Type: "NS", Content: "ns1.example.net"},
+		{Name: "test-domain.com", Type: "LOC", Content: "51 30 12.748 N 0 7 39.611 W 0.00m"},
+	}
+
+	results := VerifyRecords(context.Background(), records, []string{stub.addr()})
+	want := []struct {
+		name   string
+		status string
+	}{
+		{"web.test-domain.com", VerifyOK},
+		{"rr.test-domain.com", VerifyMismatch},
+		{"cdn.test-domain.com", VerifyOK},
+		{"fuga.test-domain.com", VerifyMismatch},
+		{"blog.test-domain.com", VerifyOK},
+		{"falta.test-domain.com", VerifyMissing},
+		{"lab.test-domain.com", VerifyMismatch},
+		{"test-domain.com", VerifySkipped},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("Número de resultados incorrecto: esperado %d, obtenido %d", len(want), len(results))
+	}
+	for i, w := range want {
+		if results[i].Name != w.name || results[i].Status != w.status {
+			t.Errorf("Resultado %d incorrecto: esperado %s %s, obtenido %+v", i, w.name, w.status, results[i])
+		}
+	}
+
+	rr := results[1]
+	if len(rr.Missing) != 1 || rr.Missing[0] != "192.0.2.11" || len(rr.Unexpected) != 1 || rr.Unexpected[0] != "192.0.2.99" {
+		t.Errorf("Diferencias incorrectas: %+v", rr)
+	}
+	if !VerifyFailed(results) {
+		t.Error("VerifyFailed debe detectar las diferencias")
+	}
+	if VerifyFailed(results[:1]) {
+		t.Error("VerifyFailed no debe fallar sin diferencias")
+	}
+}